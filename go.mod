@@ -8,6 +8,7 @@ require (
 	github.com/google/uuid v1.1.1
 	github.com/gorilla/handlers v1.4.0
 	github.com/gorilla/mux v1.7.1
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/lib/pq v1.1.1
 	github.com/onsi/gomega v1.5.0
 	github.com/rs/cors v1.6.0
@@ -19,4 +20,5 @@ require (
 	golang.org/x/net v0.0.0-20200114155413-6afb5195e5aa // indirect
 	golang.org/x/sys v0.0.0-20200124204421-9fbb57f87de9 // indirect
 	golang.org/x/tools v0.0.0-20200129014352-cf670267be10 // indirect
+	rsc.io/qr v0.2.0
 )
@@ -0,0 +1,305 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// channel is the Postgres channel the events are sent over
+const channel = "sqmgr_pool_events"
+
+// maxPayloadSize is just under the Postgres limit for a NOTIFY payload. Any event that is larger than this will
+// be sent without its data and clients are expected to fetch the resource again.
+const maxPayloadSize = 7900
+
+// subscriptionBuffer is the number of events that can be queued for a subscriber before events are dropped
+const subscriptionBuffer = 32
+
+const (
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+	pingInterval         = time.Minute
+)
+
+// ErrClosed is returned when trying to publish to a broker that has been closed
+var ErrClosed = errors.New("broker: closed")
+
+// EventType is the type of event that happened within a pool
+type EventType string
+
+// Event types
+const (
+	EventSquareClaimed      EventType = "squareClaimed"
+	EventSquareUnclaimed    EventType = "squareUnclaimed"
+	EventSquareRenamed      EventType = "squareRenamed"
+	EventSquareStateChanged EventType = "squareStateChanged"
+	EventSquareHeld         EventType = "squareHeld"
+	EventSquareTraded       EventType = "squareTraded"
+	EventTradeUpdated       EventType = "tradeUpdated"
+	EventNumbersDrawn       EventType = "numbersDrawn"
+	EventAnnotationSaved    EventType = "annotationSaved"
+	EventAnnotationDeleted  EventType = "annotationDeleted"
+	EventScoreSaved         EventType = "scoreSaved"
+	EventScoreDeleted       EventType = "scoreDeleted"
+	EventPoolLocked         EventType = "poolLocked"
+	EventPoolUnlocked       EventType = "poolUnlocked"
+	EventPoolLockReminder   EventType = "poolLockReminder"
+	EventPoolUpdated        EventType = "poolUpdated"
+	EventGridCreated        EventType = "gridCreated"
+	EventGridUpdated        EventType = "gridUpdated"
+	EventGridDeleted        EventType = "gridDeleted"
+	EventGridsReordered     EventType = "gridsReordered"
+)
+
+// Event is something that happened within a pool
+type Event struct {
+	PoolID  int64           `json:"poolId"`
+	Type    EventType       `json:"type"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Created time.Time       `json:"created"`
+}
+
+// Broker will fan events out to the subscribers of a pool. When it is listening, every event is sent through
+// Postgres so that subscribers connected to any instance of the API will receive it.
+type Broker struct {
+	db          *sql.DB
+	listener    *pq.Listener
+	subscribers map[int64]map[*Subscription]bool
+	closed      bool
+	mu          sync.RWMutex
+	wg          sync.WaitGroup
+}
+
+// New returns a new Broker. Until Listen() is called, events will only be delivered to subscribers of this broker.
+func New(db *sql.DB) *Broker {
+	return &Broker{
+		db:          db,
+		subscribers: make(map[int64]map[*Subscription]bool),
+	}
+}
+
+// Listen will start listening for events from Postgres
+func (b *Broker) Listen(dsn string) error {
+	listener := pq.NewListener(dsn, minReconnectInterval, maxReconnectInterval, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			logrus.WithError(err).WithField("event", ev).Error("broker: listener error")
+		}
+	})
+
+	if err := listener.Listen(channel); err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	b.mu.Lock()
+	b.listener = listener
+	b.mu.Unlock()
+
+	b.wg.Add(1)
+	go b.run(listener)
+
+	return nil
+}
+
+func (b *Broker) run(listener *pq.Listener) {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case n, ok := <-listener.Notify:
+			if !ok {
+				return
+			}
+
+			// a nil notification means the connection was re-established and events may have been missed
+			if n == nil {
+				logrus.Warn("broker: listener reconnected")
+				continue
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+				logrus.WithError(err).Error("broker: could not decode event")
+				continue
+			}
+
+			b.dispatch(&event)
+		case <-ticker.C:
+			go func() {
+				if err := listener.Ping(); err != nil {
+					logrus.WithError(err).Warn("broker: could not ping listener")
+				}
+			}()
+		}
+	}
+}
+
+// Publish will send an event to every subscriber of the pool
+func (b *Broker) Publish(ctx context.Context, poolID int64, eventType EventType, data interface{}) error {
+	event := &Event{
+		PoolID:  poolID,
+		Type:    eventType,
+		Created: time.Now(),
+	}
+
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		event.Data = raw
+	}
+
+	b.mu.RLock()
+	closed, listening := b.closed, b.listener != nil
+	b.mu.RUnlock()
+
+	if closed {
+		return ErrClosed
+	}
+
+	if !listening {
+		b.dispatch(event)
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > maxPayloadSize {
+		event.Data = nil
+		if payload, err = json.Marshal(event); err != nil {
+			return err
+		}
+	}
+
+	_, err = b.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, string(payload))
+	return err
+}
+
+func (b *Broker) dispatch(event *Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers[event.PoolID] {
+		select {
+		case sub.c <- event:
+		default:
+			logrus.WithField("pool", event.PoolID).WithField("type", event.Type).Warn("broker: subscriber is full, dropping event")
+		}
+	}
+}
+
+// Subscribe returns a new subscription to the events of a pool. The subscription must be closed when it is
+// no longer needed.
+func (b *Broker) Subscribe(poolID int64) *Subscription {
+	c := make(chan *Event, subscriptionBuffer)
+	sub := &Subscription{
+		C:      c,
+		c:      c,
+		poolID: poolID,
+		broker: b,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(c)
+		return sub
+	}
+
+	if b.subscribers[poolID] == nil {
+		b.subscribers[poolID] = make(map[*Subscription]bool)
+	}
+	b.subscribers[poolID][sub] = true
+
+	return sub
+}
+
+func (b *Broker) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs, ok := b.subscribers[sub.poolID]
+	if !ok || !subs[sub] {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, sub.poolID)
+	}
+	close(sub.c)
+}
+
+// Close will stop listening and close every subscription
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+
+	b.closed = true
+	for poolID, subs := range b.subscribers {
+		for sub := range subs {
+			close(sub.c)
+		}
+		delete(b.subscribers, poolID)
+	}
+
+	listener := b.listener
+	b.mu.Unlock()
+
+	var err error
+	if listener != nil {
+		err = listener.Close()
+	}
+
+	b.wg.Wait()
+	return err
+}
+
+// Subscription receives the events of a single pool
+type Subscription struct {
+	// C will receive the events. It is closed when the subscription or broker is closed.
+	C      <-chan *Event
+	c      chan *Event
+	poolID int64
+	broker *Broker
+}
+
+// Close will stop receiving events
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
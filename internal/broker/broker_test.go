@@ -0,0 +1,118 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestPublishFanOut(t *testing.T) {
+	g := gomega.NewWithT(t)
+	b := New(nil)
+	defer b.Close()
+
+	a := b.Subscribe(1)
+	defer a.Close()
+	c := b.Subscribe(1)
+	defer c.Close()
+	other := b.Subscribe(2)
+	defer other.Close()
+
+	g.Expect(b.Publish(context.Background(), 1, EventSquareClaimed, map[string]int{"squareId": 5})).Should(gomega.Succeed())
+
+	for _, sub := range []*Subscription{a, c} {
+		g.Expect(sub.C).Should(gomega.HaveLen(1))
+		event := <-sub.C
+		g.Expect(event.PoolID).Should(gomega.Equal(int64(1)))
+		g.Expect(event.Type).Should(gomega.Equal(EventSquareClaimed))
+		g.Expect(event.Data).Should(gomega.Equal(json.RawMessage(`{"squareId":5}`)))
+	}
+
+	g.Expect(other.C).Should(gomega.BeEmpty())
+
+	g.Expect(b.Publish(context.Background(), 1, EventPoolLocked, nil)).Should(gomega.Succeed())
+	event := <-a.C
+	g.Expect(event.Data).Should(gomega.BeNil())
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	g := gomega.NewWithT(t)
+	b := New(nil)
+	defer b.Close()
+
+	a := b.Subscribe(1)
+	c := b.Subscribe(1)
+	defer c.Close()
+
+	a.Close()
+	_, ok := <-a.C
+	g.Expect(ok).Should(gomega.BeFalse())
+
+	// closing twice is harmless
+	a.Close()
+
+	g.Expect(b.Publish(context.Background(), 1, EventPoolUpdated, nil)).Should(gomega.Succeed())
+	g.Expect(c.C).Should(gomega.HaveLen(1))
+
+	c.Close()
+	g.Expect(b.subscribers).ShouldNot(gomega.HaveKey(int64(1)))
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	g := gomega.NewWithT(t)
+	b := New(nil)
+	defer b.Close()
+
+	slow := b.Subscribe(1)
+	defer slow.Close()
+	fast := b.Subscribe(1)
+	defer fast.Close()
+
+	// a subscriber that is not reading only loses the events that do not fit in its buffer, and it does not hold
+	// up the other subscribers
+	for i := 0; i < subscriptionBuffer+5; i++ {
+		g.Expect(b.Publish(context.Background(), 1, EventSquareClaimed, i)).Should(gomega.Succeed())
+		g.Expect((<-fast.C).Data).Should(gomega.Equal(json.RawMessage(fmt.Sprintf("%d", i))))
+	}
+
+	g.Expect(slow.C).Should(gomega.HaveLen(subscriptionBuffer))
+	g.Expect((<-slow.C).Data).Should(gomega.Equal(json.RawMessage("0")))
+}
+
+func TestBrokerClose(t *testing.T) {
+	g := gomega.NewWithT(t)
+	b := New(nil)
+
+	sub := b.Subscribe(1)
+	g.Expect(b.Close()).Should(gomega.Succeed())
+	g.Expect(b.Close()).Should(gomega.Succeed())
+
+	_, ok := <-sub.C
+	g.Expect(ok).Should(gomega.BeFalse())
+	sub.Close()
+
+	g.Expect(b.Publish(context.Background(), 1, EventPoolUpdated, nil)).Should(gomega.Equal(ErrClosed))
+
+	late := b.Subscribe(1)
+	_, ok = <-late.C
+	g.Expect(ok).Should(gomega.BeFalse())
+}
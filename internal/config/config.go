@@ -18,6 +18,8 @@ package config
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -26,6 +28,7 @@ type config struct {
 	dsn           string
 	jwtPrivateKey string
 	jwtPublicKey  string
+	webURL        string
 }
 
 var instance *config
@@ -48,6 +51,12 @@ func JWTPrivateKey() string {
 	return instance.jwtPrivateKey
 }
 
+// WebURL returns the base URL of the web site, which is used to build links for people rather than the API
+func WebURL() string {
+	mustHaveInstance()
+	return instance.webURL
+}
+
 func mustHaveInstance() {
 	if instance == nil {
 		panic("config: must call Load() first")
@@ -63,8 +72,10 @@ func Load() error {
 	_ = viper.BindEnv("dsn")
 	_ = viper.BindEnv("jwt_private_key")
 	_ = viper.BindEnv("jwt_public_key")
+	_ = viper.BindEnv("web_url")
 
 	viper.SetDefault("dsn", "host=localhost port=5432 user=postgres sslmode=disable")
+	viper.SetDefault("web_url", "https://sqmgr.com")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, isNotFoundError := err.(viper.ConfigFileNotFoundError); !isNotFoundError {
@@ -78,6 +89,7 @@ func Load() error {
 		dsn:           viper.GetString("dsn"),
 		jwtPrivateKey: viperGetStringOrFatal("jwt_private_key"),
 		jwtPublicKey:  viperGetStringOrFatal("jwt_public_key"),
+		webURL:        strings.TrimSuffix(viper.GetString("web_url"), "/"),
 	}
 
 	return nil
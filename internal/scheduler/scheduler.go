@@ -0,0 +1,306 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package scheduler runs the jobs that pool admins schedule against their pools. Every instance of the API runs a
+// scheduler, but only the one holding a Postgres advisory lock runs jobs at any given time.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+// lockKey is the key of the advisory lock held by the leader ("sqmgr" in ASCII)
+const lockKey int64 = 0x73716d6772
+
+const (
+	pollInterval   = time.Second * 30
+	resignTimeout  = time.Second * 5
+	batchSize      = 20
+	retryInterval  = time.Minute * 5
+	maxErrorLength = 500
+)
+
+// ErrNoHandler is returned when a job has an action that no handler was registered for
+var ErrNoHandler = errors.New("scheduler: no handler for action")
+
+// Handler will run a job against its pool
+type Handler func(ctx context.Context, pool *model.Pool, job *model.PoolJob) error
+
+// Sweeper cleans up anything that has expired. Unlike a job, it is not scheduled against a pool, but is run by the
+// leader every time it checks for jobs.
+type Sweeper func(ctx context.Context) error
+
+// Scheduler will run the jobs that are due
+type Scheduler struct {
+	model    *model.Model
+	handlers map[model.PoolJobAction]Handler
+	sweepers []Sweeper
+	conn     *sql.Conn
+	wake     chan struct{}
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+	mu       sync.RWMutex
+}
+
+// New returns a new Scheduler
+func New(m *model.Model) *Scheduler {
+	return &Scheduler{
+		model:    m,
+		handlers: make(map[model.PoolJobAction]Handler),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Handle registers the handler for an action. It must be called before Start.
+func (s *Scheduler) Handle(action model.PoolJobAction, handler Handler) {
+	s.handlers[action] = handler
+}
+
+// Sweep registers a sweeper. It must be called before Start.
+func (s *Scheduler) Sweep(sweeper Sweeper) {
+	s.sweepers = append(s.sweepers, sweeper)
+}
+
+// Start will begin running jobs in the background
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.resign()
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				select {
+				case <-s.stop:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			if err := s.tick(ctx); err != nil && err != context.Canceled {
+				logrus.WithError(err).Error("scheduler: could not run jobs")
+			}
+			cancel()
+
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+// Wake will tell the scheduler to check for jobs now instead of waiting for the next poll. This has no effect if
+// this instance is not the leader.
+func (s *Scheduler) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop will stop running jobs, wait for any job in progress and give up the leadership
+func (s *Scheduler) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	s.wg.Wait()
+}
+
+// IsLeader returns whether this instance is currently the one running jobs
+func (s *Scheduler) IsLeader() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.conn != nil
+}
+
+func (s *Scheduler) tick(ctx context.Context) error {
+	isLeader, err := s.elect(ctx)
+	if err != nil || !isLeader {
+		return err
+	}
+
+	_, err = s.RunDue(ctx)
+	s.sweep(ctx)
+	return err
+}
+
+// sweep will run every sweeper. A sweeper that fails does not keep the others from running.
+func (s *Scheduler) sweep(ctx context.Context) {
+	for i, sweeper := range s.sweepers {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := sweeper(ctx); err != nil && err != context.Canceled {
+			logrus.WithError(err).WithField("sweeper", i).Error("scheduler: sweeper failed")
+		}
+	}
+}
+
+// elect will try to become the leader. The advisory lock belongs to the database session, so a dedicated connection
+// is held for as long as this instance is the leader. If that connection is lost, so is the lock.
+func (s *Scheduler) elect(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		if _, err := s.conn.ExecContext(ctx, "SELECT 1"); err == nil {
+			return true, nil
+		} else if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+
+		logrus.Warn("scheduler: lost the leader connection")
+		s.release()
+	}
+
+	conn, err := s.model.DB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return false, err
+	}
+
+	if !acquired {
+		return false, conn.Close()
+	}
+
+	logrus.Info("scheduler: became the leader")
+	s.conn = conn
+	return true, nil
+}
+
+// resign will give up the leadership
+func (s *Scheduler) resign() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		s.release()
+	}
+}
+
+// release will unlock the advisory lock and close the connection. Closing a *sql.Conn returns it to the pool, so the
+// lock must be explicitly unlocked or it would be held by whoever uses the connection next. s.mu must be held.
+func (s *Scheduler) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), resignTimeout)
+	defer cancel()
+
+	if _, err := s.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+		logrus.WithError(err).Warn("scheduler: could not release the advisory lock")
+	}
+
+	_ = s.conn.Close()
+	s.conn = nil
+}
+
+// RunDue will run every job that is due and returns the number of jobs that were run. It does not check whether
+// this instance is the leader.
+func (s *Scheduler) RunDue(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		jobs, err := s.model.DueJobs(ctx, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		if len(jobs) == 0 {
+			return total, nil
+		}
+
+		for _, job := range jobs {
+			if err := s.run(ctx, job); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+}
+
+// run will run a single job and record the outcome. An error is only returned if the outcome could not be recorded.
+func (s *Scheduler) run(ctx context.Context, job *model.PoolJob) error {
+	lr := logrus.WithFields(logrus.Fields{
+		"job":    job.ID(),
+		"pool":   job.PoolID(),
+		"action": job.Action(),
+	})
+
+	err := s.handle(ctx, job)
+	if err == nil {
+		lr.Info("scheduler: job done")
+		return job.Done(ctx)
+	}
+
+	// the scheduler is stopping, so leave the job to be picked up again
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	errMsg := err.Error()
+	if len(errMsg) > maxErrorLength {
+		errMsg = errMsg[:maxErrorLength]
+	}
+
+	var retryAt *time.Time
+	if attempts := job.Attempts() + 1; attempts < model.MaxJobAttempts && err != ErrNoHandler {
+		t := time.Now().Add(time.Duration(attempts) * retryInterval)
+		retryAt = &t
+	}
+
+	lr.WithError(err).WithField("attempts", job.Attempts()+1).Warn("scheduler: job failed")
+	return job.Failed(ctx, errMsg, retryAt)
+}
+
+func (s *Scheduler) handle(ctx context.Context, job *model.PoolJob) (err error) {
+	handler, ok := s.handlers[job.Action()]
+	if !ok {
+		return ErrNoHandler
+	}
+
+	// a bad job should not take the scheduler down with it
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("scheduler: panic: %v", r)
+		}
+	}()
+
+	pool, err := s.model.PoolByID(job.PoolID())
+	if err != nil {
+		return err
+	}
+
+	return handler(ctx, pool, job)
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/internal/broker"
+	"github.com/sqmgr/sqmgr-api/internal/webhook"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+const (
+	// eventStreamDuration is how long an event stream is kept open. Clients are expected to reconnect when the
+	// stream ends.
+	eventStreamDuration = time.Second * 50
+	// eventStreamWriteTimeout replaces the server's write timeout for an event stream. It must be longer than
+	// eventStreamDuration so the stream is closed before the deadline.
+	eventStreamWriteTimeout = eventStreamDuration + time.Second*10
+	eventStreamRetry        = time.Second * 2
+	eventStreamKeepAlive    = time.Second * 15
+	publishTimeout          = time.Second * 5
+)
+
+// gridEventData is sent with events about a grid that no longer exists or a part of a grid that was removed
+type gridEventData struct {
+	GridID   int64                 `json:"gridId"`
+	SquareID int                   `json:"squareId,omitempty"`
+	Period   model.GridScorePeriod `json:"period,omitempty"`
+}
+
+// publish will send an event to everyone who is subscribed to the pool. Failing to publish an event will not fail the
+// request that caused it, so errors are only logged. Webhooks are not sent from here, see enqueueWebhooks.
+func (s *Server) publish(pool *model.Pool, eventType broker.EventType, data interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if err := s.broker.Publish(ctx, pool.ID(), eventType, data); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"pool": pool.ID(),
+			"type": eventType,
+		}).Error("could not publish event")
+	}
+}
+
+// enqueueWebhooks will queue the event for every webhook of the pool that wants it. It must be called in the
+// transaction of the change that caused the event, so that the deliveries are queued if and only if the change is
+// saved. The dispatcher should be woken once the transaction is committed.
+func (s *Server) enqueueWebhooks(ctx context.Context, tx *sql.Tx, pool *model.Pool, eventType broker.EventType, data interface{}) error {
+	if !webhook.IsEvent(eventType) {
+		return nil
+	}
+
+	payload, err := webhookPayload(pool, eventType, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = pool.EnqueueWebhookDeliveriesTx(ctx, tx, string(eventType), payload)
+	return err
+}
+
+// enqueueSquareWebhooks will queue the event for each of the squares, as enqueueWebhooks does
+func (s *Server) enqueueSquareWebhooks(ctx context.Context, tx *sql.Tx, pool *model.Pool, eventType broker.EventType, squares []*model.PoolSquare) error {
+	for _, square := range squares {
+		if err := s.enqueueWebhooks(ctx, tx, pool, eventType, square.JSON()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// webhookPayload returns the body that is sent to the webhooks for the event
+func webhookPayload(pool *model.Pool, eventType broker.EventType, data interface{}) ([]byte, error) {
+	return json.Marshal(webhook.Payload{
+		Event:   eventType,
+		Pool:    pool.Token(),
+		Created: time.Now(),
+		Data:    data,
+	})
+}
+
+// inTx will run fn in a transaction. The transaction is committed if fn succeeds and rolled back if it does not.
+func (s *Server) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.model.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// hijackEventStream will take over the connection of the request and write the headers of an event stream. The
+// server's write timeout is meant for regular requests, so the connection is given a deadline of its own and it is
+// closed once the stream ends.
+func hijackEventStream(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("streaming is not supported")
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := conn.SetWriteDeadline(time.Now().Add(eventStreamWriteTimeout)); err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	header.Set("Connection", "close")
+
+	if _, err := fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\n", http.StatusOK, http.StatusText(http.StatusOK)); err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+
+	if err := header.Write(buf); err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+
+	if _, err := buf.WriteString("\r\n"); err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+
+	return conn, buf, nil
+}
+
+func (s *Server) getPoolTokenEventsEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user, _ := r.Context().Value(ctxUserKey).(*model.User)
+
+		sub := s.broker.Subscribe(pool.ID())
+		defer sub.Close()
+
+		conn, buf, err := hijackEventStream(w)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		defer conn.Close()
+
+		if _, err := fmt.Fprintf(buf, "retry: %d\n\n", eventStreamRetry/time.Millisecond); err != nil {
+			return
+		}
+		if err := buf.Flush(); err != nil {
+			return
+		}
+
+		timeout := time.NewTimer(eventStreamDuration)
+		defer timeout.Stop()
+
+		keepAlive := time.NewTicker(eventStreamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-timeout.C:
+				return
+			case <-keepAlive.C:
+				// a stream can outlive the user's membership, so a member who is removed or banned while it is open is
+				// cut off at the next keep-alive. Banning a member also removes them.
+				if canRead, err := canReadPool(r.Context(), pool, user); err != nil || !canRead {
+					if err != nil {
+						logrus.WithError(err).WithField("pool", pool.ID()).Error("could not check access to event stream")
+					}
+
+					return
+				}
+
+				if _, err := fmt.Fprint(buf, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case event, ok := <-sub.C:
+				if !ok {
+					return
+				}
+
+				data := event.Data
+				if data == nil {
+					data = []byte("null")
+				}
+
+				if _, err := fmt.Fprintf(buf, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+					return
+				}
+			}
+
+			if err := buf.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+// gridExport has everything needed to export a single grid
+type gridExport struct {
+	grid        *model.Grid
+	squares     map[int]*model.PoolSquare
+	annotations map[int]*model.GridAnnotation
+	notes       map[int]string
+}
+
+func (s *Server) getPoolTokenGridIDExportCSVEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		grid := r.Context().Value(ctxGridKey).(*model.Grid)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		squares, err := pool.GridSquares(r.Context(), grid)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		notes, err := pool.GridLastNotes(r.Context(), grid)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		annotations, err := grid.Annotations(r.Context())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		records := gridCSVRecords(gridExport{
+			grid:        grid,
+			squares:     squares,
+			annotations: annotations,
+			notes:       notes,
+		})
+
+		s.writeCSVResponse(w, fmt.Sprintf("sqmgr-%s-grid-%d.csv", pool.Token(), grid.ID()), records)
+	}
+}
+
+func (s *Server) getPoolTokenExportCSVEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		grids, err := pool.Grids(r.Context(), 0, model.MaxGridsPerPool)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		records := make([][]string, 0)
+		for i, grid := range grids {
+			squares, err := pool.GridSquares(r.Context(), grid)
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			notes, err := pool.GridLastNotes(r.Context(), grid)
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			annotations, err := grid.Annotations(r.Context())
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			// each grid gets its own section, separated by an empty row
+			if i > 0 {
+				records = append(records, []string{})
+			}
+
+			records = append(records, []string{csvSafe(grid.Name())})
+			records = append(records, gridCSVRecords(gridExport{
+				grid:        grid,
+				squares:     squares,
+				annotations: annotations,
+				notes:       notes,
+			})...)
+		}
+
+		s.writeCSVResponse(w, fmt.Sprintf("sqmgr-%s.csv", pool.Token()), records)
+	}
+}
+
+func (s *Server) writeCSVResponse(w http.ResponseWriter, filename string, records [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(records); err != nil {
+		logrus.WithError(err).Error("could not write CSV")
+	}
+}
+
+// gridCSVRecords returns a header and a row for each square of the grid. The row digits are the away team's numbers
+// and the column digits are the home team's numbers.
+func gridCSVRecords(e gridExport) [][]string {
+	records := [][]string{{
+		"Square ID",
+		csvSafe(fmt.Sprintf("Row (%s)", e.grid.AwayTeamName())),
+		csvSafe(fmt.Sprintf("Column (%s)", e.grid.HomeTeamName())),
+		"Claimant",
+		"State",
+		"Parent Square",
+		"Child Squares",
+		"Annotation",
+		"Last Note",
+	}}
+
+	squareIDs := make([]int, 0, len(e.squares))
+	for squareID := range e.squares {
+		squareIDs = append(squareIDs, squareID)
+	}
+	sort.Ints(squareIDs)
+
+	for _, squareID := range squareIDs {
+		square := e.squares[squareID]
+		home, away := e.grid.SquareNumbers(squareID)
+
+		parent := ""
+		if square.ParentSquareID > 0 {
+			parent = strconv.Itoa(square.ParentSquareID)
+		}
+
+		children := make([]string, len(square.ChildSquareIDs))
+		for i, child := range square.ChildSquareIDs {
+			children[i] = strconv.Itoa(int(child))
+		}
+
+		annotation := ""
+		if a, ok := e.annotations[squareID]; ok {
+			annotation = a.Annotation
+		}
+
+		records = append(records, []string{
+			strconv.Itoa(squareID),
+			joinInts(away),
+			joinInts(home),
+			csvSafe(square.Claimant()),
+			string(square.State),
+			parent,
+			strings.Join(children, " "),
+			csvSafe(annotation),
+			csvSafe(e.notes[squareID]),
+		})
+	}
+
+	return records
+}
+
+func joinInts(nums []int) string {
+	strs := make([]string, len(nums))
+	for i, num := range nums {
+		strs[i] = strconv.Itoa(num)
+	}
+
+	return strings.Join(strs, "/")
+}
+
+// csvSafe will keep spreadsheets from treating user supplied text as a formula
+func csvSafe(val string) string {
+	if len(val) > 0 && strings.ContainsAny(val[:1], "=+-@\t\r") {
+		return "'" + val
+	}
+
+	return val
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+func csvTestGrid(drawn bool) *model.Grid {
+	grid := &model.Grid{}
+	grid.SetHomeTeamName("Chiefs")
+	grid.SetAwayTeamName("=Eagles")
+	if drawn {
+		if err := grid.SetManualNumbers([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}); err != nil {
+			panic(err)
+		}
+	}
+
+	return grid
+}
+
+func csvTestSquare(squareID int, state model.PoolSquareState, claimant string) *model.PoolSquare {
+	square := &model.PoolSquare{SquareID: squareID, State: state}
+	square.SetClaimant(claimant)
+	return square
+}
+
+func TestGridCSVRecords(t *testing.T) {
+	header := []string{"Square ID", "Row (=Eagles)", "Column (Chiefs)", "Claimant", "State", "Parent Square", "Child Squares", "Annotation", "Last Note"}
+
+	parent := csvTestSquare(12, model.PoolSquareStateClaimed, "Alice")
+	parent.ChildSquareIDs = []int8{13, 22}
+	right := csvTestSquare(13, model.PoolSquareStateClaimed, "Alice")
+	right.ParentSquareID = 12
+	below := csvTestSquare(22, model.PoolSquareStateClaimed, "Alice")
+	below.ParentSquareID = 12
+
+	tests := []struct {
+		name     string
+		export   gridExport
+		expected [][]string
+	}{
+		{
+			name:     "no squares",
+			export:   gridExport{grid: csvTestGrid(true)},
+			expected: [][]string{header},
+		},
+		{
+			name: "numbers not drawn",
+			export: gridExport{
+				grid: csvTestGrid(false),
+				squares: map[int]*model.PoolSquare{
+					2: csvTestSquare(2, model.PoolSquareStateUnclaimed, ""),
+					1: csvTestSquare(1, model.PoolSquareStateClaimed, "Bob"),
+				},
+			},
+			expected: [][]string{
+				header,
+				{"1", "", "", "Bob", "claimed", "", "", "", ""},
+				{"2", "", "", "", "unclaimed", "", "", "", ""},
+			},
+		},
+		{
+			name: "roll100",
+			export: gridExport{
+				grid: csvTestGrid(true),
+				squares: map[int]*model.PoolSquare{
+					22: below,
+					13: right,
+					12: parent,
+				},
+			},
+			expected: [][]string{
+				header,
+				{"12", "8", "1", "Alice", "claimed", "", "13 22", "", ""},
+				{"13", "8", "2", "Alice", "claimed", "12", "", "", ""},
+				{"22", "7", "1", "Alice", "claimed", "12", "", "", ""},
+			},
+		},
+		{
+			name: "user supplied text",
+			export: gridExport{
+				grid: csvTestGrid(true),
+				squares: map[int]*model.PoolSquare{
+					1:   csvTestSquare(1, model.PoolSquareStateClaimed, "=HYPERLINK(\"x\")"),
+					100: csvTestSquare(100, model.PoolSquareStatePaidFull, "Smith, \"Bo\""),
+				},
+				annotations: map[int]*model.GridAnnotation{
+					1: {Annotation: "+1"},
+				},
+				notes: map[int]string{
+					100: "@paid\nin cash",
+				},
+			},
+			expected: [][]string{
+				header,
+				{"1", "9", "0", "'=HYPERLINK(\"x\")", "claimed", "", "", "'+1", ""},
+				{"100", "0", "9", "Smith, \"Bo\"", "paid-full", "", "", "", "'@paid\nin cash"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			g.Expect(gridCSVRecords(test.export)).Should(gomega.Equal(test.expected))
+		})
+	}
+}
+
+func TestWriteCSVResponse(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	w := httptest.NewRecorder()
+	(&Server{}).writeCSVResponse(w, "grid.csv", [][]string{
+		{"Square ID", "Claimant", "Last Note"},
+		{"1", "Smith, \"Bo\"", "'@paid\nin cash"},
+		{},
+	})
+
+	g.Expect(w.Header().Get("Content-Type")).Should(gomega.Equal("text/csv; charset=utf-8"))
+	g.Expect(w.Header().Get("Content-Disposition")).Should(gomega.Equal(`attachment; filename="grid.csv"`))
+	g.Expect(w.Body.String()).Should(gomega.Equal("Square ID,Claimant,Last Note\n1,\"Smith, \"\"Bo\"\"\",\"'@paid\nin cash\"\n\n"))
+}
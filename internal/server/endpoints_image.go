@@ -0,0 +1,121 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+	"github.com/sqmgr/sqmgr-api/pkg/render"
+)
+
+func (s *Server) getPoolTokenGridIDImageSVGEndpoint() http.HandlerFunc {
+	return s.gridImageEndpoint("image/svg+xml", "svg", render.SVG)
+}
+
+func (s *Server) getPoolTokenGridIDImagePNGEndpoint() http.HandlerFunc {
+	return s.gridImageEndpoint("image/png", "png", render.PNG)
+}
+
+func (s *Server) gridImageEndpoint(contentType, ext string, renderFunc func(w io.Writer, b *render.Board) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		grid := r.Context().Value(ctxGridKey).(*model.Grid)
+
+		board, err := gridBoard(r.Context(), pool, grid)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// render into a buffer so that a failure can still be reported as an error
+		var buf bytes.Buffer
+		if err := renderFunc(&buf, board); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="sqmgr-%s-grid-%d.%s"`, pool.Token(), grid.ID(), ext))
+		w.WriteHeader(http.StatusOK)
+		if _, err := buf.WriteTo(w); err != nil {
+			logrus.WithError(err).Error("could not write image")
+		}
+	}
+}
+
+// gridBoard collects everything about the grid that is drawn on its image
+func gridBoard(ctx context.Context, pool *model.Pool, grid *model.Grid) (*render.Board, error) {
+	if err := grid.LoadSettings(ctx); err != nil {
+		return nil, err
+	}
+
+	squares, err := pool.GridSquares(ctx, grid)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations, err := grid.Annotations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	size := 10
+	if pool.GridType() == model.GridTypeStd25 {
+		size = 5
+	}
+
+	settings := grid.Settings()
+	board := &render.Board{
+		Size:  size,
+		Title: grid.Name(),
+		HomeTeam: render.Team{
+			Name:   grid.HomeTeamName(),
+			Color1: settings.HomeTeamColor1(),
+			Color2: settings.HomeTeamColor2(),
+		},
+		AwayTeam: render.Team{
+			Name:   grid.AwayTeamName(),
+			Color1: settings.AwayTeamColor1(),
+			Color2: settings.AwayTeamColor2(),
+		},
+		HomeNumbers: grid.HomeNumbers(),
+		AwayNumbers: grid.AwayNumbers(),
+		Squares:     make(map[int]render.Square),
+	}
+
+	for squareID, square := range squares {
+		board.Squares[squareID] = render.Square{Claimant: square.Claimant()}
+	}
+
+	for squareID, annotation := range annotations {
+		square := board.Squares[squareID]
+		square.Annotation = annotation.Annotation
+		if icon, ok := model.AnnotationIcons[annotation.Icon]; ok {
+			square.Icon = icon.Name
+		}
+
+		board.Squares[squareID] = square
+	}
+
+	return board, nil
+}
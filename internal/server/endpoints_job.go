@@ -0,0 +1,143 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sqmgr/sqmgr-api/internal/validator"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+func (s *Server) getPoolTokenJobEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		jobs, err := pool.Jobs(r.Context())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		jobsJSON := make([]*model.PoolJobJSON, len(jobs))
+		for i, job := range jobs {
+			jobsJSON[i] = job.JSON()
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, jobsJSON)
+	}
+}
+
+func (s *Server) postPoolTokenJobEndpoint() http.HandlerFunc {
+	type payload struct {
+		Action      model.PoolJobAction `json:"action"`
+		OffsetHours int                 `json:"offsetHours"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		v := validator.New()
+		if !data.Action.IsValid() {
+			v.AddError("action", "%s is not a valid action", data.Action)
+		}
+
+		offsetHours := v.IntInRange("offsetHours", data.OffsetHours, 0, model.MaxJobOffsetHours+1)
+		if !v.OK() {
+			s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+				Status:           statusError,
+				Error:            validationErrorMessage,
+				ValidationErrors: v.Errors,
+			})
+			return
+		}
+
+		job, err := pool.ScheduleJob(r.Context(), data.Action, offsetHours)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.scheduler.Wake()
+		s.writeJSONResponse(w, http.StatusCreated, job.JSON())
+	}
+}
+
+func (s *Server) deletePoolTokenJobIDEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		job, err := pool.JobByID(r.Context(), id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := job.Cancel(r.Context()); err != nil {
+			if err == model.ErrJobNotPending {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,425 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sqmgr/sqmgr-api/internal/validator"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+func (s *Server) getPoolTokenMemberEndpoint() http.HandlerFunc {
+	const defaultPerPage = 100
+	const maxPerPage = 100
+
+	type response struct {
+		Members    []*model.PoolMemberJSON `json:"members"`
+		Total      *int64                  `json:"total,omitempty"`
+		NextCursor string                  `json:"nextCursor,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		page, err := parsePage(r, defaultPerPage, maxPerPage)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		var members []*model.PoolMember
+		var next model.Cursor
+		if page.useOffset() {
+			members, err = pool.Members(r.Context(), page.offset, page.limit)
+		} else {
+			members, next, err = pool.MembersAfter(r.Context(), page.cursor, page.limit)
+		}
+
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var total *int64
+		if page.countsTotal() {
+			count, err := pool.MembersCount(r.Context())
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			total = &count
+		}
+
+		membersJSON := make([]*model.PoolMemberJSON, len(members))
+		for i, member := range members {
+			membersJSON[i] = member.JSON()
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, response{
+			Members:    membersJSON,
+			Total:      total,
+			NextCursor: next.String(),
+		})
+	}
+}
+
+func (s *Server) postPoolTokenMemberUserIDEndpoint() http.HandlerFunc {
+	type payload struct {
+		Action  string `json:"action"`
+		IsAdmin bool   `json:"isAdmin"`
+		Reason  string `json:"reason"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		member, ok := s.poolMemberForAdmin(w, r)
+		if !ok {
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		var err error
+		switch data.Action {
+		case "setAdmin":
+			err = member.SetAdmin(r.Context(), user, r.RemoteAddr, data.IsAdmin)
+		case "ban":
+			v := validator.New()
+			reason := v.Printable("reason", data.Reason, true)
+			reason = v.MaxLength("reason", reason, model.BanReasonMaxLength)
+			if !v.OK() {
+				s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+					Status:           statusError,
+					Error:            validationErrorMessage,
+					ValidationErrors: v.Errors,
+				})
+				return
+			}
+
+			err = pool.Ban(r.Context(), user, r.RemoteAddr, member.UserID(), reason)
+		default:
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("unsupported action %s", data.Action))
+			return
+		}
+
+		if err != nil {
+			if err == model.ErrPoolOwner {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if data.Action == "ban" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, member.JSON())
+	}
+}
+
+func (s *Server) deletePoolTokenMemberUserIDEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		member, ok := s.poolMemberForAdmin(w, r)
+		if !ok {
+			return
+		}
+
+		if err := member.Remove(r.Context(), user, r.RemoteAddr); err != nil {
+			if err == model.ErrPoolOwner {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// poolMemberForAdmin will return the member identified by the user_id route variable if the user is allowed to
+// administer them. Any admin may act on a regular member, but only the owner of the pool may act on another admin. If
+// false is returned, the response has already been written.
+func (s *Server) poolMemberForAdmin(w http.ResponseWriter, r *http.Request) (*model.PoolMember, bool) {
+	pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+	user := r.Context().Value(ctxUserKey).(*model.User)
+
+	if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, err)
+		return nil, false
+	} else if !isAdmin {
+		s.writeErrorResponse(w, http.StatusForbidden, nil)
+		return nil, false
+	}
+
+	userID, _ := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+	member, err := pool.MemberByUserID(r.Context(), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.writeErrorResponse(w, http.StatusNotFound, nil)
+			return nil, false
+		}
+
+		s.writeErrorResponse(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	if member.IsAdmin() && !member.IsOwner() && user.ID != pool.UserID() {
+		s.writeErrorResponse(w, http.StatusForbidden, nil)
+		return nil, false
+	}
+
+	return member, true
+}
+
+func (s *Server) getPoolTokenBanEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		bans, err := pool.Bans(r.Context())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		bansJSON := make([]*model.PoolBanJSON, len(bans))
+		for i, ban := range bans {
+			bansJSON[i] = ban.JSON()
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, bansJSON)
+	}
+}
+
+// postPoolTokenBanUserIDEndpoint will ban a user from the pool. Unlike the ban action of a member, the user does not
+// have to be a member of the pool, so someone can be kept out before they join or after they have left.
+func (s *Server) postPoolTokenBanUserIDEndpoint() http.HandlerFunc {
+	type payload struct {
+		Reason string `json:"reason"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		v := validator.New()
+		reason := v.Printable("reason", data.Reason, true)
+		reason = v.MaxLength("reason", reason, model.BanReasonMaxLength)
+		if !v.OK() {
+			s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+				Status:           statusError,
+				Error:            validationErrorMessage,
+				ValidationErrors: v.Errors,
+			})
+			return
+		}
+
+		userID, _ := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+		member, err := pool.MemberByUserID(r.Context(), userID)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := s.model.GetUserByID(r.Context(), userID); err != nil {
+				if err == sql.ErrNoRows {
+					s.writeErrorResponse(w, http.StatusNotFound, nil)
+					return
+				}
+
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+		case err != nil:
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		case member.IsAdmin() && !member.IsOwner() && user.ID != pool.UserID():
+			// only the owner of the pool may ban another admin
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		if err := pool.Ban(r.Context(), user, r.RemoteAddr, userID, reason); err != nil {
+			if err == model.ErrPoolOwner {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) deletePoolTokenBanUserIDEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		userID, _ := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+		if err := pool.Unban(r.Context(), user, r.RemoteAddr, userID); err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// auditState is the part of something that an admin action changed, as it is stored in the audit log
+type auditState map[string]interface{}
+
+// gridAuditState returns the state of a grid for the audit log. The settings are copied, since the grid may be changed
+// before the event is recorded.
+func gridAuditState(grid *model.Grid) auditState {
+	state := auditState{
+		"id":           grid.ID(),
+		"label":        grid.Label(),
+		"homeTeamName": grid.HomeTeamName(),
+		"awayTeamName": grid.AwayTeamName(),
+		"eventDate":    grid.EventDate(),
+		"rollover":     grid.Rollover(),
+	}
+
+	if settings := grid.Settings(); settings != nil {
+		state["settings"] = *settings
+	}
+
+	return state
+}
+
+// annotationAuditState returns the state of an annotation for the audit log
+func annotationAuditState(a *model.GridAnnotation) auditState {
+	return auditState{
+		"gridId":     a.GridID,
+		"squareId":   a.SquareID,
+		"annotation": a.Annotation,
+		"icon":       a.Icon,
+	}
+}
+
+// audit will make the change and add the event to the audit log of the pool in the same transaction. The change
+// returns the after state of the event.
+func (s *Server) audit(r *http.Request, pool *model.Pool, user *model.User, event model.AuditEvent, before interface{}, change func(tx *sql.Tx) (interface{}, error)) error {
+	return pool.Audited(r.Context(), user, r.RemoteAddr, event, before, change)
+}
+
+// auditFilter returns the filter of the audit log from the query string. The event can be any audit event, actor is
+// the ID of the user who caused it, and from and to are RFC 3339 timestamps.
+func auditFilter(r *http.Request) (model.AuditFilter, error) {
+	var filter model.AuditFilter
+
+	if event := r.FormValue("event"); event != "" {
+		filter.Event = model.AuditEvent(event)
+		if !filter.Event.IsValid() {
+			return filter, fmt.Errorf("%s is not a valid event", event)
+		}
+	}
+
+	if actor := r.FormValue("actor"); actor != "" {
+		userID, err := strconv.ParseInt(actor, 10, 64)
+		if err != nil || userID <= 0 {
+			return filter, fmt.Errorf("%s is not a valid actor", actor)
+		}
+
+		filter.UserID = userID
+	}
+
+	for _, param := range []struct {
+		key string
+		val *time.Time
+	}{
+		{key: "from", val: &filter.From},
+		{key: "to", val: &filter.To},
+	} {
+		value := r.FormValue(param.key)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", param.key)
+		}
+
+		*param.val = t
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,470 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sqmgr/sqmgr-api/internal/validator"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+func (s *Server) getPoolTokenParticipantEndpoint() http.HandlerFunc {
+	const defaultPerPage = 100
+	const maxPerPage = 100
+
+	type participantJSON struct {
+		*model.PoolParticipantJSON
+		Squares map[model.PoolSquareState]int `json:"squares"`
+		Balance *model.ParticipantBalance     `json:"balance"`
+	}
+
+	type response struct {
+		Participants []participantJSON `json:"participants"`
+		Total        int64             `json:"total"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		offset, _ := strconv.ParseInt(r.FormValue("offset"), 10, 64)
+		if offset < 0 {
+			offset = 0
+		}
+
+		limit, _ := strconv.Atoi(r.FormValue("limit"))
+		if limit <= 0 {
+			limit = defaultPerPage
+		}
+
+		if limit > maxPerPage {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("limit cannot exceed %d", maxPerPage))
+			return
+		}
+
+		participants, err := pool.Participants(r.Context(), offset, limit)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		count, err := pool.ParticipantsCount(r.Context())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		squareCounts, err := pool.ParticipantSquareCounts(r.Context())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		balances, err := pool.ParticipantBalances(r.Context())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		participantsJSON := make([]participantJSON, len(participants))
+		for i, participant := range participants {
+			counts := squareCounts[participant.ID()]
+			if counts == nil {
+				counts = make(map[model.PoolSquareState]int)
+			}
+
+			balance := balances[participant.ID()]
+			if balance == nil {
+				balance = &model.ParticipantBalance{}
+			}
+
+			participantsJSON[i] = participantJSON{
+				PoolParticipantJSON: participant.JSON(),
+				Squares:             counts,
+				Balance:             balance,
+			}
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, response{
+			Participants: participantsJSON,
+			Total:        count,
+		})
+	}
+}
+
+func (s *Server) postPoolTokenParticipantEndpoint() http.HandlerFunc {
+	type payload struct {
+		Name    string `json:"name"`
+		Contact string `json:"contact"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		v := validator.New()
+		name := v.Printable("name", data.Name)
+		name = v.ContainsWordChar("name", name)
+		contact := v.Printable("contact", data.Contact, true)
+
+		if !v.OK() {
+			s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+				Status:           statusError,
+				Error:            validationErrorMessage,
+				ValidationErrors: v.Errors,
+			})
+			return
+		}
+
+		var participant *model.PoolParticipant
+		err := s.audit(r, pool, user, model.AuditEventParticipantAdded, nil, func(tx *sql.Tx) (interface{}, error) {
+			var err error
+			if participant, err = pool.NewParticipantTx(r.Context(), tx, name); err != nil {
+				return nil, err
+			}
+
+			if contact != "" {
+				participant.SetContact(contact)
+				if err := participant.SaveTx(r.Context(), tx); err != nil {
+					return nil, err
+				}
+			}
+
+			return participantAuditState(participant), nil
+		})
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusCreated, participant.JSON())
+	}
+}
+
+// getPoolTokenParticipantIDEndpoint returns a participant along with every square that belongs to them and their
+// balance, so an admin can see what they owe
+func (s *Server) getPoolTokenParticipantIDEndpoint() http.HandlerFunc {
+	type response struct {
+		*model.PoolParticipantJSON
+		Squares []*model.PoolSquareJSON   `json:"squares"`
+		Balance *model.ParticipantBalance `json:"balance"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		participantID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		participant, err := pool.ParticipantByID(r.Context(), participantID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		squares, err := participant.Squares(r.Context())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		balance, err := participant.Balance(r.Context())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		squaresJSON := make([]*model.PoolSquareJSON, len(squares))
+		for i, square := range squares {
+			squaresJSON[i] = square.JSON()
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, response{
+			PoolParticipantJSON: participant.JSON(),
+			Squares:             squaresJSON,
+			Balance:             balance,
+		})
+	}
+}
+
+// postPoolTokenParticipantIDEndpoint lets an admin update a participant, merge duplicates into them or give them a new
+// token to link their account with
+func (s *Server) postPoolTokenParticipantIDEndpoint() http.HandlerFunc {
+	type payload struct {
+		Action         string  `json:"action"`
+		Name           string  `json:"name"`
+		Contact        string  `json:"contact"`
+		ParticipantIDs []int64 `json:"participantIds"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		participantID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		participant, err := pool.ParticipantByID(r.Context(), participantID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		switch data.Action {
+		case "update":
+			v := validator.New()
+			name := v.Printable("name", data.Name)
+			name = v.ContainsWordChar("name", name)
+			contact := v.Printable("contact", data.Contact, true)
+
+			if !v.OK() {
+				s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+					Status:           statusError,
+					Error:            validationErrorMessage,
+					ValidationErrors: v.Errors,
+				})
+				return
+			}
+
+			before := participantAuditState(participant)
+			participant.SetName(name)
+			participant.SetContact(contact)
+			err := s.audit(r, pool, user, model.AuditEventParticipantUpdated, before, func(tx *sql.Tx) (interface{}, error) {
+				if err := participant.SaveTx(r.Context(), tx); err != nil {
+					return nil, err
+				}
+
+				return participantAuditState(participant), nil
+			})
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+		case "merge":
+			if len(data.ParticipantIDs) == 0 {
+				s.writeErrorResponse(w, http.StatusBadRequest, errors.New("participantIds is required"))
+				return
+			}
+
+			others := make([]*model.PoolParticipant, len(data.ParticipantIDs))
+			for i, id := range data.ParticipantIDs {
+				if others[i], err = pool.ParticipantByID(r.Context(), id); err != nil {
+					if err == sql.ErrNoRows {
+						s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("participant %d not found", id))
+						return
+					}
+
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+					return
+				}
+			}
+
+			merged := make([]auditState, len(others))
+			for i, other := range others {
+				merged[i] = participantAuditState(other)
+			}
+
+			err := s.audit(r, pool, user, model.AuditEventParticipantsMerged, auditState{"participants": merged}, func(tx *sql.Tx) (interface{}, error) {
+				if err := participant.MergeTx(r.Context(), tx, r.RemoteAddr, others...); err != nil {
+					return nil, err
+				}
+
+				return participantAuditState(participant), nil
+			})
+			if err != nil {
+				switch err {
+				case model.ErrParticipantsLinked, model.ErrParticipantMergeSelf:
+					s.writeErrorResponse(w, http.StatusBadRequest, err)
+				default:
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				}
+				return
+			}
+		case "resetToken":
+			if participant.UserID() > 0 {
+				s.writeErrorResponse(w, http.StatusBadRequest, model.ErrParticipantLinked)
+				return
+			}
+
+			err := s.audit(r, pool, user, model.AuditEventParticipantTokenReset, nil, func(tx *sql.Tx) (interface{}, error) {
+				if err := participant.ResetTokenTx(r.Context(), tx); err != nil {
+					return nil, err
+				}
+
+				return participantAuditState(participant), nil
+			})
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+		default:
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("unsupported action %s", data.Action))
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, participant.JSON())
+	}
+}
+
+func (s *Server) deletePoolTokenParticipantIDEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		participantID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		participant, err := pool.ParticipantByID(r.Context(), participantID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		err = s.audit(r, pool, user, model.AuditEventParticipantDeleted, participantAuditState(participant), func(tx *sql.Tx) (interface{}, error) {
+			return nil, participant.DeleteTx(r.Context(), tx)
+		})
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// participantAuditState returns the state of a participant for the audit log. The token is left out, since it would let
+// anyone who can read the audit log take over the participant's squares.
+func participantAuditState(participant *model.PoolParticipant) auditState {
+	return auditState{
+		"id":      participant.ID(),
+		"name":    participant.Name(),
+		"contact": participant.Contact(),
+		"userId":  participant.UserID(),
+	}
+}
+
+// postParticipantTokenEndpoint lets someone who had squares claimed for them link their account. They join the pool
+// and the squares become theirs.
+func (s *Server) postParticipantTokenEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		participant, err := s.model.ParticipantByToken(r.Context(), mux.Vars(r)["token"])
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if _, err := participant.Link(r.Context(), user, r.RemoteAddr); err != nil {
+			switch err {
+			case model.ErrParticipantLinked, model.ErrUserBanned:
+				s.writeErrorResponse(w, http.StatusForbidden, err)
+			default:
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		pool, err := s.model.PoolByID(participant.PoolID())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		isAdmin, err := user.IsAdminOf(r.Context(), pool)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, poolResponse{
+			PoolJSON: pool.JSON(),
+			IsAdmin:  isAdmin,
+		})
+	}
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sqmgr/sqmgr-api/internal/broker"
+	"github.com/sqmgr/sqmgr-api/internal/validator"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+// paymentNoteMaxLength is the maximum number of characters allowed in the note of a payment
+const paymentNoteMaxLength = 500
+
+func (s *Server) getPoolTokenPaymentEndpoint() http.HandlerFunc {
+	const defaultPerPage = 100
+	const maxPerPage = 100
+
+	type response struct {
+		Payments []*model.PoolPaymentJSON `json:"payments"`
+		Total    int64                    `json:"total"`
+		Totals   *model.PaymentTotals     `json:"totals"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		participantID, _ := strconv.ParseInt(r.FormValue("participantId"), 10, 64)
+		if participantID < 0 {
+			participantID = 0
+		}
+
+		offset, _ := strconv.ParseInt(r.FormValue("offset"), 10, 64)
+		if offset < 0 {
+			offset = 0
+		}
+
+		limit, _ := strconv.Atoi(r.FormValue("limit"))
+		if limit <= 0 {
+			limit = defaultPerPage
+		}
+
+		if limit > maxPerPage {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("limit cannot exceed %d", maxPerPage))
+			return
+		}
+
+		payments, err := pool.Payments(r.Context(), participantID, offset, limit)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		count, err := pool.PaymentsCount(r.Context(), participantID)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		totals, err := pool.PaymentTotals(r.Context())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		paymentsJSON := make([]*model.PoolPaymentJSON, len(payments))
+		for i, payment := range payments {
+			paymentsJSON[i] = payment.JSON()
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, response{
+			Payments: paymentsJSON,
+			Total:    count,
+			Totals:   totals,
+		})
+	}
+}
+
+// postPoolTokenPaymentEndpoint records a payment made by a participant. The payment is either for one of their squares
+// or goes towards all of them.
+func (s *Server) postPoolTokenPaymentEndpoint() http.HandlerFunc {
+	type payload struct {
+		ParticipantID int64               `json:"participantId"`
+		GridID        int64               `json:"gridId"`
+		SquareID      int                 `json:"squareId"`
+		Amount        int64               `json:"amount"`
+		Method        model.PaymentMethod `json:"method"`
+		Note          string              `json:"note"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		v := validator.New()
+		if data.Amount <= 0 {
+			v.AddError("amount", "must be greater than zero")
+		}
+
+		if !data.Method.IsValid() {
+			v.AddError("method", "must be a valid payment method")
+		}
+
+		note := v.PrintableWithNewline("note", data.Note, true)
+		note = v.MaxLength("note", note, paymentNoteMaxLength)
+
+		if data.SquareID == 0 && data.ParticipantID == 0 {
+			v.AddError("participantId", "a participant or a square is required")
+		}
+
+		if !v.OK() {
+			s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+				Status:           statusError,
+				Error:            validationErrorMessage,
+				ValidationErrors: v.Errors,
+			})
+			return
+		}
+
+		var participant *model.PoolParticipant
+		var square *model.PoolSquare
+		var err error
+		if data.SquareID > 0 {
+			var grid *model.Grid
+			if data.GridID > 0 {
+				if grid, err = pool.GridByID(r.Context(), data.GridID); err != nil {
+					if err == sql.ErrNoRows {
+						s.writeErrorResponse(w, http.StatusBadRequest, errors.New("grid not found"))
+						return
+					}
+
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+					return
+				}
+			}
+
+			if square, err = pool.GridSquareBySquareID(r.Context(), grid, data.SquareID); err != nil {
+				switch err {
+				case sql.ErrNoRows:
+					s.writeErrorResponse(w, http.StatusBadRequest, errors.New("square not found"))
+				case model.ErrSquaresPerGrid:
+					s.writeErrorResponse(w, http.StatusBadRequest, err)
+				default:
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				}
+				return
+			}
+		} else if participant, err = pool.ParticipantByID(r.Context(), data.ParticipantID); err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusBadRequest, errors.New("participant not found"))
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var payment *model.PoolPayment
+		var changed []*model.PoolSquare
+		err = s.audit(r, pool, user, model.AuditEventPaymentRecorded, nil, func(tx *sql.Tx) (interface{}, error) {
+			var err error
+			if payment, changed, err = pool.RecordPaymentTx(r.Context(), tx, participant, square, data.Amount, data.Method, note, user.ID, r.RemoteAddr); err != nil {
+				return nil, err
+			}
+
+			if err := s.enqueueSquareWebhooks(r.Context(), tx, pool, broker.EventSquareStateChanged, changed); err != nil {
+				return nil, err
+			}
+
+			return payment.JSON(), nil
+		})
+		if err != nil {
+			if err == model.ErrPaymentSquareUnclaimed {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.webhooks.Wake()
+		for _, square := range changed {
+			s.publish(pool, broker.EventSquareStateChanged, square.JSON())
+		}
+
+		s.writeJSONResponse(w, http.StatusCreated, payment.JSON())
+	}
+}
+
+func (s *Server) postPoolTokenPaymentIDEndpoint() http.HandlerFunc {
+	type payload struct {
+		Action string `json:"action"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		paymentID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		payment, err := pool.PaymentByID(r.Context(), paymentID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		if data.Action != "void" {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("unsupported action %s", data.Action))
+			return
+		}
+
+		before := payment.JSON()
+		var changed []*model.PoolSquare
+		err = s.audit(r, pool, user, model.AuditEventPaymentVoided, before, func(tx *sql.Tx) (interface{}, error) {
+			var err error
+			if changed, err = payment.VoidTx(r.Context(), tx, user.ID, r.RemoteAddr); err != nil {
+				return nil, err
+			}
+
+			if err := s.enqueueSquareWebhooks(r.Context(), tx, pool, broker.EventSquareStateChanged, changed); err != nil {
+				return nil, err
+			}
+
+			return payment.JSON(), nil
+		})
+		if err != nil {
+			if err == model.ErrPaymentVoided {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.webhooks.Wake()
+		for _, square := range changed {
+			s.publish(pool, broker.EventSquareStateChanged, square.JSON())
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, payment.JSON())
+	}
+}
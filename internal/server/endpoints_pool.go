@@ -25,7 +25,9 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/internal/broker"
 	"github.com/sqmgr/sqmgr-api/internal/validator"
+	"github.com/sqmgr/sqmgr-api/internal/webhook"
 	"github.com/sqmgr/sqmgr-api/pkg/model"
 	"net/http"
 	"strconv"
@@ -57,27 +59,32 @@ func (s *Server) poolHandler(next http.Handler) http.Handler {
 			return
 		}
 
-		if pool.IsLocked() && pool.OpenAccessOnLock() {
-			// no auth required
-		} else {
-			user := r.Context().Value(ctxUserKey).(*model.User)
-
-			isMemberOf, err := user.IsMemberOf(r.Context(), pool)
-			if err != nil {
-				s.writeErrorResponse(w, http.StatusInternalServerError, err)
-				return
-			}
+		user, _ := r.Context().Value(ctxUserKey).(*model.User)
+		canRead, err := canReadPool(r.Context(), pool, user)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
 
-			if !isMemberOf {
-				s.writeErrorResponse(w, http.StatusForbidden, nil)
-				return
-			}
+		if !canRead {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
 		}
 
 		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxPoolKey, pool)))
 	})
 }
 
+// canReadPool returns whether the user can read the pool. Once a pool that is open on lock is locked, no auth is
+// required. Otherwise, the user has to be a member.
+func canReadPool(ctx context.Context, pool *model.Pool, user *model.User) (bool, error) {
+	if pool.IsLocked() && pool.OpenAccessOnLock() {
+		return true, nil
+	}
+
+	return user.IsMemberOf(ctx, pool)
+}
+
 func (s *Server) poolGridHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
@@ -139,6 +146,13 @@ func (s *Server) postPoolTokenEndpoint() http.HandlerFunc {
 		Password         string  `json:"password"`
 		ResetMembership  bool    `json:"resetMembership"`
 		OpenAccessOnLock bool    `json:"openAccessOnLock"`
+		SquaresPerGrid   bool    `json:"squaresPerGrid"`
+		MaxSquares       int     `json:"maxSquaresPerUser"`
+		RandomAssignment bool    `json:"randomAssignment"`
+		LocksDate        string  `json:"locksDate"`
+		LocksTime        string  `json:"locksTime"`
+		TimeZoneOffset   string  `json:"timeZoneOffset"`
+		TimeZone         string  `json:"timeZone"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -153,30 +167,151 @@ func (s *Server) postPoolTokenEndpoint() http.HandlerFunc {
 			return
 		}
 
+		if s.preconditionFailed(w, r, pool.Version()) {
+			return
+		}
+
+		version := ifMatchVersion(r, pool.Version())
+
 		var resp payload
 		if ok := s.parseJSONPayload(w, r, &resp); !ok {
 			return
 		}
 
+		prev := pool.JSON()
+
+		// state picks the part of the pool that the action changes, as it is recorded in the audit log
+		var event model.AuditEvent
+		var state func(p *model.PoolJSON) interface{}
+		var change func(tx *sql.Tx) error
+		var assigned []*model.PoolSquare
+
 		var err error
 		switch resp.Action {
 		case "lock":
 			pool.SetLocks(time.Now())
-			err = pool.Save(r.Context())
+			event, state = model.AuditEventPoolLocked, poolLocksAuditState
+			change = func(tx *sql.Tx) error {
+				if err := pool.SaveVersionTx(r.Context(), tx, version); err != nil {
+					return err
+				}
+
+				// commit to the seed that the numbers will be drawn with before anyone can see them
+				return pool.CommitDrawSeedTx(r.Context(), tx)
+			}
 		case "unlock":
 			pool.SetLocks(time.Time{})
-			err = pool.Save(r.Context())
+			event, state = model.AuditEventPoolUnlocked, poolLocksAuditState
+			change = func(tx *sql.Tx) error {
+				if err := pool.SaveVersionTx(r.Context(), tx, version); err != nil {
+					return err
+				}
+
+				// a revealed seed must not be used while squares can still be claimed
+				return pool.CommitDrawSeedTx(r.Context(), tx)
+			}
+		case "setLocks":
+			v := validator.New()
+			locks := v.Datetime("Locks", resp.LocksDate, resp.LocksTime, resp.TimeZoneOffset)
+			if !v.OK() {
+				s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+					Status:           statusError,
+					Error:            validationErrorMessage,
+					ValidationErrors: v.Errors,
+				})
+				return
+			}
+
+			pool.SetLocks(locks)
+			event, state = model.AuditEventPoolLocksChanged, poolLocksAuditState
+			change = func(tx *sql.Tx) error {
+				if err := pool.SaveVersionTx(r.Context(), tx, version); err != nil {
+					return err
+				}
+
+				return pool.CommitDrawSeedTx(r.Context(), tx)
+			}
 		case "accessOnLock":
 			pool.SetOpenAccessOnLock(resp.OpenAccessOnLock)
-			err = pool.Save(r.Context())
+			event = model.AuditEventPoolAccessOnLockChanged
+			state = func(p *model.PoolJSON) interface{} {
+				return auditState{"openAccessOnLock": p.OpenAccessOnLock}
+			}
+		case "timeZone":
+			if err := pool.SetTimeZone(resp.TimeZone); err != nil {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			event = model.AuditEventPoolTimeZoneChanged
+			state = func(p *model.PoolJSON) interface{} {
+				return auditState{"timeZone": p.TimeZone}
+			}
 		case "reorderGrids":
-			err = pool.SetGridsOrder(r.Context(), resp.IDs)
+			event = model.AuditEventGridsReordered
+			change = func(tx *sql.Tx) error {
+				return pool.SetGridsOrderTx(r.Context(), tx, resp.IDs)
+			}
+		case "squaresPerGrid":
+			event = model.AuditEventPoolSquaresPerGridChanged
+			state = func(p *model.PoolJSON) interface{} {
+				return auditState{"squaresPerGrid": p.SquaresPerGrid}
+			}
+			change = func(tx *sql.Tx) error {
+				return pool.SetSquaresPerGridTx(r.Context(), tx, resp.SquaresPerGrid)
+			}
+		case "maxSquaresPerUser":
+			v := validator.New()
+			maxSquares := v.IntInRange("maxSquaresPerUser", resp.MaxSquares, 0, pool.NumberOfSquares()+1)
+			if !v.OK() {
+				s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+					Status:           statusError,
+					Error:            validationErrorMessage,
+					ValidationErrors: v.Errors,
+				})
+				return
+			}
+
+			pool.SetMaxSquaresPerUser(maxSquares)
+			event = model.AuditEventPoolMaxSquaresChanged
+			state = func(p *model.PoolJSON) interface{} {
+				return auditState{"maxSquaresPerUser": p.MaxSquaresPerUser}
+			}
+		case "randomAssignment":
+			pool.SetRandomAssignment(resp.RandomAssignment)
+			event = model.AuditEventPoolRandomAssignmentChanged
+			state = func(p *model.PoolJSON) interface{} {
+				return auditState{"randomAssignment": p.RandomAssignment}
+			}
+			change = func(tx *sql.Tx) error {
+				if err := pool.SaveVersionTx(r.Context(), tx, version); err != nil || !pool.RandomAssignment() {
+					return err
+				}
+
+				// the squares are assigned when the pool locks
+				_, err := pool.ScheduleJobTx(r.Context(), tx, model.PoolJobActionAssignSquares, 0)
+				return err
+			}
+		case "assignSquares":
+			if !pool.RandomAssignment() {
+				s.writeErrorResponse(w, http.StatusBadRequest, model.ErrNotRandomAssignment)
+				return
+			}
+
+			event = model.AuditEventPoolSquaresAssigned
+			change = func(tx *sql.Tx) error {
+				var err error
+				assigned, err = pool.AssignSquaresTx(r.Context(), tx, r.RemoteAddr)
+				return err
+			}
 		case "archive":
 			pool.SetArchived(true)
-			err = pool.Save(r.Context())
+			event = model.AuditEventPoolArchived
+			state = poolArchivedAuditState
 		case "unarchive":
 			pool.SetArchived(false)
-			err = pool.Save(r.Context())
+			event = model.AuditEventPoolUnarchived
+			state = poolArchivedAuditState
 		case "changeJoinPassword":
 			v := validator.New()
 			password := v.Password("Join Password", resp.Password, minJoinPasswordLength)
@@ -195,16 +330,13 @@ func (s *Server) postPoolTokenEndpoint() http.HandlerFunc {
 			}
 
 			pool.IncrementCheckID()
-			if err := pool.Save(r.Context()); err != nil {
-				s.writeErrorResponse(w, http.StatusInternalServerError, err)
-				return
-			}
-
-			if resp.ResetMembership {
-				if err := pool.RemoveAllMembers(r.Context()); err != nil {
-					s.writeErrorResponse(w, http.StatusInternalServerError, err)
-					return
+			event = model.AuditEventPoolJoinPasswordChanged
+			change = func(tx *sql.Tx) error {
+				if err := pool.SaveVersionTx(r.Context(), tx, version); err != nil || !resp.ResetMembership {
+					return err
 				}
+
+				return pool.RemoveAllMembersTx(r.Context(), tx)
 			}
 		case "rename":
 			v := validator.New()
@@ -220,17 +352,109 @@ func (s *Server) postPoolTokenEndpoint() http.HandlerFunc {
 			}
 
 			pool.SetName(name)
-			err = pool.Save(r.Context())
+			event = model.AuditEventPoolRenamed
+			state = func(p *model.PoolJSON) interface{} {
+				return auditState{"name": p.Name}
+			}
 		default:
 			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("unsupported action %s", resp.Action))
 			return
 		}
 
+		if event != "" {
+			if change == nil {
+				change = func(tx *sql.Tx) error {
+					return pool.SaveVersionTx(r.Context(), tx, version)
+				}
+			}
+
+			var before interface{}
+			if state != nil {
+				before = state(prev)
+			}
+
+			err = s.audit(r, pool, user, event, before, func(tx *sql.Tx) (interface{}, error) {
+				if err := change(tx); err != nil {
+					return nil, err
+				}
+
+				switch {
+				case resp.Action == "lock", resp.Action == "setLocks" && pool.IsLocked():
+					if err := s.enqueueWebhooks(r.Context(), tx, pool, broker.EventPoolLocked, pool.JSON()); err != nil {
+						return nil, err
+					}
+				case resp.Action == "assignSquares":
+					if err := s.enqueueSquareWebhooks(r.Context(), tx, pool, broker.EventSquareClaimed, assigned); err != nil {
+						return nil, err
+					}
+				}
+
+				switch resp.Action {
+				case "reorderGrids":
+					return auditState{"ids": resp.IDs}, nil
+				case "changeJoinPassword":
+					// the password itself never goes in the audit log
+					return auditState{"resetMembership": resp.ResetMembership}, nil
+				}
+
+				if state == nil {
+					return nil, nil
+				}
+
+				return state(pool.JSON()), nil
+			})
+		}
+
 		if err != nil {
+			if err == model.ErrSquaresClaimed {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			if err == model.ErrVersionMismatch {
+				s.writeErrorResponse(w, http.StatusPreconditionFailed, err)
+				return
+			}
+
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
 
+		switch resp.Action {
+		case "lock", "unlock", "setLocks":
+			s.rescheduleJobs(r.Context(), pool)
+		case "randomAssignment":
+			if pool.RandomAssignment() {
+				s.scheduler.Wake()
+			}
+		}
+
+		switch resp.Action {
+		case "lock":
+			s.webhooks.Wake()
+			s.publish(pool, broker.EventPoolLocked, pool.JSON())
+		case "unlock":
+			s.publish(pool, broker.EventPoolUnlocked, pool.JSON())
+		case "setLocks":
+			if pool.IsLocked() {
+				s.webhooks.Wake()
+				s.publish(pool, broker.EventPoolLocked, pool.JSON())
+			} else {
+				s.publish(pool, broker.EventPoolUpdated, pool.JSON())
+			}
+		case "reorderGrids":
+			s.publish(pool, broker.EventGridsReordered, resp.IDs)
+		case "changeJoinPassword":
+			// nothing that members can see has changed
+		case "assignSquares":
+			s.webhooks.Wake()
+			for _, square := range assigned {
+				s.publish(pool, broker.EventSquareClaimed, square.JSON())
+			}
+		default:
+			s.publish(pool, broker.EventPoolUpdated, pool.JSON())
+		}
+
 		s.writeJSONResponse(w, http.StatusOK, poolResponse{
 			PoolJSON: pool.JSON(),
 			IsAdmin:  true,
@@ -238,13 +462,27 @@ func (s *Server) postPoolTokenEndpoint() http.HandlerFunc {
 	}
 }
 
+// poolLocksAuditState is the state of the pool that locking it changes, as it is recorded in the audit log
+func poolLocksAuditState(p *model.PoolJSON) interface{} {
+	return auditState{"locks": p.Locks}
+}
+
+// poolArchivedAuditState is the state of the pool that archiving it changes, as it is recorded in the audit log
+func poolArchivedAuditState(p *model.PoolJSON) interface{} {
+	return auditState{"archived": p.Archived}
+}
+
 func (s *Server) getPoolTokenLogEndpoint() http.HandlerFunc {
 	const defaultPerPage = 100
 	const maxPerPage = 100
 
 	type response struct {
-		Logs  []*model.PoolSquareLogJSON `json:"logs"`
-		Total int64                      `json:"total"`
+		Logs             []*model.PoolSquareLogJSON  `json:"logs"`
+		Total            *int64                      `json:"total,omitempty"`
+		NextCursor       string                      `json:"nextCursor,omitempty"`
+		Events           []*model.PoolAuditEventJSON `json:"events"`
+		EventsTotal      *int64                      `json:"eventsTotal,omitempty"`
+		NextEventsCursor string                      `json:"nextEventsCursor,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -259,47 +497,172 @@ func (s *Server) getPoolTokenLogEndpoint() http.HandlerFunc {
 			return
 		}
 
-		offset, _ := strconv.ParseInt(r.FormValue("offset"), 10, 64)
-		if offset < 0 {
-			offset = 0
+		page, err := parsePage(r, defaultPerPage, maxPerPage)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err)
+			return
 		}
 
-		limit, _ := strconv.Atoi(r.FormValue("limit"))
-		if limit <= 0 {
-			limit = defaultPerPage
+		// the audit log is paged through separately from the square logs, with its own offset and cursor
+		eventsPage, err := parseNamedPage(r, "eventsOffset", "eventsCursor", defaultPerPage, maxPerPage)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err)
+			return
 		}
 
-		if limit > maxPerPage {
-			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("limit cannot exceed %d", maxPerPage))
+		filter, err := auditFilter(r)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err)
+			return
 		}
 
-		logs, err := pool.Logs(r.Context(), offset, limit)
+		logFilter, err := logFilter(r)
 		if err != nil {
-			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			s.writeErrorResponse(w, http.StatusBadRequest, err)
 			return
 		}
 
-		count, err := pool.LogsCount(r.Context())
+		// the date range applies to both the square logs and the audit log
+		logFilter.From, logFilter.To = filter.From, filter.To
+
+		grid, isGrid := r.Context().Value(ctxGridKey).(*model.Grid)
+
+		var logs []*model.PoolSquareLog
+		var next model.Cursor
+		switch {
+		case isGrid && page.useOffset():
+			logs, err = pool.GridLogs(r.Context(), grid, logFilter, page.offset, page.limit)
+		case isGrid:
+			logs, next, err = pool.GridLogsAfter(r.Context(), grid, logFilter, page.cursor, page.limit)
+		case page.useOffset():
+			logs, err = pool.Logs(r.Context(), logFilter, page.offset, page.limit)
+		default:
+			logs, next, err = pool.LogsAfter(r.Context(), logFilter, page.cursor, page.limit)
+		}
+
 		if err != nil {
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
 
+		var total *int64
+		if page.countsTotal() {
+			var count int64
+			if isGrid {
+				count, err = pool.GridLogsCount(r.Context(), grid, logFilter)
+			} else {
+				count, err = pool.LogsCount(r.Context(), logFilter)
+			}
+
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			total = &count
+		}
+
+		// everything else that admins did to the pool is in the audit log. A client that only pages through the square
+		// logs already has the first page of events, so they are not fetched again and are null in the response.
+		var events []*model.PoolAuditEvent
+		var nextEvents model.Cursor
+		var eventsTotal *int64
+		if page.isFirst() || !eventsPage.isFirst() {
+			if eventsPage.useOffset() {
+				events, err = pool.AuditEvents(r.Context(), filter, eventsPage.offset, eventsPage.limit)
+			} else {
+				events, nextEvents, err = pool.AuditEventsAfter(r.Context(), filter, eventsPage.cursor, eventsPage.limit)
+			}
+
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			if eventsPage.countsTotal() {
+				count, err := pool.AuditEventsCount(r.Context(), filter)
+				if err != nil {
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+					return
+				}
+
+				eventsTotal = &count
+			}
+		}
+
 		logsJSON := make([]*model.PoolSquareLogJSON, len(logs))
 		for i, log := range logs {
 			logsJSON[i] = log.JSON()
 		}
 
+		var eventsJSON []*model.PoolAuditEventJSON
+		if events != nil {
+			eventsJSON = make([]*model.PoolAuditEventJSON, len(events))
+			for i, event := range events {
+				eventsJSON[i] = event.JSON()
+			}
+		}
+
 		s.writeJSONResponse(w, http.StatusOK, response{
-			Logs:  logsJSON,
-			Total: count,
+			Logs:             logsJSON,
+			Total:            total,
+			NextCursor:       next.String(),
+			Events:           eventsJSON,
+			EventsTotal:      eventsTotal,
+			NextEventsCursor: nextEvents.String(),
 		})
 	}
 }
 
+// logFilter returns the square log filter from the query parameters of the request. The date range is left to
+// auditFilter.
+func logFilter(r *http.Request) (model.LogFilter, error) {
+	var filter model.LogFilter
+
+	if squareID := r.FormValue("squareId"); squareID != "" {
+		id, err := strconv.Atoi(squareID)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("%s is not a valid square ID", squareID)
+		}
+
+		filter.SquareID = id
+	}
+
+	if userID := r.FormValue("userId"); userID != "" {
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("%s is not a valid user ID", userID)
+		}
+
+		filter.UserID = id
+	}
+
+	if state := r.FormValue("state"); state != "" {
+		filter.State = model.PoolSquareState(state)
+		if !filter.State.IsValid() {
+			return filter, fmt.Errorf("%s is not a valid state", state)
+		}
+	}
+
+	filter.Claimant = r.FormValue("claimant")
+	filter.Note = r.FormValue("note")
+
+	return filter, nil
+}
+
 func (s *Server) deletePoolTokenGridIDEndpoint() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
 		id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
 
 		grid, err := pool.GridByID(r.Context(), id)
@@ -313,7 +676,18 @@ func (s *Server) deletePoolTokenGridIDEndpoint() http.HandlerFunc {
 			return
 		}
 
-		if err := grid.Delete(r.Context()); err != nil {
+		if s.preconditionFailed(w, r, grid.Version()) {
+			return
+		}
+
+		err = s.audit(r, pool, user, model.AuditEventGridDeleted, gridAuditState(grid), func(tx *sql.Tx) (interface{}, error) {
+			if err := grid.DeleteTx(r.Context(), tx); err != nil {
+				return nil, err
+			}
+
+			return nil, s.enqueueWebhooks(r.Context(), tx, pool, broker.EventGridDeleted, gridEventData{GridID: grid.ID()})
+		})
+		if err != nil {
 			if err == model.ErrLastGrid {
 				s.writeErrorResponse(w, http.StatusBadRequest, errors.New("you cannot delete the last grid"))
 				return
@@ -323,6 +697,9 @@ func (s *Server) deletePoolTokenGridIDEndpoint() http.HandlerFunc {
 			return
 		}
 
+		s.rescheduleJobs(r.Context(), pool)
+		s.webhooks.Wake()
+		s.publish(pool, broker.EventGridDeleted, gridEventData{GridID: grid.ID()})
 		s.writeJSONResponse(w, http.StatusNoContent, nil)
 	}
 }
@@ -342,7 +719,46 @@ func (s *Server) getPoolConfiguration() http.HandlerFunc {
 		}
 	}
 
-	resp := struct {
+	type periodDescription struct {
+		Key         model.GridScorePeriod `json:"key"`
+		Description string                `json:"description"`
+	}
+
+	periodsSlice := make([]periodDescription, len(model.GridScorePeriods))
+	for i, period := range model.GridScorePeriods {
+		periodsSlice[i] = periodDescription{
+			Key:         period,
+			Description: period.Description(),
+		}
+	}
+
+	type unclaimedRuleDescription struct {
+		Key         model.UnclaimedRule `json:"key"`
+		Description string              `json:"description"`
+	}
+
+	unclaimedRulesSlice := make([]unclaimedRuleDescription, len(model.UnclaimedRules))
+	for i, rule := range model.UnclaimedRules {
+		unclaimedRulesSlice[i] = unclaimedRuleDescription{
+			Key:         rule,
+			Description: rule.Description(),
+		}
+	}
+
+	type jobActionDescription struct {
+		Key         model.PoolJobAction `json:"key"`
+		Description string              `json:"description"`
+	}
+
+	jobActionsSlice := make([]jobActionDescription, len(model.PoolJobActions))
+	for i, action := range model.PoolJobActions {
+		jobActionsSlice[i] = jobActionDescription{
+			Key:         action,
+			Description: action.Description(),
+		}
+	}
+
+	type configuration struct {
 		ClaimantMaxLength     int                             `json:"claimantMaxLength"`
 		NameMaxLength         int                             `json:"nameMaxLength"`
 		NotesMaxLength        int                             `json:"notesMaxLength"`
@@ -351,7 +767,25 @@ func (s *Server) getPoolConfiguration() http.HandlerFunc {
 		GridTypes             []keyDescription                `json:"gridTypes"`
 		MinJoinPasswordLength int                             `json:"minJoinPasswordLength"`
 		GridAnnotationIcons   model.GridAnnotationIconMapping `json:"gridAnnotationIcons"`
-	}{
+		GridScorePeriods      []periodDescription             `json:"gridScorePeriods"`
+		ScoreMax              int                             `json:"scoreMax"`
+		UnclaimedRules        []unclaimedRuleDescription      `json:"unclaimedRules"`
+		PayoutAmountTypes     []model.PayoutAmountType        `json:"payoutAmountTypes"`
+		WebhookEvents         []broker.EventType              `json:"webhookEvents"`
+		MaxWebhooksPerPool    int                             `json:"maxWebhooksPerPool"`
+		PoolJobActions        []jobActionDescription          `json:"poolJobActions"`
+		MaxJobOffsetHours     int                             `json:"maxJobOffsetHours"`
+	}
+
+	// quotaConfiguration is returned when the configuration is requested for a pool
+	type quotaConfiguration struct {
+		configuration
+
+		// SquaresRemaining is how many more squares the user may claim. It is omitted if there is no limit.
+		SquaresRemaining *int `json:"squaresRemaining,omitempty"`
+	}
+
+	resp := configuration{
 		ClaimantMaxLength:     model.ClaimantMaxLength,
 		NameMaxLength:         model.NameMaxLength,
 		NotesMaxLength:        model.NotesMaxLength,
@@ -360,6 +794,14 @@ func (s *Server) getPoolConfiguration() http.HandlerFunc {
 		GridTypes:             gridTypesSlice,
 		MinJoinPasswordLength: minJoinPasswordLength,
 		GridAnnotationIcons:   model.AnnotationIcons,
+		GridScorePeriods:      periodsSlice,
+		ScoreMax:              model.ScoreMax,
+		UnclaimedRules:        unclaimedRulesSlice,
+		PayoutAmountTypes:     []model.PayoutAmountType{model.PayoutAmountTypePercent, model.PayoutAmountTypeFixed},
+		WebhookEvents:         webhook.Events,
+		MaxWebhooksPerPool:    model.MaxWebhooksPerPool,
+		PoolJobActions:        jobActionsSlice,
+		MaxJobOffsetHours:     model.MaxJobOffsetHours,
 	}
 
 	jsonResp, err := json.Marshal(resp)
@@ -368,6 +810,22 @@ func (s *Server) getPoolConfiguration() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		// the configuration of a pool also has the quota of the user who is asking
+		if pool, ok := r.Context().Value(ctxPoolKey).(*model.Pool); ok {
+			user := r.Context().Value(ctxUserKey).(*model.User)
+			squaresRemaining, err := s.squaresRemaining(r.Context(), pool, user)
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.writeJSONResponse(w, http.StatusOK, quotaConfiguration{
+				configuration:    resp,
+				SquaresRemaining: squaresRemaining,
+			})
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		if _, err := w.Write(jsonResp); err != nil {
 			logrus.WithError(err).Error("could not write response")
@@ -375,6 +833,27 @@ func (s *Server) getPoolConfiguration() http.HandlerFunc {
 	}
 }
 
+// squaresRemaining returns how many more squares the user may claim in the pool. If the pool has no limit, nil is
+// returned.
+func (s *Server) squaresRemaining(ctx context.Context, pool *model.Pool, user *model.User) (*int, error) {
+	maxSquares := pool.MaxSquaresPerUser()
+	if maxSquares <= 0 {
+		return nil, nil
+	}
+
+	claimed, err := pool.SquaresClaimedBy(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := maxSquares - claimed
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &remaining, nil
+}
+
 func (s *Server) postPoolEndpoint() http.HandlerFunc {
 	type payload struct {
 		Name         string `json:"name"`
@@ -424,6 +903,11 @@ func (s *Server) postPoolEndpoint() http.HandlerFunc {
 			return
 		}
 
+		if _, err := pool.CommitDrawSeed(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
 		s.writeJSONResponse(w, http.StatusCreated, poolResponse{
 			PoolJSON: pool.JSON(),
 			IsAdmin:  true,
@@ -441,9 +925,26 @@ func (s *Server) getPoolTokenEndpoint() http.HandlerFunc {
 			return
 		}
 
+		squaresRemaining, err := s.squaresRemaining(r.Context(), pool, user)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// the response also depends on who is asking
+		vary := []interface{}{isAdminOf}
+		if squaresRemaining != nil {
+			vary = append(vary, *squaresRemaining)
+		}
+
+		if notModified(w, r, etag(pool.Version(), vary...)) {
+			return
+		}
+
 		s.writeJSONResponse(w, http.StatusOK, poolResponse{
-			PoolJSON: pool.JSON(),
-			IsAdmin:  isAdminOf,
+			PoolJSON:         pool.JSON(),
+			IsAdmin:          isAdminOf,
+			SquaresRemaining: squaresRemaining,
 		})
 	}
 }
@@ -464,18 +965,7 @@ func (s *Server) getPoolTokenInviteTokenEndpoint() http.HandlerFunc {
 			return
 		}
 
-		claim := &inviteClaims{
-			StandardClaims: &jwt.StandardClaims{
-				Audience:  sqmgrInviteAudience,
-				ExpiresAt: time.Now().Add(inviteTokenTTL).Unix(),
-				Issuer:    model.IssuerSqMGR,
-				NotBefore: 0,
-				Subject:   pool.Token(),
-			},
-			CheckID: pool.CheckID(),
-		}
-
-		sign, err := s.smjwt.Sign(claim)
+		sign, err := s.signInviteJWT(pool)
 		if err != nil {
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
@@ -485,42 +975,64 @@ func (s *Server) getPoolTokenInviteTokenEndpoint() http.HandlerFunc {
 	}
 }
 
+// signInviteJWT returns a JWT that lets anyone join the pool without its password
+func (s *Server) signInviteJWT(pool *model.Pool) (string, error) {
+	claim := &inviteClaims{
+		StandardClaims: &jwt.StandardClaims{
+			Audience:  sqmgrInviteAudience,
+			ExpiresAt: time.Now().Add(inviteTokenTTL).Unix(),
+			Issuer:    model.IssuerSqMGR,
+			NotBefore: 0,
+			Subject:   pool.Token(),
+		},
+		CheckID: pool.CheckID(),
+	}
+
+	return s.smjwt.Sign(claim)
+}
+
 func (s *Server) getPoolTokenGridEndpoint() http.HandlerFunc {
 	const defaultPerPage = model.MaxGridsPerPool
 	const maxPerPage = model.MaxGridsPerPool
 
 	type response struct {
 		Grids      []*model.GridJSON `json:"grids"`
-		Total      int64             `json:"total"`
+		Total      *int64            `json:"total,omitempty"`
+		NextCursor string            `json:"nextCursor,omitempty"`
 		MaxAllowed int               `json:"maxAllowed"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
 
-		offset, _ := strconv.ParseInt(r.FormValue("offset"), 10, 64)
-		if offset < 0 {
-			offset = 0
+		page, err := parsePage(r, defaultPerPage, maxPerPage)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err)
+			return
 		}
 
-		limit, _ := strconv.Atoi(r.FormValue("limit"))
-		if limit < 1 {
-			limit = defaultPerPage
-		} else if limit > maxPerPage {
-			s.writeErrorResponse(w, http.StatusBadGateway, fmt.Errorf("limit cannot exceed %d", maxPerPage))
-			return
+		var grids []*model.Grid
+		var next model.Cursor
+		if page.useOffset() {
+			grids, err = pool.Grids(r.Context(), page.offset, page.limit)
+		} else {
+			grids, next, err = pool.GridsAfter(r.Context(), page.cursor, page.limit)
 		}
 
-		grids, err := pool.Grids(r.Context(), offset, limit)
 		if err != nil {
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
 
-		count, err := pool.GridsCount(r.Context())
-		if err != nil {
-			s.writeErrorResponse(w, http.StatusInternalServerError, err)
-			return
+		var total *int64
+		if page.countsTotal() {
+			count, err := pool.GridsCount(r.Context())
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			total = &count
 		}
 
 		gridsJSON := make([]*model.GridJSON, len(grids))
@@ -530,7 +1042,8 @@ func (s *Server) getPoolTokenGridEndpoint() http.HandlerFunc {
 
 		s.writeJSONResponse(w, http.StatusOK, response{
 			Grids:      gridsJSON,
-			Total:      count,
+			Total:      total,
+			NextCursor: next.String(),
 			MaxAllowed: model.MaxGridsPerPool,
 		})
 	}
@@ -562,6 +1075,20 @@ func (s *Server) getPoolTokenGridIDEndpoint() http.HandlerFunc {
 			return
 		}
 
+		if err := grid.LoadScores(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := grid.LoadPayouts(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if notModified(w, r, etag(grid.Version())) {
+			return
+		}
+
 		s.writeJSONResponse(w, http.StatusOK, grid.JSON())
 	}
 }
@@ -569,16 +1096,29 @@ func (s *Server) getPoolTokenGridIDEndpoint() http.HandlerFunc {
 func (s *Server) getPoolTokenSquareEndpoint() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		grid, _ := squareRequest(r)
 
-		squares, err := pool.Squares()
+		squares, err := pool.GridSquares(r.Context(), grid)
 		if err != nil {
+			if err == model.ErrSquaresPerGrid {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
 
+		// the version of a square only goes up, so their sum changes whenever any of them does
+		var version int64
 		squaresJSON := make(map[int]*model.PoolSquareJSON)
 		for key, square := range squares {
 			squaresJSON[key] = square.JSON()
+			version += square.Version()
+		}
+
+		if notModified(w, r, etag(version, len(squares))) {
+			return
 		}
 
 		s.writeJSONResponse(w, http.StatusOK, squaresJSON)
@@ -590,22 +1130,35 @@ func (s *Server) getPoolTokenSquareIDEndpoint() http.HandlerFunc {
 		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
 		user := r.Context().Value(ctxUserKey).(*model.User)
 
-		squareID, _ := strconv.Atoi(mux.Vars(r)["id"])
-		square, err := pool.SquareBySquareID(squareID)
+		grid, squareID := squareRequest(r)
+		square, err := pool.GridSquareBySquareID(r.Context(), grid, squareID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				s.writeErrorResponse(w, http.StatusNotFound, nil)
 				return
 			}
 
+			if err == model.ErrSquaresPerGrid {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
 
-		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+		isAdmin, err := user.IsAdminOf(r.Context(), pool)
+		if err != nil {
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
-		} else if isAdmin {
+		}
+
+		// only admins are given the logs
+		if notModified(w, r, etag(square.Version(), isAdmin)) {
+			return
+		}
+
+		if isAdmin {
 			if err := square.LoadLogs(r.Context()); err != nil {
 				s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				return
@@ -616,6 +1169,19 @@ func (s *Server) getPoolTokenSquareIDEndpoint() http.HandlerFunc {
 	}
 }
 
+// squareRequest returns the grid and the ID of the square being requested. The grid is nil unless the request was
+// made through the grid, e.g. /pool/{token}/grid/{id}/square/{square_id}, in which case the square is one that the
+// grid is played with.
+func squareRequest(r *http.Request) (*model.Grid, int) {
+	if grid, ok := r.Context().Value(ctxGridKey).(*model.Grid); ok {
+		squareID, _ := strconv.Atoi(mux.Vars(r)["square_id"])
+		return grid, squareID
+	}
+
+	squareID, _ := strconv.Atoi(mux.Vars(r)["id"])
+	return nil, squareID
+}
+
 func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 	type postPayload struct {
 		Claimant          string                `json:"claimant"`
@@ -623,14 +1189,18 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 		Note              string                `json:"note"`
 		Unclaim           bool                  `json:"unclaim"`
 		Rename            bool                  `json:"rename"`
+		Hold              bool                  `json:"hold"`
+		OnBehalf          bool                  `json:"onBehalf"`
+		ParticipantID     int64                 `json:"participantId"`
+		NewParticipant    bool                  `json:"newParticipant"`
 		SecondarySquareID int                   `json:"secondarySquareId"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
 		user := r.Context().Value(ctxUserKey).(*model.User)
-		squareID, _ := strconv.Atoi(mux.Vars(r)["id"])
-		square, err := pool.SquareBySquareID(squareID)
+		grid, squareID := squareRequest(r)
+		square, err := pool.GridSquareBySquareID(r.Context(), grid, squareID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				logrus.WithFields(logrus.Fields{
@@ -641,10 +1211,21 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 				return
 			}
 
+			if err == model.ErrSquaresPerGrid {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
 
+		if s.preconditionFailed(w, r, square.Version()) {
+			return
+		}
+
+		version := ifMatchVersion(r, square.Version())
+
 		lr := logrus.WithField("square-id", squareID)
 
 		isAdmin, err := user.IsAdminOf(r.Context(), pool)
@@ -673,7 +1254,7 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 
 		var secondSquare *model.PoolSquare
 		if payload.SecondarySquareID > 0 {
-			secondSquare, err = pool.SquareBySquareID(payload.SecondarySquareID)
+			secondSquare, err = pool.GridSquareBySquareID(r.Context(), grid, payload.SecondarySquareID)
 			if err != nil {
 				if err == sql.ErrNoRows {
 					logrus.WithFields(logrus.Fields{
@@ -719,15 +1300,99 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 				"claimant":    claimant,
 			}).Info("renaming square")
 
-			if err := square.Save(r.Context(), s.model.DB, true, model.PoolSquareLog{
+			if err := square.SaveVersion(r.Context(), s.model.DB, true, version, model.PoolSquareLog{
 				RemoteAddr: r.RemoteAddr,
 				Note:       fmt.Sprintf("admin: changed claimant from %s", oldClaimant),
 			}); err != nil {
+				if err == model.ErrVersionMismatch {
+					s.writeErrorResponse(w, http.StatusPreconditionFailed, err)
+					return
+				}
+
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.publish(pool, broker.EventSquareRenamed, square.JSON())
+		} else if payload.OnBehalf {
+			// claiming a square for someone who does not sign in
+			if !isAdmin {
+				s.writeErrorResponse(w, http.StatusForbidden, errors.New("only an admin can claim a square for someone else"))
+				return
+			}
+
+			var participant *model.PoolParticipant
+			claimant := payload.Claimant
+			if payload.ParticipantID > 0 {
+				participant, err = pool.ParticipantByID(r.Context(), payload.ParticipantID)
+				if err != nil {
+					if err == sql.ErrNoRows {
+						s.writeErrorResponse(w, http.StatusBadRequest, errors.New("participant not found"))
+						return
+					}
+
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+					return
+				}
+
+				claimant = participant.Name()
+			}
+
+			v := validator.New()
+			claimant = v.Printable("name", claimant)
+			claimant = v.ContainsWordChar("name", claimant)
+
+			if !v.OK() {
+				s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+					Status:           statusError,
+					Error:            validationErrorMessage,
+					ValidationErrors: v.Errors,
+				})
+				return
+			}
+
+			squares := []*model.PoolSquare{square}
+			if secondSquare != nil {
+				squares = append(squares, secondSquare)
+			}
+
+			lr.WithField("claimant", claimant).Info("claiming square on behalf of a participant")
+			err = s.inTx(r.Context(), func(tx *sql.Tx) error {
+				var err error
+				if participant == nil && payload.NewParticipant {
+					_, err = pool.ClaimForNewParticipantTx(r.Context(), tx, claimant, r.RemoteAddr, squares...)
+				} else {
+					err = pool.ClaimOnBehalfTx(r.Context(), tx, claimant, participant, r.RemoteAddr, squares...)
+				}
+
+				if err != nil {
+					return err
+				}
+
+				return s.enqueueSquareWebhooks(r.Context(), tx, pool, broker.EventSquareClaimed, squares)
+			})
+
+			if err != nil {
+				if err == model.ErrSquareAlreadyClaimed {
+					s.writeErrorResponse(w, http.StatusBadRequest, err)
+					return
+				}
+
 				s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
+
+			s.webhooks.Wake()
+			for _, square := range squares {
+				s.publish(pool, broker.EventSquareClaimed, square.JSON())
+			}
 		} else if len(payload.Claimant) > 0 {
 			// making a claim
+			if pool.RandomAssignment() && !isAdmin {
+				s.writeErrorResponse(w, http.StatusBadRequest, model.ErrRandomAssignment)
+				return
+			}
+
 			v := validator.New()
 			claimant := v.Printable("name", payload.Claimant)
 			claimant = v.ContainsWordChar("name", claimant)
@@ -741,8 +1406,28 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 				return
 			}
 
+			state := model.PoolSquareStateClaimed
+			note := "user: initial claim"
+			event := broker.EventSquareClaimed
+			if payload.Hold {
+				state = model.PoolSquareStateHeld
+				note = "user: hold"
+				event = broker.EventSquareHeld
+			}
+
+			// the member is turning their hold into a claim
+			isHolder := square.State == model.PoolSquareStateHeld && square.UserID() == user.ID
+			if isHolder {
+				if payload.Hold {
+					s.writeErrorResponse(w, http.StatusBadRequest, errors.New("you are already holding this square"))
+					return
+				}
+
+				note = "user: claimed held square"
+			}
+
 			square.SetClaimant(claimant)
-			square.State = model.PoolSquareStateClaimed
+			square.State = state
 			square.SetUserID(user.ID)
 
 			tx, err := s.model.DB.BeginTx(r.Context(), nil)
@@ -751,15 +1436,35 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 				return
 			}
 
-			lr.WithField("claimant", payload.Claimant).Info("claiming square")
-			if err := square.Save(r.Context(), tx, false, model.PoolSquareLog{
+			// the secondary square was held along with the square
+			if isHolder {
+				childSquares, err := square.ChildSquares(r.Context(), tx)
+				if err != nil {
+					_ = tx.Rollback()
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+					return
+				}
+
+				secondSquare = nil
+				if len(childSquares) > 0 {
+					secondSquare = childSquares[0]
+				}
+			}
+
+			lr.WithFields(logrus.Fields{
+				"claimant": payload.Claimant,
+				"state":    state,
+			}).Info("claiming square")
+			if err := square.SaveVersion(r.Context(), tx, false, version, model.PoolSquareLog{
 				RemoteAddr: r.RemoteAddr,
-				Note:       "user: initial claim",
+				Note:       note,
 			}); err != nil {
 				_ = tx.Rollback()
 
-				if err == model.ErrSquareAlreadyClaimed {
+				if err == model.ErrSquareAlreadyClaimed || err == model.ErrSquareLimit {
 					s.writeErrorResponse(w, http.StatusBadRequest, err)
+				} else if err == model.ErrVersionMismatch {
+					s.writeErrorResponse(w, http.StatusPreconditionFailed, err)
 				} else {
 					s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				}
@@ -769,30 +1474,47 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 
 			if secondSquare != nil {
 				secondSquare.SetClaimant(claimant)
-				secondSquare.State = model.PoolSquareStateClaimed
+				secondSquare.State = state
 				secondSquare.SetUserID(user.ID)
 
-				if err := secondSquare.Save(r.Context(), tx, false, model.PoolSquareLog{
-					RemoteAddr: r.RemoteAddr,
-					Note:       "user: initial claim (secondary)",
-				}); err != nil {
+				// the parent is set first so that the secondary square does not count towards the user's limit
+				if err := secondSquare.SetParentSquare(r.Context(), tx, square); err != nil {
 					_ = tx.Rollback()
 					s.writeErrorResponse(w, http.StatusInternalServerError, err)
 					return
 				}
 
-				if err := secondSquare.SetParentSquare(r.Context(), tx, square); err != nil {
+				if err := secondSquare.Save(r.Context(), tx, false, model.PoolSquareLog{
+					RemoteAddr: r.RemoteAddr,
+					Note:       note + " (secondary)",
+				}); err != nil {
 					_ = tx.Rollback()
 					s.writeErrorResponse(w, http.StatusInternalServerError, err)
 					return
 				}
 			}
 
+			claimed := []*model.PoolSquare{square}
+			if secondSquare != nil {
+				claimed = append(claimed, secondSquare)
+			}
+
+			if err := s.enqueueSquareWebhooks(r.Context(), tx, pool, event, claimed); err != nil {
+				_ = tx.Rollback()
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
 			if err := tx.Commit(); err != nil {
 				_ = tx.Rollback()
 				s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
+
+			s.webhooks.Wake()
+			for _, square := range claimed {
+				s.publish(pool, event, square.JSON())
+			}
 		} else if payload.Unclaim && square.UserID() == user.ID {
 			tx, err := square.Model.DB.BeginTx(r.Context(), nil)
 			if err != nil {
@@ -802,7 +1524,7 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 
 			squares := []*model.PoolSquare{square}
 			if square.ParentID > 0 {
-				pSq, err := pool.SquareBySquareID(square.ParentSquareID)
+				pSq, err := pool.GridSquareBySquareID(r.Context(), grid, square.ParentSquareID)
 				if err != nil {
 					_ = tx.Rollback()
 					s.writeErrorResponse(w, http.StatusInternalServerError, err)
@@ -819,16 +1541,34 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 			}
 			squares = append(squares, childSquares...)
 
-			for _, square := range squares {
+			for i, square := range squares {
 				// trying to unclaim as user
 				square.State = model.PoolSquareStateUnclaimed
 				square.SetUserID(user.ID)
 
-				if err := square.Save(r.Context(), tx, false, model.PoolSquareLog{
+				// only the square of the request is expected to be at the version of If-Match
+				var squareVersion int64
+				if i == 0 {
+					squareVersion = version
+				}
+
+				if err := square.SaveVersion(r.Context(), tx, false, squareVersion, model.PoolSquareLog{
 					RemoteAddr: r.RemoteAddr,
 					Note:       fmt.Sprintf("user: `%s` unclaimed", square.Claimant()),
 				}); err != nil {
 					_ = tx.Rollback()
+
+					if err == model.ErrVersionMismatch {
+						s.writeErrorResponse(w, http.StatusPreconditionFailed, err)
+						return
+					}
+
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+					return
+				}
+
+				if err := s.enqueueWebhooks(r.Context(), tx, pool, broker.EventSquareUnclaimed, square.JSON()); err != nil {
+					_ = tx.Rollback()
 					s.writeErrorResponse(w, http.StatusInternalServerError, err)
 					return
 				}
@@ -838,19 +1578,47 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 				s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
+
+			s.webhooks.Wake()
+			for _, square := range squares {
+				s.publish(pool, broker.EventSquareUnclaimed, square.JSON())
+			}
 		} else if isAdmin {
 			// admin actions
+			oldState := square.State
 			if payload.State.IsValid() {
 				square.State = payload.State
 			}
 
-			if err := square.Save(r.Context(), s.model.DB, true, model.PoolSquareLog{
-				RemoteAddr: r.RemoteAddr,
-				Note:       payload.Note,
-			}); err != nil {
+			event := broker.EventSquareStateChanged
+			if square.State == model.PoolSquareStateUnclaimed {
+				event = broker.EventSquareUnclaimed
+			}
+
+			err := s.inTx(r.Context(), func(tx *sql.Tx) error {
+				if err := square.SaveVersion(r.Context(), tx, true, version, model.PoolSquareLog{
+					RemoteAddr: r.RemoteAddr,
+					Note:       payload.Note,
+				}); err != nil || square.State == oldState {
+					return err
+				}
+
+				return s.enqueueWebhooks(r.Context(), tx, pool, event, square.JSON())
+			})
+			if err != nil {
+				if err == model.ErrVersionMismatch {
+					s.writeErrorResponse(w, http.StatusPreconditionFailed, err)
+					return
+				}
+
 				s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
+
+			if square.State != oldState {
+				s.webhooks.Wake()
+				s.publish(pool, event, square.JSON())
+			}
 		} else {
 			lr.WithField("remoteAddr", r.RemoteAddr).Warn("non-admin tried to administer squares")
 			s.writeErrorResponse(w, http.StatusForbidden, nil)
@@ -868,6 +1636,17 @@ func (s *Server) postPoolTokenSquareIDEndpoint() http.HandlerFunc {
 	}
 }
 
+// drawSeedRevealed responds to a draw that was attempted with a seed that is already public. The pool is committed to
+// a fresh seed, which has to be published before the numbers can be drawn with it, so the draw is not retried here.
+func (s *Server) drawSeedRevealed(w http.ResponseWriter, r *http.Request, pool *model.Pool) {
+	if _, err := pool.CommitDrawSeed(r.Context()); err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.writeErrorResponse(w, http.StatusConflict, errors.New("the draw seed has already been revealed, a new one has been committed"))
+}
+
 func (s *Server) postPoolTokenGridIDEndpoint() http.HandlerFunc {
 	type payload struct {
 		Action string `json:"action"`
@@ -883,8 +1662,16 @@ func (s *Server) postPoolTokenGridIDEndpoint() http.HandlerFunc {
 			AwayTeamColor1 string `json:"awayTeamColor1"`
 			AwayTeamColor2 string `json:"awayTeamColor2"`
 
+			SquarePrice   *int64               `json:"squarePrice"`
+			HouseCut      *int                 `json:"houseCut"`
+			UnclaimedRule *model.UnclaimedRule `json:"unclaimedRule"`
+
 			HomeTeamNumbers []int `json:"homeTeamNumbers"`
 			AwayTeamNumbers []int `json:"awayTeamNumbers"`
+
+			Payouts []*model.GridPayout `json:"payouts"`
+
+			Entropy string `json:"entropy"`
 		} `json:"data,omitempty"`
 	}
 
@@ -913,6 +1700,7 @@ func (s *Server) postPoolTokenGridIDEndpoint() http.HandlerFunc {
 		}
 
 		var grid *model.Grid
+		var version int64
 		if gridID > 0 {
 			var err error
 			grid, err = pool.GridByID(r.Context(), gridID)
@@ -935,6 +1723,22 @@ func (s *Server) postPoolTokenGridIDEndpoint() http.HandlerFunc {
 				s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
+
+			if err := grid.LoadScores(r.Context()); err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			if err := grid.LoadPayouts(r.Context()); err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			if s.preconditionFailed(w, r, grid.Version()) {
+				return
+			}
+
+			version = ifMatchVersion(r, grid.Version())
 		} else if data.Action != "save" {
 			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("cannot call action %s without an ID", data.Action))
 			return
@@ -946,29 +1750,143 @@ func (s *Server) postPoolTokenGridIDEndpoint() http.HandlerFunc {
 				s.writeErrorResponse(w, http.StatusBadRequest, errors.New("the numbers supplied are not valid"))
 			}
 
-			if err := grid.Save(r.Context()); err != nil {
+			err := s.audit(r, pool, user, model.AuditEventNumbersDrawn, nil, func(tx *sql.Tx) (interface{}, error) {
+				if err := grid.SaveVersionTx(r.Context(), tx, version); err != nil {
+					return nil, err
+				}
+
+				if err := s.enqueueWebhooks(r.Context(), tx, pool, broker.EventNumbersDrawn, grid.JSON()); err != nil {
+					return nil, err
+				}
+
+				return auditState{
+					"gridId":      grid.ID(),
+					"manual":      true,
+					"homeNumbers": grid.HomeNumbers(),
+					"awayNumbers": grid.AwayNumbers(),
+				}, nil
+			})
+			if err != nil {
+				if err == model.ErrVersionMismatch {
+					s.writeErrorResponse(w, http.StatusPreconditionFailed, err)
+					return
+				}
+
 				s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 
+			s.webhooks.Wake()
+			s.publish(pool, broker.EventNumbersDrawn, grid.JSON())
 			s.writeJSONResponse(w, http.StatusOK, grid.JSON())
 			return
 		case "drawNumbers":
-			if err := grid.SelectRandomNumbers(); err != nil {
+			entropy := ""
+			if data.Data != nil {
+				v := validator.New()
+				entropy = v.Printable("Entropy", data.Data.Entropy, true)
+				entropy = v.MaxLength("Entropy", entropy, model.DrawEntropyMaxLength)
+				if !v.OK() {
+					s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+						Status:           statusError,
+						Error:            validationErrorMessage,
+						ValidationErrors: v.Errors,
+					})
+					return
+				}
+			}
+
+			commitment, err := pool.DrawCommitment(r.Context())
+			if err == sql.ErrNoRows {
+				commitment, err = pool.CommitDrawSeed(r.Context())
+			}
+
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			if err := grid.SelectCommittedNumbers(commitment, entropy); err != nil {
 				if err == model.ErrNumbersAlreadyDrawn {
 					s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("the numbers have already been drawn"))
 					return
 				}
 
+				if err == model.ErrDrawSeedRevealed {
+					s.drawSeedRevealed(w, r, pool)
+					return
+				}
+
 				s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 
-			if err := grid.Save(r.Context()); err != nil {
+			err = s.audit(r, pool, user, model.AuditEventNumbersDrawn, nil, func(tx *sql.Tx) (interface{}, error) {
+				if err := grid.SaveVersionTx(r.Context(), tx, version); err != nil {
+					return nil, err
+				}
+
+				if err := s.enqueueWebhooks(r.Context(), tx, pool, broker.EventNumbersDrawn, grid.JSON()); err != nil {
+					return nil, err
+				}
+
+				return auditState{
+					"gridId":      grid.ID(),
+					"manual":      false,
+					"entropy":     entropy,
+					"homeNumbers": grid.HomeNumbers(),
+					"awayNumbers": grid.AwayNumbers(),
+				}, nil
+			})
+			if err != nil {
+				if err == model.ErrDrawSeedRevealed {
+					s.drawSeedRevealed(w, r, pool)
+					return
+				}
+
+				if err == model.ErrVersionMismatch {
+					s.writeErrorResponse(w, http.StatusPreconditionFailed, err)
+					return
+				}
+
 				s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 
+			s.webhooks.Wake()
+			s.publish(pool, broker.EventNumbersDrawn, grid.JSON())
+			s.writeJSONResponse(w, http.StatusOK, grid.JSON())
+			return
+		case "savePayouts":
+			if data.Data == nil {
+				s.writeErrorResponse(w, http.StatusBadRequest, errors.New("missing data in payload"))
+				return
+			}
+
+			before := auditState{"gridId": grid.ID(), "payouts": grid.JSON().Payouts}
+			err := s.audit(r, pool, user, model.AuditEventGridPayoutsSaved, before, func(tx *sql.Tx) (interface{}, error) {
+				if err := grid.SetPayoutsTx(r.Context(), tx, data.Data.Payouts, version); err != nil {
+					return nil, err
+				}
+
+				return auditState{"gridId": grid.ID(), "payouts": data.Data.Payouts}, nil
+			})
+			if err != nil {
+				if err == model.ErrInvalidPayouts {
+					s.writeErrorResponse(w, http.StatusBadRequest, errors.New("each period can only be paid once, the percentages cannot add up to more than 100 and the fixed amounts must fit in the pot"))
+					return
+				}
+
+				if err == model.ErrVersionMismatch {
+					s.writeErrorResponse(w, http.StatusPreconditionFailed, err)
+					return
+				}
+
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.publish(pool, broker.EventGridUpdated, grid.JSON())
 			s.writeJSONResponse(w, http.StatusOK, grid.JSON())
 			return
 		case "save":
@@ -995,6 +1913,18 @@ func (s *Server) postPoolTokenGridIDEndpoint() http.HandlerFunc {
 				v.AddError("rollover", "Rollover is not valid for this pool type")
 			}
 
+			if data.Data.SquarePrice != nil && *data.Data.SquarePrice < 0 {
+				v.AddError("squarePrice", "Square Price cannot be negative")
+			}
+
+			if data.Data.HouseCut != nil {
+				v.IntInRange("House Cut", *data.Data.HouseCut, 0, 101)
+			}
+
+			if data.Data.UnclaimedRule != nil && !data.Data.UnclaimedRule.IsValid() {
+				v.AddError("unclaimedRule", "%s is not a valid unclaimed rule", *data.Data.UnclaimedRule)
+			}
+
 			if !v.OK() {
 				s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
 					Status:           statusError,
@@ -1004,8 +1934,15 @@ func (s *Server) postPoolTokenGridIDEndpoint() http.HandlerFunc {
 				return
 			}
 
+			eventType := broker.EventGridUpdated
+			auditEvent := model.AuditEventGridUpdated
+			var before interface{}
 			if grid == nil {
 				grid = pool.NewGrid()
+				eventType = broker.EventGridCreated
+				auditEvent = model.AuditEventGridCreated
+			} else {
+				before = gridAuditState(grid)
 			}
 
 			grid.SetEventDate(eventDate)
@@ -1020,16 +1957,47 @@ func (s *Server) postPoolTokenGridIDEndpoint() http.HandlerFunc {
 			settings.SetAwayTeamColor1(awayTeamColor1)
 			settings.SetAwayTeamColor2(awayTeamColor2)
 
-			if err := grid.Save(r.Context()); err != nil {
+			if data.Data.SquarePrice != nil {
+				settings.SetSquarePrice(*data.Data.SquarePrice)
+			}
+
+			if data.Data.HouseCut != nil {
+				settings.SetHouseCut(*data.Data.HouseCut)
+			}
+
+			if data.Data.UnclaimedRule != nil {
+				settings.SetUnclaimedRule(*data.Data.UnclaimedRule)
+			}
+
+			err := s.audit(r, pool, user, auditEvent, before, func(tx *sql.Tx) (interface{}, error) {
+				if err := grid.SaveVersionTx(r.Context(), tx, version); err != nil {
+					return nil, err
+				}
+
+				if err := s.enqueueWebhooks(r.Context(), tx, pool, eventType, grid.JSON()); err != nil {
+					return nil, err
+				}
+
+				return gridAuditState(grid), nil
+			})
+			if err != nil {
 				if err == model.ErrGridLimit {
 					s.writeErrorResponse(w, http.StatusBadRequest, err)
 					return
 				}
 
+				if err == model.ErrVersionMismatch {
+					s.writeErrorResponse(w, http.StatusPreconditionFailed, err)
+					return
+				}
+
 				s.writeErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 
+			s.rescheduleJobs(r.Context(), pool)
+			s.webhooks.Wake()
+			s.publish(pool, eventType, grid.JSON())
 			s.writeJSONResponse(w, http.StatusAccepted, grid.JSON())
 			return
 		}
@@ -1046,6 +2014,8 @@ func (s *Server) postPoolTokenGridIDSquareSquareIDAnnotationEndpoint() http.Hand
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
 		grid := r.Context().Value(ctxGridKey).(*model.Grid)
 		squareID := r.Context().Value(ctxSquareIDKey).(int)
 
@@ -1077,10 +2047,22 @@ func (s *Server) postPoolTokenGridIDSquareSquareIDAnnotationEndpoint() http.Hand
 			return
 		}
 
+		var before interface{}
+		if !a.Created.IsZero() {
+			before = annotationAuditState(a)
+		}
+
 		a.Annotation = annotation
 		a.Icon = payloadData.Icon
 		isNew := a.Created.IsZero()
-		if err := a.Save(r.Context()); err != nil {
+		err = s.audit(r, pool, user, model.AuditEventAnnotationSaved, before, func(tx *sql.Tx) (interface{}, error) {
+			if err := a.SaveTx(r.Context(), tx); err != nil {
+				return nil, err
+			}
+
+			return annotationAuditState(a), nil
+		})
+		if err != nil {
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
@@ -1090,25 +2072,231 @@ func (s *Server) postPoolTokenGridIDSquareSquareIDAnnotationEndpoint() http.Hand
 			status = http.StatusCreated
 		}
 
+		s.publish(pool, broker.EventAnnotationSaved, a)
+
 		s.writeJSONResponse(w, status, a)
 	}
 }
 
 func (s *Server) deletePoolTokenGridIDSquareSquareIDAnnotationEndpoint() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
 		grid := r.Context().Value(ctxGridKey).(*model.Grid)
 		squareID := r.Context().Value(ctxSquareIDKey).(int)
 
-		if err := grid.DeleteAnnotationBySquareID(r.Context(), squareID); err != nil {
+		a, err := grid.AnnotationBySquareID(r.Context(), squareID)
+		if err != nil {
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
 
+		// there is nothing to record if the square did not have an annotation
+		if a.Created.IsZero() {
+			err = grid.DeleteAnnotationBySquareID(r.Context(), squareID)
+		} else {
+			err = s.audit(r, pool, user, model.AuditEventAnnotationDeleted, annotationAuditState(a), func(tx *sql.Tx) (interface{}, error) {
+				return nil, grid.DeleteAnnotationBySquareIDTx(r.Context(), tx, squareID)
+			})
+		}
+
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(pool, broker.EventAnnotationDeleted, gridEventData{GridID: grid.ID(), SquareID: squareID})
+
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 }
 
+func (s *Server) postPoolTokenGridIDScoreEndpoint() http.HandlerFunc {
+	type payload struct {
+		Period    model.GridScorePeriod `json:"period"`
+		HomeScore int                   `json:"homeScore"`
+		AwayScore int                   `json:"awayScore"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		grid := r.Context().Value(ctxGridKey).(*model.Grid)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		v := validator.New()
+		if !data.Period.IsValid() {
+			v.AddError("period", "%s is not a valid period", data.Period)
+		}
+
+		v.IntInRange("Home Score", data.HomeScore, 0, model.ScoreMax+1)
+		v.IntInRange("Away Score", data.AwayScore, 0, model.ScoreMax+1)
+
+		if !v.OK() {
+			s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+				Status:           statusError,
+				Error:            validationErrorMessage,
+				ValidationErrors: v.Errors,
+			})
+			return
+		}
+
+		err := s.audit(r, pool, user, model.AuditEventScoreSaved, nil, func(tx *sql.Tx) (interface{}, error) {
+			if _, err := grid.SetScoreTx(r.Context(), tx, data.Period, data.HomeScore, data.AwayScore); err != nil {
+				return nil, err
+			}
+
+			return auditState{
+				"gridId":    grid.ID(),
+				"period":    data.Period,
+				"homeScore": data.HomeScore,
+				"awayScore": data.AwayScore,
+			}, nil
+		})
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := grid.LoadSettings(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := grid.LoadAnnotations(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := grid.LoadScores(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := grid.LoadPayouts(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(pool, broker.EventScoreSaved, grid.JSON())
+		s.writeJSONResponse(w, http.StatusOK, grid.JSON())
+	}
+}
+
+func (s *Server) getPoolTokenGridIDPayoutEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		grid := r.Context().Value(ctxGridKey).(*model.Grid)
+
+		if err := grid.LoadSettings(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := grid.LoadScores(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := grid.LoadPayouts(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		squares, err := pool.GridSquares(r.Context(), grid)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, grid.Winnings(squares))
+	}
+}
+
+func (s *Server) getPoolTokenGridIDDrawEndpoint() http.HandlerFunc {
+	type response struct {
+		Draw       *model.GridDrawJSON       `json:"draw"`
+		Commitment *model.DrawCommitmentJSON `json:"commitment"`
+		ManualDraw bool                      `json:"manualDraw"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		grid := r.Context().Value(ctxGridKey).(*model.Grid)
+
+		resp := response{ManualDraw: grid.JSON().ManualDraw}
+
+		draw, err := grid.Draw(r.Context())
+		if err == nil {
+			resp.Draw = draw.JSON()
+			resp.Commitment = resp.Draw.Commitment
+			s.writeJSONResponse(w, http.StatusOK, resp)
+			return
+		} else if err != sql.ErrNoRows {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// not drawn from a seed yet, so show the seed that it will be drawn from
+		commitment, err := pool.DrawCommitment(r.Context())
+		if err == nil {
+			resp.Commitment = commitment.JSON()
+		} else if err != sql.ErrNoRows {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, resp)
+	}
+}
+
+func (s *Server) deletePoolTokenGridIDScorePeriodEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		grid := r.Context().Value(ctxGridKey).(*model.Grid)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		period := model.GridScorePeriod(mux.Vars(r)["period"])
+		if !period.IsValid() {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("%s is not a valid period", period))
+			return
+		}
+
+		err := s.audit(r, pool, user, model.AuditEventScoreDeleted, auditState{"gridId": grid.ID(), "period": period}, func(tx *sql.Tx) (interface{}, error) {
+			return nil, grid.DeleteScoreTx(r.Context(), tx, period)
+		})
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(pool, broker.EventScoreDeleted, gridEventData{GridID: grid.ID(), Period: period})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
 func (s *Server) postPoolTokenMemberEndpoint() http.HandlerFunc {
 	type payload struct {
 		Password string `json:"password"`
@@ -1155,6 +2343,11 @@ func (s *Server) postPoolTokenMemberEndpoint() http.HandlerFunc {
 		}
 
 		if err := user.JoinPool(r.Context(), pool); err != nil {
+			if err == model.ErrUserBanned {
+				s.writeErrorResponse(w, http.StatusForbidden, err)
+				return
+			}
+
 			s.writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
@@ -1166,4 +2359,7 @@ func (s *Server) postPoolTokenMemberEndpoint() http.HandlerFunc {
 type poolResponse struct {
 	*model.PoolJSON
 	IsAdmin bool `json:"isAdmin"`
+
+	// SquaresRemaining is how many more squares the user may claim. It is omitted if there is no limit.
+	SquaresRemaining *int `json:"squaresRemaining,omitempty"`
 }
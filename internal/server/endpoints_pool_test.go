@@ -0,0 +1,163 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+func TestDeleteGridRequiresAdmin(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := model.New(getDB())
+	s := newTestServer(m)
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, model.IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	member, err := m.GetUser(ctx, model.IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "Test Pool", model.GridTypeStd25, "my-password")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(member.JoinPool(ctx, pool)).Should(gomega.Succeed())
+
+	grid := pool.NewGrid()
+	g.Expect(grid.Save(ctx)).Should(gomega.Succeed())
+
+	vars := map[string]string{"id": strconv.FormatInt(grid.ID(), 10)}
+
+	// a member cannot delete a grid, and the grid is left as it was
+	w := serveTestRequest(s.deletePoolTokenGridIDEndpoint(), newTestRequest(http.MethodDelete, pool, member, nil, vars))
+	g.Expect(w.Code).Should(gomega.Equal(http.StatusForbidden))
+
+	stillThere, err := pool.GridByID(ctx, grid.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(stillThere.ID()).Should(gomega.Equal(grid.ID()))
+
+	// an admin can
+	w = serveTestRequest(s.deletePoolTokenGridIDEndpoint(), newTestRequest(http.MethodDelete, pool, owner, nil, vars))
+	g.Expect(w.Code).Should(gomega.Equal(http.StatusNoContent))
+
+	_, err = pool.GridByID(ctx, grid.ID())
+	g.Expect(err).Should(gomega.Equal(sql.ErrNoRows))
+}
+
+func TestDeleteGridQueuesWebhook(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := model.New(getDB())
+	s := newTestServer(m)
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, model.IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "Test Pool", model.GridTypeStd25, "my-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	hook, err := pool.NewWebhook(ctx, "https://example.com/hook", []string{"gridDeleted"})
+	g.Expect(err).Should(gomega.Succeed())
+
+	first, err := pool.DefaultGrid(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+
+	grid := pool.NewGrid()
+	g.Expect(grid.Save(ctx)).Should(gomega.Succeed())
+
+	deleteGrid := func(grid *model.Grid) int {
+		vars := map[string]string{"id": strconv.FormatInt(grid.ID(), 10)}
+		return serveTestRequest(s.deletePoolTokenGridIDEndpoint(), newTestRequest(http.MethodDelete, pool, owner, nil, vars)).Code
+	}
+
+	g.Expect(deleteGrid(grid)).Should(gomega.Equal(http.StatusNoContent))
+
+	deliveries, err := hook.Deliveries(ctx, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(deliveries)).Should(gomega.Equal(1))
+	g.Expect(deliveries[0].Event).Should(gomega.Equal("gridDeleted"))
+
+	// the last grid cannot be deleted, so nothing is queued for it
+	g.Expect(deleteGrid(first)).Should(gomega.Equal(http.StatusBadRequest))
+
+	deliveries, err = hook.Deliveries(ctx, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(deliveries)).Should(gomega.Equal(1))
+}
+
+func TestPoolSettingsIfMatch(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := model.New(getDB())
+	s := newTestServer(m)
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, model.IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "Test Pool", model.GridTypeStd25, "my-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	// both requests loaded the pool at the same version before either of them saved it
+	rename := func(name string) int {
+		r := newTestRequest(http.MethodPost, pool, owner, []byte(`{"action":"rename","name":"`+name+`"}`), nil)
+		r.Header.Set("If-Match", etag(pool.Version()))
+		return serveTestRequest(s.postPoolTokenEndpoint(), r).Code
+	}
+
+	g.Expect(rename("First")).Should(gomega.Equal(http.StatusOK))
+	g.Expect(rename("Second")).Should(gomega.Equal(http.StatusPreconditionFailed))
+
+	pool, err = m.PoolByID(pool.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(pool.Name()).Should(gomega.Equal("First"))
+}
+
+func TestPoolTimeZoneAudited(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := model.New(getDB())
+	s := newTestServer(m)
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, model.IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "Test Pool", model.GridTypeStd25, "my-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	r := newTestRequest(http.MethodPost, pool, owner, []byte(`{"action":"timeZone","timeZone":"America/Chicago"}`), nil)
+	g.Expect(serveTestRequest(s.postPoolTokenEndpoint(), r).Code).Should(gomega.Equal(http.StatusOK))
+
+	events, err := pool.AuditEvents(ctx, model.AuditFilter{Event: model.AuditEventPoolTimeZoneChanged}, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(events).Should(gomega.HaveLen(1))
+	g.Expect(events[0].UserID()).Should(gomega.Equal(owner.ID))
+	g.Expect(string(events[0].JSON().Before)).Should(gomega.MatchJSON(`{"timeZone":"America/New_York"}`))
+	g.Expect(string(events[0].JSON().After)).Should(gomega.MatchJSON(`{"timeZone":"America/Chicago"}`))
+}
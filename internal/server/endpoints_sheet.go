@@ -0,0 +1,267 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/internal/config"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+	"github.com/sqmgr/sqmgr-api/pkg/render"
+	"rsc.io/qr"
+)
+
+// measurements of the pool sheet in millimeters
+const (
+	sheetMargin     = 12.0
+	sheetBoardWidth = 150.0
+	sheetQRSize     = 42.0
+	sheetLineHeight = 5.0
+)
+
+func (s *Server) getPoolTokenGridIDSheetPDFEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		grid := r.Context().Value(ctxGridKey).(*model.Grid)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		board, err := gridBoard(r.Context(), pool, grid)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := grid.LoadScores(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := grid.LoadPayouts(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		squares, err := pool.GridSquares(r.Context(), grid)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		invite, err := s.signInviteJWT(pool)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		sheet := poolSheet{
+			pool:     pool,
+			grid:     grid,
+			board:    board,
+			payouts:  grid.JSON().Payouts,
+			winnings: grid.Winnings(squares),
+			joinURL:  fmt.Sprintf("%s/pool/%s/join?jwt=%s", config.WebURL(), url.PathEscape(pool.Token()), url.QueryEscape(invite)),
+		}
+
+		var buf bytes.Buffer
+		if err := sheet.write(&buf); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="sqmgr-%s-grid-%d.pdf"`, pool.Token(), grid.ID()))
+		w.WriteHeader(http.StatusOK)
+		if _, err := buf.WriteTo(w); err != nil {
+			logrus.WithError(err).Error("could not write pool sheet")
+		}
+	}
+}
+
+// poolSheet is a printable, single page PDF of a grid for pools that are run on paper
+type poolSheet struct {
+	pool     *model.Pool
+	grid     *model.Grid
+	board    *render.Board
+	payouts  []*model.GridPayout
+	winnings *model.GridWinnings
+	joinURL  string
+}
+
+func (p poolSheet) write(buf *bytes.Buffer) error {
+	pdf := gofpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(sheetMargin, sheetMargin, sheetMargin)
+	pdf.SetAutoPageBreak(false, sheetMargin)
+	pdf.SetTitle(p.grid.Name(), true)
+	pdf.SetCreator("SqMGR", false)
+	pdf.AddPage()
+
+	// the core fonts are not UTF-8, so everything needs to go through the translator
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageWidth, pageHeight := pdf.GetPageSize()
+	contentWidth := pageWidth - 2*sheetMargin
+
+	pdf.SetTextColor(0x77, 0x77, 0x77)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(contentWidth, sheetLineHeight, tr(p.pool.Name()), "", 1, "C", false, 0, "")
+
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(contentWidth, 8, tr(p.grid.Name()), "", 1, "C", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(contentWidth, sheetLineHeight+1, tr(p.eventDate()), "", 1, "C", false, 0, "")
+
+	// the board is the same image that is served as a PNG
+	var img bytes.Buffer
+	if err := render.PNG(&img, p.board); err != nil {
+		return err
+	}
+
+	info := pdf.RegisterImageOptionsReader("board", gofpdf.ImageOptions{ImageType: "PNG"}, &img)
+	if pdf.Err() {
+		return pdf.Error()
+	}
+
+	boardHeight := sheetBoardWidth * info.Height() / info.Width()
+	boardY := pdf.GetY() + 2
+	pdf.ImageOptions("board", (pageWidth-sheetBoardWidth)/2, boardY, sheetBoardWidth, boardHeight, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+	// the payout table and notes go on the left and the QR code goes on the right
+	top := boardY + boardHeight + 4
+	tableWidth := contentWidth - sheetQRSize - 6
+	pdf.SetXY(sheetMargin, top)
+	p.writePayouts(pdf, tr, tableWidth)
+
+	if notes := p.grid.Settings().Notes(); notes != "" {
+		pdf.Ln(2)
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(tableWidth, 4, "Notes", "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+
+		// only print the lines that fit on the page
+		lines := pdf.SplitLines([]byte(tr(notes)), tableWidth)
+		for _, line := range lines {
+			if pdf.GetY()+4 > pageHeight-sheetMargin {
+				break
+			}
+
+			pdf.CellFormat(tableWidth, 4, string(line), "", 1, "L", false, 0, "")
+		}
+	}
+
+	code, err := qr.Encode(p.joinURL, qr.M)
+	if err != nil {
+		return err
+	}
+
+	pdf.RegisterImageOptionsReader("qr", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(code.PNG()))
+	qrX := pageWidth - sheetMargin - sheetQRSize
+	pdf.ImageOptions("qr", qrX, top, sheetQRSize, sheetQRSize, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, p.joinURL)
+	pdf.SetXY(qrX, top+sheetQRSize)
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(sheetQRSize, 4, "Scan to join the pool", "", 1, "C", false, 0, "")
+
+	return pdf.Output(buf)
+}
+
+// eventDate returns the event date of the grid in the time zone of the pool
+func (p poolSheet) eventDate() string {
+	if p.grid.EventDate().IsZero() {
+		return "Event date to be announced"
+	}
+
+	return p.grid.EventDate().In(p.pool.Location()).Format("Monday, January 2, 2006 3:04 PM MST")
+}
+
+func (p poolSheet) writePayouts(pdf *gofpdf.Fpdf, tr func(string) string, width float64) {
+	w := p.winnings
+
+	pdf.SetFont("Helvetica", "", 9)
+	summary := fmt.Sprintf("Square price: %s   Squares sold: %d   Pot: %s", formatCents(w.SquarePrice), w.SquaresSold, formatCents(w.Pot))
+	if w.HouseCut > 0 {
+		summary += fmt.Sprintf("   House cut: %s", formatCents(w.HouseCut))
+	}
+	pdf.CellFormat(width, sheetLineHeight, summary, "", 1, "L", false, 0, "")
+
+	payouts := make(map[model.GridScorePeriod]*model.GridPayout)
+	for _, payout := range p.payouts {
+		payouts[payout.Period] = payout
+	}
+
+	columns := []float64{width * 0.25, width * 0.2, width * 0.2, width * 0.35}
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(0xee, 0xee, 0xee)
+	for i, header := range []string{"Period", "Payout", "Amount", "Winner"} {
+		pdf.CellFormat(columns[i], sheetLineHeight, header, "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	if len(w.Periods) == 0 {
+		pdf.CellFormat(width, sheetLineHeight, "No payouts have been set", "1", 1, "L", false, 0, "")
+		return
+	}
+
+	for _, period := range w.Periods {
+		payout := ""
+		if gp, ok := payouts[period.Period]; ok {
+			if gp.AmountType == model.PayoutAmountTypePercent {
+				payout = fmt.Sprintf("%d%%", gp.Amount)
+			} else {
+				payout = formatCents(gp.Amount)
+			}
+		}
+
+		winner := ""
+		switch period.Status {
+		case model.PayoutStatusWon:
+			winner = fmt.Sprintf("#%d %s", period.SquareID, period.Claimant)
+		case model.PayoutStatusUnclaimed:
+			winner = fmt.Sprintf("#%d unclaimed", period.SquareID)
+		}
+
+		row := []string{period.Period.Description(), payout, formatCents(period.Amount + period.RolledOverIn), winner}
+		for i, cell := range row {
+			pdf.CellFormat(columns[i], sheetLineHeight, tr(cell), "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+// formatCents formats an amount in cents as dollars
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
+}
@@ -0,0 +1,323 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/internal/broker"
+	"github.com/sqmgr/sqmgr-api/internal/validator"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+// errBatchFailed is returned when a batch was not saved because some of its squares could not be changed
+var errBatchFailed = errors.New("none of the squares were saved because some of them could not be changed")
+
+func (s *Server) postPoolTokenSquareBatchEndpoint() http.HandlerFunc {
+	type squareChange struct {
+		SquareID int                   `json:"squareId"`
+		Claimant string                `json:"claimant"`
+		State    model.PoolSquareState `json:"state"`
+		Note     string                `json:"note"`
+
+		// SecondarySquareID is the square that is claimed along with the square in a roll100 grid
+		SecondarySquareID int `json:"secondarySquareId"`
+	}
+
+	type payload struct {
+		Squares []squareChange `json:"squares"`
+
+		// Partial will save the squares that could be changed even if others could not. By default, nothing is saved
+		// unless every square can be changed.
+		Partial bool `json:"partial"`
+	}
+
+	type result struct {
+		SquareID        int                   `json:"squareId"`
+		Square          *model.PoolSquareJSON `json:"square,omitempty"`
+		SecondarySquare *model.PoolSquareJSON `json:"secondarySquare,omitempty"`
+		Error           string                `json:"error,omitempty"`
+	}
+
+	type response struct {
+		Status  string   `json:"status,omitempty"`
+		Error   string   `json:"error,omitempty"`
+		Results []result `json:"results"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		grid, _ := squareRequest(r)
+
+		isAdmin, err := user.IsAdminOf(r.Context(), pool)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if pool.IsLocked() && !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, errors.New("the grid is locked"))
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		v := validator.New()
+		v.IntInRange("squares", len(data.Squares), 1, pool.NumberOfSquares()+1)
+
+		seen := make(map[int]bool)
+		for i, change := range data.Squares {
+			key := fmt.Sprintf("squares[%d]", i)
+			if change.SquareID < 1 || change.SquareID > pool.NumberOfSquares() {
+				v.AddError(key, "%d is not a valid square", change.SquareID)
+			} else if seen[change.SquareID] {
+				v.AddError(key, "square %d is included more than once", change.SquareID)
+			}
+			seen[change.SquareID] = true
+
+			if change.SecondarySquareID != 0 {
+				if pool.GridType() != model.GridTypeRoll100 {
+					v.AddError(key+".secondarySquareId", "secondary squares are not used with this grid type")
+				} else if change.SecondarySquareID < 1 || change.SecondarySquareID > pool.NumberOfSquares() {
+					v.AddError(key+".secondarySquareId", "%d is not a valid square", change.SecondarySquareID)
+				} else if seen[change.SecondarySquareID] {
+					v.AddError(key+".secondarySquareId", "square %d is included more than once", change.SecondarySquareID)
+				} else if len(change.Claimant) == 0 {
+					v.AddError(key+".secondarySquareId", "a secondary square can only be claimed along with its square")
+				}
+				seen[change.SecondarySquareID] = true
+			}
+
+			if len(change.Claimant) > 0 {
+				if pool.RandomAssignment() && !isAdmin {
+					s.writeErrorResponse(w, http.StatusBadRequest, model.ErrRandomAssignment)
+					return
+				}
+
+				claimant := v.Printable(key+".claimant", change.Claimant)
+				data.Squares[i].Claimant = v.ContainsWordChar(key+".claimant", claimant)
+				continue
+			}
+
+			if !change.State.IsValid() {
+				v.AddError(key+".state", "a claimant or a valid state is required")
+			} else if !isAdmin {
+				s.writeErrorResponse(w, http.StatusForbidden, errors.New("only an admin can change the state of a square"))
+				return
+			}
+		}
+
+		if !v.OK() {
+			s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+				Status:           statusError,
+				Error:            validationErrorMessage,
+				ValidationErrors: v.Errors,
+			})
+			return
+		}
+
+		squares, err := pool.GridSquares(r.Context(), grid)
+		if err != nil {
+			if err == model.ErrSquaresPerGrid {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		tx, err := s.model.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		results := make([]result, len(data.Squares))
+		events := make([]broker.EventType, len(data.Squares))
+		secondSquares := make([]*model.PoolSquare, len(data.Squares))
+		failed := false
+		for i, change := range data.Squares {
+			square := squares[change.SquareID]
+			results[i].SquareID = change.SquareID
+
+			// each square is saved within its own savepoint so that a square that cannot be changed does not abort
+			// the whole transaction
+			if _, err := tx.ExecContext(r.Context(), "SAVEPOINT batch_square"); err != nil {
+				_ = tx.Rollback()
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			oldState := square.State
+			if len(change.Claimant) > 0 {
+				note := "user: initial claim"
+				var secondSquare *model.PoolSquare
+				if change.SecondarySquareID > 0 {
+					secondSquare = squares[change.SecondarySquareID]
+				}
+
+				// the member is turning their hold into a claim, along with the secondary square that was held with it
+				if square.State == model.PoolSquareStateHeld && square.UserID() == user.ID {
+					note = "user: claimed held square"
+
+					childSquares, err := square.ChildSquares(r.Context(), tx)
+					if err != nil {
+						_ = tx.Rollback()
+						s.writeErrorResponse(w, http.StatusInternalServerError, err)
+						return
+					}
+
+					secondSquare = nil
+					if len(childSquares) > 0 {
+						secondSquare = childSquares[0]
+					}
+				}
+
+				square.SetClaimant(change.Claimant)
+				square.State = model.PoolSquareStateClaimed
+				square.SetUserID(user.ID)
+				err = square.Save(r.Context(), tx, false, model.PoolSquareLog{
+					RemoteAddr: r.RemoteAddr,
+					Note:       note,
+				})
+
+				if err == nil && secondSquare != nil {
+					secondSquare.SetClaimant(change.Claimant)
+					secondSquare.State = model.PoolSquareStateClaimed
+					secondSquare.SetUserID(user.ID)
+
+					// the parent is set first so that the secondary square does not count towards the user's limit
+					if err = secondSquare.SetParentSquare(r.Context(), tx, square); err == nil {
+						err = secondSquare.Save(r.Context(), tx, false, model.PoolSquareLog{
+							RemoteAddr: r.RemoteAddr,
+							Note:       note + " (secondary)",
+						})
+					}
+				}
+
+				events[i] = broker.EventSquareClaimed
+				secondSquares[i] = secondSquare
+			} else {
+				square.State = change.State
+				err = square.Save(r.Context(), tx, true, model.PoolSquareLog{
+					RemoteAddr: r.RemoteAddr,
+					Note:       change.Note,
+				})
+
+				if square.State == model.PoolSquareStateUnclaimed {
+					events[i] = broker.EventSquareUnclaimed
+				} else if square.State != oldState {
+					events[i] = broker.EventSquareStateChanged
+				}
+			}
+
+			if err != nil {
+				if err != model.ErrSquareAlreadyClaimed && err != model.ErrSquareLimit {
+					_ = tx.Rollback()
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+					return
+				}
+
+				if _, err := tx.ExecContext(r.Context(), "ROLLBACK TO SAVEPOINT batch_square"); err != nil {
+					_ = tx.Rollback()
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+					return
+				}
+
+				results[i].Error = err.Error()
+				events[i] = ""
+				secondSquares[i] = nil
+				failed = true
+				continue
+			}
+
+			if _, err := tx.ExecContext(r.Context(), "RELEASE SAVEPOINT batch_square"); err != nil {
+				_ = tx.Rollback()
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			results[i].Square = square.JSON()
+			if secondSquares[i] != nil {
+				results[i].SecondarySquare = secondSquares[i].JSON()
+			}
+
+			for _, squareJSON := range []*model.PoolSquareJSON{results[i].Square, results[i].SecondarySquare} {
+				if squareJSON == nil {
+					continue
+				}
+
+				if err := s.enqueueWebhooks(r.Context(), tx, pool, events[i], squareJSON); err != nil {
+					_ = tx.Rollback()
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+					return
+				}
+			}
+		}
+
+		if failed && !data.Partial {
+			_ = tx.Rollback()
+
+			// nothing was saved
+			for i := range results {
+				results[i].Square = nil
+				results[i].SecondarySquare = nil
+			}
+
+			s.writeJSONResponse(w, http.StatusBadRequest, response{
+				Status:  statusError,
+				Error:   errBatchFailed.Error(),
+				Results: results,
+			})
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"pool":    pool.ID(),
+			"squares": len(data.Squares),
+			"failed":  failed,
+		}).Info("saved batch of squares")
+
+		s.webhooks.Wake()
+		for i, event := range events {
+			if event != "" {
+				s.publish(pool, event, results[i].Square)
+			}
+
+			if results[i].SecondarySquare != nil {
+				s.publish(pool, event, results[i].SecondarySquare)
+			}
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, response{
+			Results: results,
+		})
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+type batchTestResponse struct {
+	Error   string `json:"error"`
+	Results []struct {
+		SquareID        int                   `json:"squareId"`
+		Square          *model.PoolSquareJSON `json:"square"`
+		SecondarySquare *model.PoolSquareJSON `json:"secondarySquare"`
+		Error           string                `json:"error"`
+	} `json:"results"`
+}
+
+func TestSquareBatch(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := model.New(getDB())
+	s := newTestServer(m)
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, model.IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	other, err := m.GetUser(ctx, model.IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "Test Pool", model.GridTypeStd25, "my-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	squares, err := pool.Squares()
+	g.Expect(err).Should(gomega.Succeed())
+
+	squares[3].SetClaimant("Other User")
+	squares[3].State = model.PoolSquareStateClaimed
+	squares[3].SetUserID(other.ID)
+	g.Expect(squares[3].Save(ctx, m.DB, false, model.PoolSquareLog{})).Should(gomega.Succeed())
+
+	batch := func(body string) (int, batchTestResponse) {
+		w := serveTestRequest(s.postPoolTokenSquareBatchEndpoint(), newTestRequest(http.MethodPost, pool, owner, []byte(body), nil))
+
+		var resp batchTestResponse
+		g.Expect(json.Unmarshal(w.Body.Bytes(), &resp)).Should(gomega.Succeed())
+		return w.Code, resp
+	}
+
+	state := func(squareID int) model.PoolSquareState {
+		square, err := pool.SquareBySquareID(squareID)
+		g.Expect(err).Should(gomega.Succeed())
+		return square.State
+	}
+
+	// by default, nothing is saved when one of the squares cannot be claimed
+	code, resp := batch(`{"squares":[{"squareId":1,"claimant":"Owner"},{"squareId":3,"claimant":"Owner"}]}`)
+	g.Expect(code).Should(gomega.Equal(http.StatusBadRequest))
+	g.Expect(resp.Error).Should(gomega.Equal(errBatchFailed.Error()))
+	g.Expect(resp.Results).Should(gomega.HaveLen(2))
+	g.Expect(resp.Results[0].Square).Should(gomega.BeNil())
+	g.Expect(resp.Results[1].Error).Should(gomega.Equal(model.ErrSquareAlreadyClaimed.Error()))
+	g.Expect(state(1)).Should(gomega.Equal(model.PoolSquareStateUnclaimed))
+
+	// a partial batch saves the squares that could be claimed
+	code, resp = batch(`{"partial":true,"squares":[{"squareId":1,"claimant":"Owner"},{"squareId":3,"claimant":"Owner"}]}`)
+	g.Expect(code).Should(gomega.Equal(http.StatusOK))
+	g.Expect(resp.Results[0].Error).Should(gomega.BeEmpty())
+	g.Expect(resp.Results[0].Square.State).Should(gomega.Equal(model.PoolSquareStateClaimed))
+	g.Expect(resp.Results[1].Error).Should(gomega.Equal(model.ErrSquareAlreadyClaimed.Error()))
+	g.Expect(resp.Results[1].Square).Should(gomega.BeNil())
+	g.Expect(state(1)).Should(gomega.Equal(model.PoolSquareStateClaimed))
+
+	square, err := pool.SquareBySquareID(3)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.Claimant()).Should(gomega.Equal("Other User"))
+
+	// secondary squares are only used by roll100 grids
+	code, _ = batch(`{"squares":[{"squareId":2,"claimant":"Owner","secondarySquareId":4}]}`)
+	g.Expect(code).Should(gomega.Equal(http.StatusBadRequest))
+	g.Expect(state(2)).Should(gomega.Equal(model.PoolSquareStateUnclaimed))
+}
+
+func TestSquareBatchRoll100(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := model.New(getDB())
+	s := newTestServer(m)
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, model.IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	other, err := m.GetUser(ctx, model.IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "Test Pool", model.GridTypeRoll100, "my-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	squares, err := pool.Squares()
+	g.Expect(err).Should(gomega.Succeed())
+
+	squares[20].SetClaimant("Other User")
+	squares[20].State = model.PoolSquareStateClaimed
+	squares[20].SetUserID(other.ID)
+	g.Expect(squares[20].Save(ctx, m.DB, false, model.PoolSquareLog{})).Should(gomega.Succeed())
+
+	batch := func(body string) (int, batchTestResponse) {
+		w := serveTestRequest(s.postPoolTokenSquareBatchEndpoint(), newTestRequest(http.MethodPost, pool, owner, []byte(body), nil))
+
+		var resp batchTestResponse
+		g.Expect(json.Unmarshal(w.Body.Bytes(), &resp)).Should(gomega.Succeed())
+		return w.Code, resp
+	}
+
+	square := func(squareID int) *model.PoolSquare {
+		square, err := pool.SquareBySquareID(squareID)
+		g.Expect(err).Should(gomega.Succeed())
+		return square
+	}
+
+	// the secondary square is claimed along with its square and linked to it
+	code, resp := batch(`{"squares":[{"squareId":1,"claimant":"Owner","secondarySquareId":11}]}`)
+	g.Expect(code).Should(gomega.Equal(http.StatusOK))
+	g.Expect(resp.Results[0].Square.State).Should(gomega.Equal(model.PoolSquareStateClaimed))
+	g.Expect(resp.Results[0].SecondarySquare).ShouldNot(gomega.BeNil())
+	g.Expect(resp.Results[0].SecondarySquare.SquareID).Should(gomega.Equal(11))
+	g.Expect(square(11).State).Should(gomega.Equal(model.PoolSquareStateClaimed))
+	g.Expect(square(11).ParentSquareID).Should(gomega.Equal(1))
+
+	// a secondary square that cannot be claimed rolls back its square, but not the rest of a partial batch
+	code, resp = batch(`{"partial":true,"squares":[{"squareId":2,"claimant":"Owner","secondarySquareId":20},{"squareId":3,"claimant":"Owner","secondarySquareId":13}]}`)
+	g.Expect(code).Should(gomega.Equal(http.StatusOK))
+	g.Expect(resp.Results[0].Error).Should(gomega.Equal(model.ErrSquareAlreadyClaimed.Error()))
+	g.Expect(resp.Results[0].Square).Should(gomega.BeNil())
+	g.Expect(resp.Results[0].SecondarySquare).Should(gomega.BeNil())
+	g.Expect(square(2).State).Should(gomega.Equal(model.PoolSquareStateUnclaimed))
+	g.Expect(square(20).Claimant()).Should(gomega.Equal("Other User"))
+	g.Expect(square(20).ParentSquareID).Should(gomega.Equal(0))
+	g.Expect(square(3).State).Should(gomega.Equal(model.PoolSquareStateClaimed))
+	g.Expect(square(13).ParentSquareID).Should(gomega.Equal(3))
+
+	// a hold is turned into a claim along with the secondary square that was held with it
+	held, secondary := square(4), square(14)
+	tx, err := m.DB.BeginTx(ctx, nil)
+	g.Expect(err).Should(gomega.Succeed())
+	for _, sq := range []*model.PoolSquare{held, secondary} {
+		if sq == secondary {
+			g.Expect(sq.SetParentSquare(ctx, tx, held)).Should(gomega.Succeed())
+		}
+
+		sq.SetClaimant("Owner")
+		sq.State = model.PoolSquareStateHeld
+		sq.SetUserID(owner.ID)
+		g.Expect(sq.Save(ctx, tx, false, model.PoolSquareLog{})).Should(gomega.Succeed())
+	}
+	g.Expect(tx.Commit()).Should(gomega.Succeed())
+
+	code, resp = batch(`{"squares":[{"squareId":4,"claimant":"Owner"}]}`)
+	g.Expect(code).Should(gomega.Equal(http.StatusOK))
+	g.Expect(resp.Results[0].Square.State).Should(gomega.Equal(model.PoolSquareStateClaimed))
+	g.Expect(resp.Results[0].SecondarySquare.SquareID).Should(gomega.Equal(14))
+	g.Expect(square(4).State).Should(gomega.Equal(model.PoolSquareStateClaimed))
+	g.Expect(square(4).HoldExpires()).Should(gomega.BeNil())
+	g.Expect(square(14).State).Should(gomega.Equal(model.PoolSquareStateClaimed))
+	g.Expect(square(14).ParentSquareID).Should(gomega.Equal(4))
+
+	claimed := square(4)
+	g.Expect(claimed.LoadLogs(ctx)).Should(gomega.Succeed())
+	g.Expect(claimed.Logs[0].Note).Should(gomega.Equal("user: claimed held square"))
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/sqmgr/sqmgr-api/internal/validator"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+func (s *Server) getPoolTokenSquareRequestEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		grid, _ := squareRequest(r)
+
+		isAdmin, err := user.IsAdminOf(r.Context(), pool)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// members can only see their own request
+		var requests []*model.PoolSquareRequest
+		if isAdmin {
+			requests, err = pool.SquareRequests(r.Context(), grid)
+		} else {
+			var request *model.PoolSquareRequest
+			request, err = pool.SquareRequestByUserID(r.Context(), grid, user.ID)
+			if err == nil {
+				requests = []*model.PoolSquareRequest{request}
+			} else if err == sql.ErrNoRows {
+				requests = []*model.PoolSquareRequest{}
+				err = nil
+			}
+		}
+
+		if err != nil {
+			if err == model.ErrSquaresPerGrid {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		requestsJSON := make([]*model.PoolSquareRequestJSON, len(requests))
+		for i, request := range requests {
+			requestsJSON[i] = request.JSON()
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, requestsJSON)
+	}
+}
+
+func (s *Server) postPoolTokenSquareRequestEndpoint() http.HandlerFunc {
+	type payload struct {
+		Claimant string `json:"claimant"`
+		Squares  int    `json:"squares"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		grid, _ := squareRequest(r)
+
+		if pool.IsLocked() {
+			s.writeErrorResponse(w, http.StatusForbidden, errors.New("the grid is locked"))
+			return
+		}
+
+		if !pool.RandomAssignment() {
+			s.writeErrorResponse(w, http.StatusBadRequest, model.ErrNotRandomAssignment)
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		// in a roll100 pool, every square comes with a secondary square
+		maxSquares := pool.NumberOfSquares()
+		if pool.GridType() == model.GridTypeRoll100 {
+			maxSquares /= 2
+		}
+
+		if limit := pool.MaxSquaresPerUser(); limit > 0 && limit < maxSquares {
+			maxSquares = limit
+		}
+
+		v := validator.New()
+		claimant := v.Printable("name", data.Claimant)
+		claimant = v.ContainsWordChar("name", claimant)
+		squares := v.IntInRange("squares", data.Squares, 1, maxSquares+1)
+		if !v.OK() {
+			s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+				Status:           statusError,
+				Error:            validationErrorMessage,
+				ValidationErrors: v.Errors,
+			})
+			return
+		}
+
+		request, err := pool.RequestSquares(r.Context(), grid, user.ID, claimant, squares)
+		if err != nil {
+			if err == model.ErrSquaresPerGrid {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, request.JSON())
+	}
+}
+
+func (s *Server) deletePoolTokenSquareRequestEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		grid, _ := squareRequest(r)
+
+		if pool.IsLocked() {
+			s.writeErrorResponse(w, http.StatusForbidden, errors.New("the grid is locked"))
+			return
+		}
+
+		if err := pool.CancelSquareRequest(r.Context(), grid, user.ID); err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+			case model.ErrSquaresPerGrid:
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+			default:
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
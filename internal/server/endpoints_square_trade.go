@@ -0,0 +1,262 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sqmgr/sqmgr-api/internal/broker"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+func (s *Server) getPoolTokenTradeEndpoint() http.HandlerFunc {
+	const defaultPerPage = 100
+	const maxPerPage = 100
+
+	type response struct {
+		Trades     []*model.PoolSquareTradeJSON `json:"trades"`
+		Total      *int64                       `json:"total,omitempty"`
+		NextCursor string                       `json:"nextCursor,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		isAdmin, err := user.IsAdminOf(r.Context(), pool)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// admins see every trade, members only see their own
+		userID := user.ID
+		if isAdmin {
+			userID = 0
+		}
+
+		page, err := parsePage(r, defaultPerPage, maxPerPage)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		var trades []*model.PoolSquareTrade
+		var next model.Cursor
+		if page.useOffset() {
+			trades, err = pool.Trades(r.Context(), userID, page.offset, page.limit)
+		} else {
+			trades, next, err = pool.TradesAfter(r.Context(), userID, page.cursor, page.limit)
+		}
+
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var total *int64
+		if page.countsTotal() {
+			count, err := pool.TradesCount(r.Context(), userID)
+			if err != nil {
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			total = &count
+		}
+
+		tradesJSON := make([]*model.PoolSquareTradeJSON, len(trades))
+		for i, trade := range trades {
+			tradesJSON[i] = trade.JSON()
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, response{
+			Trades:     tradesJSON,
+			Total:      total,
+			NextCursor: next.String(),
+		})
+	}
+}
+
+func (s *Server) postPoolTokenSquareTradeEndpoint() http.HandlerFunc {
+	type payload struct {
+		SquareID    int `json:"squareId"`
+		ForSquareID int `json:"forSquareId"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		grid, _ := squareRequest(r)
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		square, ok := s.tradeSquare(w, r, pool, grid, data.SquareID)
+		if !ok {
+			return
+		}
+
+		forSquare, ok := s.tradeSquare(w, r, pool, grid, data.ForSquareID)
+		if !ok {
+			return
+		}
+
+		trade, err := pool.ProposeTrade(r.Context(), user, square, forSquare)
+		if err != nil {
+			switch err {
+			case model.ErrTradeNotOwner, model.ErrTradeSameOwner, model.ErrTradeNotClaimed, model.ErrTradeSecondarySquare, model.ErrTradeExists:
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+			default:
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		s.publish(pool, broker.EventTradeUpdated, trade.JSON())
+		s.writeJSONResponse(w, http.StatusCreated, trade.JSON())
+	}
+}
+
+func (s *Server) postPoolTokenTradeIDEndpoint() http.HandlerFunc {
+	type payload struct {
+		Action string `json:"action"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		tradeID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		trade, err := pool.TradeByID(r.Context(), tradeID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		isAdmin, err := user.IsAdminOf(r.Context(), pool)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var squares []*model.PoolSquare
+		switch data.Action {
+		case "accept":
+			if user.ID != trade.ToUserID() {
+				s.writeErrorResponse(w, http.StatusForbidden, nil)
+				return
+			}
+
+			// once the pool is locked, a trade only goes through if an admin approves it
+			err = s.inTx(r.Context(), func(tx *sql.Tx) error {
+				var err error
+				if squares, err = trade.AcceptTx(r.Context(), tx, r.RemoteAddr, pool.IsLocked() && !isAdmin); err != nil {
+					return err
+				}
+
+				return s.enqueueSquareWebhooks(r.Context(), tx, pool, broker.EventSquareTraded, squares)
+			})
+		case "approve":
+			if !isAdmin {
+				s.writeErrorResponse(w, http.StatusForbidden, nil)
+				return
+			}
+
+			err = s.inTx(r.Context(), func(tx *sql.Tx) error {
+				var err error
+				if squares, err = trade.ApproveTx(r.Context(), tx, user, r.RemoteAddr); err != nil {
+					return err
+				}
+
+				return s.enqueueSquareWebhooks(r.Context(), tx, pool, broker.EventSquareTraded, squares)
+			})
+		case "decline":
+			if user.ID != trade.ToUserID() && !isAdmin {
+				s.writeErrorResponse(w, http.StatusForbidden, nil)
+				return
+			}
+
+			err = trade.Decline(r.Context())
+		case "cancel":
+			if user.ID != trade.FromUserID() {
+				s.writeErrorResponse(w, http.StatusForbidden, nil)
+				return
+			}
+
+			err = trade.Cancel(r.Context())
+		default:
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("unsupported action %s", data.Action))
+			return
+		}
+
+		if err != nil {
+			switch err {
+			case model.ErrTradeNotOpen, model.ErrTradeSquaresChanged:
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+			default:
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		if len(squares) > 0 {
+			s.webhooks.Wake()
+		}
+
+		for _, square := range squares {
+			s.publish(pool, broker.EventSquareTraded, square.JSON())
+		}
+
+		s.publish(pool, broker.EventTradeUpdated, trade.JSON())
+		s.writeJSONResponse(w, http.StatusOK, trade.JSON())
+	}
+}
+
+// tradeSquare will return the square of the grid. If false is returned, the response has already been written.
+func (s *Server) tradeSquare(w http.ResponseWriter, r *http.Request, pool *model.Pool, grid *model.Grid, squareID int) (*model.PoolSquare, bool) {
+	square, err := pool.GridSquareBySquareID(r.Context(), grid, squareID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			s.writeErrorResponse(w, http.StatusNotFound, nil)
+		case model.ErrSquaresPerGrid:
+			s.writeErrorResponse(w, http.StatusBadRequest, err)
+		default:
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+		}
+		return nil, false
+	}
+
+	return square, true
+}
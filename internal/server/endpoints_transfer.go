@@ -0,0 +1,169 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sqmgr/sqmgr-api/internal/broker"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+func (s *Server) getPoolTokenTransferEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		transfer, ok := s.poolTransferForUser(w, r, pool, user)
+		if !ok {
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, transfer.JSON())
+	}
+}
+
+func (s *Server) postPoolTokenTransferEndpoint() http.HandlerFunc {
+	type payload struct {
+		Action string `json:"action"`
+		UserID int64  `json:"userId"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		switch data.Action {
+		case "start":
+			if user.ID != pool.UserID() {
+				s.writeErrorResponse(w, http.StatusForbidden, nil)
+				return
+			}
+
+			to, err := s.model.GetUserByID(r.Context(), data.UserID)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					s.writeErrorResponse(w, http.StatusBadRequest, errors.New("user not found"))
+					return
+				}
+
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			transfer, err := pool.StartTransfer(r.Context(), user, r.RemoteAddr, to)
+			if err != nil {
+				switch err {
+				case model.ErrTransferToOwner, model.ErrTransferNotMember, model.ErrTransferNotPermitted:
+					s.writeErrorResponse(w, http.StatusBadRequest, err)
+				default:
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				}
+				return
+			}
+
+			s.writeJSONResponse(w, http.StatusCreated, transfer.JSON())
+		case "accept":
+			if err := pool.AcceptTransfer(r.Context(), user, r.RemoteAddr); err != nil {
+				switch err {
+				case sql.ErrNoRows:
+					s.writeErrorResponse(w, http.StatusNotFound, nil)
+				case model.ErrTransferNotPermitted, model.ErrTransferNotMember:
+					s.writeErrorResponse(w, http.StatusForbidden, err)
+				default:
+					s.writeErrorResponse(w, http.StatusInternalServerError, err)
+				}
+				return
+			}
+
+			s.publish(pool, broker.EventPoolUpdated, pool.JSON())
+			s.writeJSONResponse(w, http.StatusOK, poolResponse{
+				PoolJSON: pool.JSON(),
+				IsAdmin:  true,
+			})
+		default:
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("unsupported action %s", data.Action))
+		}
+	}
+}
+
+func (s *Server) deletePoolTokenTransferEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		transfer, ok := s.poolTransferForUser(w, r, pool, user)
+		if !ok {
+			return
+		}
+
+		// other admins may see the transfer, but only the two users involved may call it off
+		if user.ID != pool.UserID() && user.ID != transfer.ToUserID() {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		if err := pool.CancelTransfer(r.Context(), user, r.RemoteAddr); err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// poolTransferForUser will return the pending transfer of the pool if the user is allowed to see it. Admins can see it,
+// as can the user who was offered the pool. If false is returned, the response has already been written.
+func (s *Server) poolTransferForUser(w http.ResponseWriter, r *http.Request, pool *model.Pool, user *model.User) (*model.PoolTransfer, bool) {
+	transfer, err := pool.Transfer(r.Context())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.writeErrorResponse(w, http.StatusNotFound, nil)
+			return nil, false
+		}
+
+		s.writeErrorResponse(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	if transfer.ToUserID() == user.ID {
+		return transfer, true
+	}
+
+	if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, err)
+		return nil, false
+	} else if !isAdmin {
+		s.writeErrorResponse(w, http.StatusForbidden, nil)
+		return nil, false
+	}
+
+	return transfer, true
+}
@@ -72,66 +72,77 @@ func (s *Server) getUserIDPoolMembershipEndpoint() http.HandlerFunc {
 	const maxPerPage = 50
 
 	type resp struct {
-		Pools []*model.PoolJSON `json:"pools"`
-		Total int64             `json:"total"`
+		Pools      []*model.PoolJSON `json:"pools"`
+		Total      *int64            `json:"total,omitempty"`
+		NextCursor string            `json:"nextCursor,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID := r.Context().Value(ctxUserIDKey).(int64)
 		membership := mux.Vars(r)["membership"]
 
-		offset, _ := strconv.ParseInt(r.FormValue("offset"), 10, 64)
-		if offset < 0 {
-			offset = 0
-		}
-
-		limit, _ := strconv.Atoi(r.FormValue("limit"))
-		if limit <= 0 {
-			limit = defaultPerPage
-		}
-
-		if limit > maxPerPage {
-			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("limit cannot exceed %d", maxPerPage))
+		page, err := parsePage(r, defaultPerPage, maxPerPage)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err)
 			return
 		}
 
 		includeArchived := r.FormValue("includeArchived") == "true"
 
 		var getPools func(context.Context, int64, bool, int64, int) ([]*model.Pool, error)
+		var getPoolsAfter func(context.Context, int64, bool, model.Cursor, int) ([]*model.Pool, model.Cursor, error)
 		var getPoolsCount func(context.Context, int64, bool) (int64, error)
 		if membership == "own" {
 			getPools = s.model.PoolsOwnedByUserID
+			getPoolsAfter = s.model.PoolsOwnedByUserIDAfter
 			getPoolsCount = s.model.PoolsOwnedByUserIDCount
 		} else {
 			getPools = func(ctx context.Context, userID int64, includeArchived bool, offset int64, limit int) ([]*model.Pool, error) {
 				return s.model.PoolsJoinedByUserID(ctx, userID, offset, limit)
 			}
 
+			getPoolsAfter = func(ctx context.Context, userID int64, includeArchived bool, cursor model.Cursor, limit int) ([]*model.Pool, model.Cursor, error) {
+				return s.model.PoolsJoinedByUserIDAfter(ctx, userID, cursor, limit)
+			}
+
 			getPoolsCount = func(ctx context.Context, userID int64, includeArchived bool) (int64, error) {
 				return s.model.PoolsJoinedByUserIDCount(ctx, userID)
 			}
 		}
 
-		pools, err := getPools(r.Context(), userID, includeArchived, offset, limit)
-		if err != nil {
-			s.writeJSONResponse(w, http.StatusInternalServerError, err)
-			return
+		var pools []*model.Pool
+		var next model.Cursor
+		if page.useOffset() {
+			pools, err = getPools(r.Context(), userID, includeArchived, page.offset, page.limit)
+		} else {
+			pools, next, err = getPoolsAfter(r.Context(), userID, includeArchived, page.cursor, page.limit)
 		}
 
-		total, err := getPoolsCount(r.Context(), userID, includeArchived)
 		if err != nil {
 			s.writeJSONResponse(w, http.StatusInternalServerError, err)
 			return
 		}
 
+		var total *int64
+		if page.countsTotal() {
+			count, err := getPoolsCount(r.Context(), userID, includeArchived)
+			if err != nil {
+				s.writeJSONResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			total = &count
+		}
+
 		poolsJSON := make([]*model.PoolJSON, len(pools))
 		for i, p := range pools {
 			poolsJSON[i] = p.JSON()
 		}
 
 		respObj := resp{
-			Pools: poolsJSON,
-			Total: total,
+			Pools:      poolsJSON,
+			Total:      total,
+			NextCursor: next.String(),
 		}
 
 		s.writeJSONResponse(w, http.StatusOK, respObj)
@@ -219,6 +230,11 @@ func (s *Server) postUserIDGuestJWT() http.HandlerFunc {
 
 		for _, pool := range pools {
 			if err := user.JoinPool(r.Context(), pool); err != nil {
+				// bans are per account, so a pool that this account is banned from is not joined through the guest
+				if err == model.ErrUserBanned {
+					continue
+				}
+
 				s.writeJSONResponse(w, http.StatusInternalServerError, err)
 				return
 			}
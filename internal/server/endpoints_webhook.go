@@ -0,0 +1,211 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sqmgr/sqmgr-api/internal/broker"
+	"github.com/sqmgr/sqmgr-api/internal/validator"
+	"github.com/sqmgr/sqmgr-api/internal/webhook"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+// webhookURLMaxLength is the maximum length of a webhook URL
+const webhookURLMaxLength = 2048
+
+func (s *Server) getPoolTokenWebhookEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		webhooks, err := pool.Webhooks(r.Context())
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		webhooksJSON := make([]*model.PoolWebhookJSON, len(webhooks))
+		for i, wh := range webhooks {
+			webhooksJSON[i] = wh.JSON()
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, webhooksJSON)
+	}
+}
+
+func (s *Server) postPoolTokenWebhookEndpoint() http.HandlerFunc {
+	type payload struct {
+		URL    string             `json:"url"`
+		Events []broker.EventType `json:"events"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		var data payload
+		if ok := s.parseJSONPayload(w, r, &data); !ok {
+			return
+		}
+
+		v := validator.New()
+		url := v.MaxLength("url", data.URL, webhookURLMaxLength)
+		url = v.URL("url", url)
+
+		if len(data.Events) == 0 {
+			v.AddError("events", "at least one event is required")
+		}
+
+		seen := make(map[broker.EventType]bool)
+		events := make([]string, 0, len(data.Events))
+		for _, event := range data.Events {
+			if !webhook.IsEvent(event) {
+				v.AddError("events", "%s is not a valid event", event)
+				continue
+			}
+
+			if !seen[event] {
+				seen[event] = true
+				events = append(events, string(event))
+			}
+		}
+
+		if !v.OK() {
+			s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+				Status:           statusError,
+				Error:            validationErrorMessage,
+				ValidationErrors: v.Errors,
+			})
+			return
+		}
+
+		wh, err := pool.NewWebhook(r.Context(), url, events)
+		if err != nil {
+			if err == model.ErrWebhookLimit {
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// this is the only time the secret is returned
+		whJSON := wh.JSON()
+		whJSON.Secret = wh.Secret()
+		s.writeJSONResponse(w, http.StatusCreated, whJSON)
+	}
+}
+
+func (s *Server) deletePoolTokenWebhookIDEndpoint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		wh, err := pool.WebhookByID(r.Context(), id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := wh.Delete(r.Context()); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) getPoolTokenWebhookIDDeliveryEndpoint() http.HandlerFunc {
+	const defaultLimit = 50
+	const maxLimit = 100
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool := r.Context().Value(ctxPoolKey).(*model.Pool)
+		user := r.Context().Value(ctxUserKey).(*model.User)
+
+		if isAdmin, err := user.IsAdminOf(r.Context(), pool); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		} else if !isAdmin {
+			s.writeErrorResponse(w, http.StatusForbidden, nil)
+			return
+		}
+
+		id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		wh, err := pool.WebhookByID(r.Context(), id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.writeErrorResponse(w, http.StatusNotFound, nil)
+				return
+			}
+
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		limit, _ := strconv.Atoi(r.FormValue("limit"))
+		if limit <= 0 {
+			limit = defaultLimit
+		} else if limit > maxLimit {
+			limit = maxLimit
+		}
+
+		deliveries, err := wh.Deliveries(r.Context(), limit)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, deliveries)
+	}
+}
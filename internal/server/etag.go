@@ -0,0 +1,125 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+// etag returns the entity tag of a response about a resource. The tag starts with the version of the resource, which
+// is what If-Match is checked against. Anything else that the response depends on, such as whether the user is an
+// admin, is added to the tag so that If-None-Match does not hide a change to the response.
+func etag(version int64, vary ...interface{}) string {
+	if len(vary) == 0 {
+		return fmt.Sprintf(`"%d"`, version)
+	}
+
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%v", vary)
+	return fmt.Sprintf(`"%d-%x"`, version, h.Sum32())
+}
+
+// notModified will set the ETag header of the response. If the client already has the response with that tag, a 304
+// is written and true is returned.
+func notModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+
+	for _, t := range headerTags(r, "If-None-Match") {
+		if t == "*" || strings.TrimPrefix(t, "W/") == tag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
+
+// preconditionFailed will write a 412 and return true if the request has an If-Match header and none of its tags are
+// of the current version of the resource. Requests without If-Match are let through.
+func (s *Server) preconditionFailed(w http.ResponseWriter, r *http.Request, version int64) bool {
+	tags := headerTags(r, "If-Match")
+	if len(tags) == 0 {
+		return false
+	}
+
+	for _, t := range tags {
+		if t == "*" || tagVersion(t) == version {
+			return false
+		}
+	}
+
+	s.writeErrorResponse(w, http.StatusPreconditionFailed, model.ErrVersionMismatch)
+	return true
+}
+
+// ifMatchVersion returns the version that a request which passed preconditionFailed expects the resource to still be
+// at when it is saved, so that a change made in the meantime is not overwritten. It returns 0, for any version, if the
+// request does not have an If-Match header or matches any version.
+func ifMatchVersion(r *http.Request, version int64) int64 {
+	tags := headerTags(r, "If-Match")
+	if len(tags) == 0 {
+		return 0
+	}
+
+	for _, t := range tags {
+		if t == "*" {
+			return 0
+		}
+	}
+
+	return version
+}
+
+// headerTags returns the entity tags in a header, which may be a list or be sent more than once
+func headerTags(r *http.Request, key string) []string {
+	var tags []string
+	for _, value := range r.Header[key] {
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tags = append(tags, t)
+			}
+		}
+	}
+
+	return tags
+}
+
+// tagVersion returns the version of the resource that an entity tag returned by etag was for. Weak tags never match
+// in If-Match, so -1 is returned for them and for tags that were not made by etag.
+func tagVersion(tag string) int64 {
+	if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || len(tag) < 2 {
+		return -1
+	}
+
+	tag = tag[1 : len(tag)-1]
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		tag = tag[:i]
+	}
+
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	return version
+}
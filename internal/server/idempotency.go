@@ -0,0 +1,149 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+// idempotencyKeyHeader is the header that clients send with a request that they may retry
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotentRequestSize is the largest request body that is read to tell whether a retry is the same request
+const maxIdempotentRequestSize = 1 << 20
+
+// idempotentHeaders are the headers of a response that are saved and replayed along with it. Headers that are set on
+// every response, such as those for CORS, are left to the request that is replaying it.
+var idempotentHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Location"}
+
+// idempotentResponse records the response of a request so that it can be replayed
+type idempotentResponse struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (i *idempotentResponse) WriteHeader(statusCode int) {
+	i.statusCode = statusCode
+	i.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (i *idempotentResponse) Write(b []byte) (int, error) {
+	if i.statusCode == 0 {
+		i.statusCode = http.StatusOK
+	}
+
+	i.body.Write(b)
+	return i.ResponseWriter.Write(b)
+}
+
+// idempotent will replay the original response when a user retries a request with the same Idempotency-Key header,
+// instead of handling it again. Requests without the header are handled as usual.
+func (s *Server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if utf8.RuneCountInString(key) > model.IdempotencyKeyMaxLength {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("%s cannot exceed %d characters", idempotencyKeyHeader, model.IdempotencyKeyMaxLength))
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestSize))
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, errors.New("could not read the request"))
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		// a key cannot be reused for a different request
+		hash := sha256.New()
+		_, _ = fmt.Fprintf(hash, "%s %s\n", r.Method, r.URL.Path)
+		_, _ = hash.Write(body)
+
+		user := r.Context().Value(ctxUserKey).(*model.User)
+		k, reserved, err := s.model.ReserveIdempotencyKey(r.Context(), user.ID, key, hash.Sum(nil))
+		if err != nil {
+			switch err {
+			case model.ErrIdempotencyKeyInProgress:
+				s.writeErrorResponse(w, http.StatusConflict, err)
+			case model.ErrIdempotencyKeyMismatch:
+				s.writeErrorResponse(w, http.StatusBadRequest, err)
+			default:
+				s.writeErrorResponse(w, http.StatusInternalServerError, err)
+			}
+
+			return
+		}
+
+		if !reserved {
+			statusCode, headers, body := k.Response()
+			for name, values := range headers {
+				w.Header()[name] = values
+			}
+
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(statusCode)
+			_, _ = w.Write(body)
+			return
+		}
+
+		resp := &idempotentResponse{ResponseWriter: w}
+		defer func() {
+			// the request was handled even if the client went away, so the response is saved regardless
+			ctx := context.Background()
+			lr := logrus.WithFields(logrus.Fields{"user": user.ID, "idempotencyKey": key})
+
+			// the request may be retried if it could not be handled
+			if resp.statusCode == 0 || resp.statusCode/100 == 5 {
+				if err := k.Release(ctx); err != nil {
+					lr.WithError(err).Error("could not release idempotency key")
+				}
+
+				return
+			}
+
+			headers := make(http.Header)
+			for _, name := range idempotentHeaders {
+				name = http.CanonicalHeaderKey(name)
+				if values := w.Header()[name]; len(values) > 0 {
+					headers[name] = values
+				}
+			}
+
+			if err := k.Save(ctx, resp.statusCode, headers, resp.body.Bytes()); err != nil {
+				lr.WithError(err).Error("could not save idempotent response")
+			}
+		}()
+
+		next.ServeHTTP(resp, r)
+	})
+}
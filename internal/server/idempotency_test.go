@@ -0,0 +1,67 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+func TestIdempotentReplaysHeaders(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := model.New(getDB())
+	s := newTestServer(m)
+	ctx := context.Background()
+
+	user, err := m.GetUser(ctx, model.IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	calls := 0
+	handler := s.idempotent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("ETag", `"1"`)
+		w.Header().Set("Location", "/pool/abc")
+		w.Header().Set("X-Not-Replayed", "true")
+		s.writeJSONResponse(w, http.StatusCreated, map[string]string{"token": "abc"})
+	}))
+
+	key := randString()
+	request := func() *http.Request {
+		r := newTestRequest(http.MethodPost, nil, user, []byte(`{"name":"test"}`), nil)
+		r.Header.Set(idempotencyKeyHeader, key)
+		return r
+	}
+
+	first := serveTestRequest(handler.ServeHTTP, request())
+	g.Expect(first.Code).Should(gomega.Equal(http.StatusCreated))
+
+	replay := serveTestRequest(handler.ServeHTTP, request())
+	g.Expect(calls).Should(gomega.Equal(1))
+	g.Expect(replay.Code).Should(gomega.Equal(http.StatusCreated))
+	g.Expect(replay.Header().Get("Idempotent-Replayed")).Should(gomega.Equal("true"))
+	g.Expect(replay.Header().Get("Content-Type")).Should(gomega.Equal(first.Header().Get("Content-Type")))
+	g.Expect(replay.Header().Get("ETag")).Should(gomega.Equal(`"1"`))
+	g.Expect(replay.Header().Get("Location")).Should(gomega.Equal("/pool/abc"))
+	g.Expect(replay.Header().Get("X-Not-Replayed")).Should(gomega.Equal(""))
+	g.Expect(replay.Body.String()).Should(gomega.Equal(first.Body.String()))
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/internal/broker"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+// setupJobs registers a handler for every action that can be scheduled against a pool
+func (s *Server) setupJobs() {
+	s.scheduler.Handle(model.PoolJobActionDrawNumbers, s.drawNumbersJob)
+	s.scheduler.Handle(model.PoolJobActionArchive, s.archiveJob)
+	s.scheduler.Handle(model.PoolJobActionLockReminder, s.lockReminderJob)
+	s.scheduler.Handle(model.PoolJobActionAssignSquares, s.assignSquaresJob)
+	s.scheduler.Sweep(s.releaseExpiredHolds)
+	s.scheduler.Sweep(s.deleteExpiredIdempotencyKeys)
+}
+
+// rescheduleJobs must be called after anything that the pool's jobs are relative to has changed
+func (s *Server) rescheduleJobs(ctx context.Context, pool *model.Pool) {
+	if err := pool.RescheduleJobs(ctx); err != nil {
+		logrus.WithError(err).WithField("pool", pool.ID()).Error("could not reschedule jobs")
+		return
+	}
+
+	s.scheduler.Wake()
+}
+
+// drawNumbersJob draws the numbers of every grid that has not been drawn yet. Each grid is drawn with the seed the pool
+// is committed to, and a fresh seed is committed every time one is revealed.
+func (s *Server) drawNumbersJob(ctx context.Context, pool *model.Pool, job *model.PoolJob) error {
+	// the pool was unlocked after the job was picked up
+	if !pool.IsLocked() {
+		return nil
+	}
+
+	grids, err := pool.Grids(ctx, 0, model.MaxGridsPerPool)
+	if err != nil {
+		return err
+	}
+
+	for _, grid := range grids {
+		commitment, err := pool.DrawCommitment(ctx)
+		if err == sql.ErrNoRows {
+			commitment, err = pool.CommitDrawSeed(ctx)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := grid.SelectCommittedNumbers(commitment, ""); err != nil {
+			if err == model.ErrNumbersAlreadyDrawn {
+				continue
+			}
+
+			if err == model.ErrDrawSeedRevealed {
+				// the job is retried once the fresh seed has been published
+				if _, err := pool.CommitDrawSeed(ctx); err != nil {
+					return err
+				}
+			}
+
+			return err
+		}
+
+		err = pool.Audited(ctx, nil, "", model.AuditEventNumbersDrawn, nil, func(tx *sql.Tx) (interface{}, error) {
+			if err := grid.SaveTx(ctx, tx); err != nil {
+				return nil, err
+			}
+
+			if err := s.enqueueWebhooks(ctx, tx, pool, broker.EventNumbersDrawn, grid.JSON()); err != nil {
+				return nil, err
+			}
+
+			return auditState{
+				"gridId":      grid.ID(),
+				"manual":      false,
+				"homeNumbers": grid.HomeNumbers(),
+				"awayNumbers": grid.AwayNumbers(),
+			}, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		s.webhooks.Wake()
+		s.publish(pool, broker.EventNumbersDrawn, grid.JSON())
+	}
+
+	return nil
+}
+
+// archiveJob archives the pool
+func (s *Server) archiveJob(ctx context.Context, pool *model.Pool, job *model.PoolJob) error {
+	if pool.Archived() {
+		return nil
+	}
+
+	// jobs are run by the scheduler, so the event has no actor
+	pool.SetArchived(true)
+	err := pool.Audited(ctx, nil, "", model.AuditEventPoolArchived, auditState{"archived": false}, func(tx *sql.Tx) (interface{}, error) {
+		if err := pool.SaveTx(ctx, tx); err != nil {
+			return nil, err
+		}
+
+		return auditState{"archived": true}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(pool, broker.EventPoolUpdated, pool.JSON())
+	return nil
+}
+
+// lockReminderJob lets the members, and any webhooks, know that the pool is about to lock
+func (s *Server) lockReminderJob(ctx context.Context, pool *model.Pool, job *model.PoolJob) error {
+	if pool.IsLocked() {
+		return nil
+	}
+
+	// nothing changes with a reminder, so the deliveries are queued on their own. The job is retried if they cannot be.
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.enqueueWebhooks(ctx, tx, pool, broker.EventPoolLockReminder, pool.JSON())
+	})
+	if err != nil {
+		return err
+	}
+
+	s.webhooks.Wake()
+	s.publish(pool, broker.EventPoolLockReminder, pool.JSON())
+	return nil
+}
+
+// assignSquaresJob assigns the requested squares at random once the pool has locked
+func (s *Server) assignSquaresJob(ctx context.Context, pool *model.Pool, job *model.PoolJob) error {
+	if !pool.IsLocked() || !pool.RandomAssignment() {
+		return nil
+	}
+
+	return s.assignSquares(ctx, pool, "")
+}
+
+// assignSquares assigns the requested squares of the pool at random and lets everyone know which squares were claimed
+func (s *Server) assignSquares(ctx context.Context, pool *model.Pool, remoteAddr string) error {
+	var squares []*model.PoolSquare
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		if squares, err = pool.AssignSquaresTx(ctx, tx, remoteAddr); err != nil {
+			return err
+		}
+
+		return s.enqueueSquareWebhooks(ctx, tx, pool, broker.EventSquareClaimed, squares)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.webhooks.Wake()
+	for _, square := range squares {
+		s.publish(pool, broker.EventSquareClaimed, square.JSON())
+	}
+
+	return nil
+}
+
+// releaseExpiredHolds puts the squares whose holds have expired back up for grabs
+func (s *Server) releaseExpiredHolds(ctx context.Context) error {
+	poolIDs, err := s.model.PoolIDsWithExpiredHolds(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, poolID := range poolIDs {
+		pool, err := s.model.PoolByID(poolID)
+		if err != nil {
+			return err
+		}
+
+		var squares []*model.PoolSquare
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			var err error
+			if squares, err = pool.ReleaseExpiredHoldsTx(ctx, tx); err != nil {
+				return err
+			}
+
+			return s.enqueueSquareWebhooks(ctx, tx, pool, broker.EventSquareUnclaimed, squares)
+		})
+		if err != nil {
+			return err
+		}
+
+		s.webhooks.Wake()
+		for _, square := range squares {
+			s.publish(pool, broker.EventSquareUnclaimed, square.JSON())
+		}
+	}
+
+	return nil
+}
+
+// deleteExpiredIdempotencyKeys forgets the responses that are no longer replayed
+func (s *Server) deleteExpiredIdempotencyKeys(ctx context.Context) error {
+	deleted, err := s.model.DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		logrus.WithField("deleted", deleted).Info("scheduler: deleted expired idempotency keys")
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+// page is the part of a list that a request asked for. Clients page through a list by passing back the nextCursor of
+// the previous response. Clients that came before cursors pass an offset instead, which is still supported.
+type page struct {
+	offset int64
+	limit  int
+	cursor model.Cursor
+}
+
+// parsePage will parse the offset, limit and cursor query parameters of a list endpoint. A cursor takes precedence over
+// an offset.
+func parsePage(r *http.Request, defaultPerPage, maxPerPage int) (page, error) {
+	return parseNamedPage(r, "offset", "cursor", defaultPerPage, maxPerPage)
+}
+
+// parseNamedPage is parsePage for a list whose offset and cursor are passed in the named query parameters, so that a
+// response can page through more than one list. The limit is shared.
+func parseNamedPage(r *http.Request, offsetParam, cursorParam string, defaultPerPage, maxPerPage int) (page, error) {
+	var p page
+
+	p.offset, _ = strconv.ParseInt(r.FormValue(offsetParam), 10, 64)
+	if p.offset < 0 {
+		p.offset = 0
+	}
+
+	p.limit, _ = strconv.Atoi(r.FormValue("limit"))
+	if p.limit <= 0 {
+		p.limit = defaultPerPage
+	}
+
+	if p.limit > maxPerPage {
+		return p, fmt.Errorf("limit cannot exceed %d", maxPerPage)
+	}
+
+	if cursor := r.FormValue(cursorParam); cursor != "" {
+		c, err := model.ParseCursor(cursor)
+		if err != nil {
+			return p, err
+		}
+
+		p.cursor = c
+		p.offset = 0
+	}
+
+	return p, nil
+}
+
+// isFirst returns whether the page is the first page of the list
+func (p page) isFirst() bool {
+	return p.offset == 0 && p.cursor.IsZero()
+}
+
+// useOffset returns whether the page has to be fetched by its offset. The first page is fetched with a cursor, so
+// that the response has a nextCursor even for clients that do not pass one yet.
+func (p page) useOffset() bool {
+	return p.offset > 0
+}
+
+// countsTotal returns whether the total of the list should be counted. Counting is slow on long lists, so it is skipped
+// once a client pages with a cursor, as it was already given the total with the first page.
+func (p page) countsTotal() bool {
+	return p.cursor.IsZero()
+}
@@ -27,16 +27,18 @@ var optionsHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Reques
 
 func (s *Server) setupRoutes() {
 
-	// these routes do NOT require auth
+	// these routes do NOT require auth, except for the configuration of a pool
 	s.Router.Path("/").Methods(http.MethodGet).Handler(s.getHealthEndpoint())
+	s.Router.Path("/pool/configuration").Methods(http.MethodGet).Queries("pool", "{token:[A-Za-z0-9_-]+}").Handler(s.authHandler(s.poolHandler(s.getPoolConfiguration())))
 	s.Router.Path("/pool/configuration").Methods(http.MethodGet).Handler(s.getPoolConfiguration())
 	s.Router.Path("/user/guest").Methods(http.MethodPost).Handler(s.postUserGuestEndpoint())
 
 	// these routes REQUIRE AUTH
 	authRouter := s.NewRoute().Subrouter()
 	authRouter.Use(s.authHandler)
-	authRouter.Path("/pool").Methods(http.MethodPost).Handler(s.postPoolEndpoint())
-	authRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/member").Methods(http.MethodPost).Handler(s.postPoolTokenMemberEndpoint())
+	authRouter.Path("/pool").Methods(http.MethodPost).Handler(s.idempotent(s.postPoolEndpoint()))
+	authRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/member").Methods(http.MethodPost).Handler(s.idempotent(s.postPoolTokenMemberEndpoint()))
+	authRouter.Path("/participant/{token:[A-Za-z0-9]+}").Methods(http.MethodPost).Handler(s.postParticipantTokenEndpoint())
 	authRouter.Path("/user/self").Methods(http.MethodGet).Handler(s.getUserSelfEndpoint())
 
 	authPoolRouter := authRouter.NewRoute().Subrouter()
@@ -49,14 +51,67 @@ func (s *Server) setupRoutes() {
 	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}").Methods(http.MethodGet).Handler(s.getPoolTokenGridIDEndpoint())
 	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}").Methods(http.MethodPost).Handler(s.postPoolTokenGridIDEndpoint())
 
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/export.csv").Methods(http.MethodGet).Handler(s.getPoolTokenExportCSVEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/events").Methods(http.MethodGet).Handler(s.getPoolTokenEventsEndpoint())
 	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/invitetoken").Methods(http.MethodGet).Handler(s.getPoolTokenInviteTokenEndpoint())
 	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/log").Methods(http.MethodGet).Handler(s.getPoolTokenLogEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/webhook").Methods(http.MethodGet).Handler(s.getPoolTokenWebhookEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/webhook").Methods(http.MethodPost).Handler(s.postPoolTokenWebhookEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/webhook/{id:[0-9]+}").Methods(http.MethodDelete).Handler(s.deletePoolTokenWebhookIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/webhook/{id:[0-9]+}/delivery").Methods(http.MethodGet).Handler(s.getPoolTokenWebhookIDDeliveryEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/job").Methods(http.MethodGet).Handler(s.getPoolTokenJobEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/job").Methods(http.MethodPost).Handler(s.postPoolTokenJobEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/job/{id:[0-9]+}").Methods(http.MethodDelete).Handler(s.deletePoolTokenJobIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/member").Methods(http.MethodGet).Handler(s.getPoolTokenMemberEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/member/{user_id:[0-9]+}").Methods(http.MethodPost).Handler(s.postPoolTokenMemberUserIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/member/{user_id:[0-9]+}").Methods(http.MethodDelete).Handler(s.deletePoolTokenMemberUserIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/ban").Methods(http.MethodGet).Handler(s.getPoolTokenBanEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/ban/{user_id:[0-9]+}").Methods(http.MethodPost).Handler(s.postPoolTokenBanUserIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/ban/{user_id:[0-9]+}").Methods(http.MethodDelete).Handler(s.deletePoolTokenBanUserIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/transfer").Methods(http.MethodGet).Handler(s.getPoolTokenTransferEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/transfer").Methods(http.MethodPost).Handler(s.postPoolTokenTransferEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/transfer").Methods(http.MethodDelete).Handler(s.deletePoolTokenTransferEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/participant").Methods(http.MethodGet).Handler(s.getPoolTokenParticipantEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/participant").Methods(http.MethodPost).Handler(s.postPoolTokenParticipantEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/participant/{id:[0-9]+}").Methods(http.MethodGet).Handler(s.getPoolTokenParticipantIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/participant/{id:[0-9]+}").Methods(http.MethodPost).Handler(s.postPoolTokenParticipantIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/participant/{id:[0-9]+}").Methods(http.MethodDelete).Handler(s.deletePoolTokenParticipantIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/payment").Methods(http.MethodGet).Handler(s.getPoolTokenPaymentEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/payment").Methods(http.MethodPost).Handler(s.postPoolTokenPaymentEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/payment/{id:[0-9]+}").Methods(http.MethodPost).Handler(s.postPoolTokenPaymentIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/trade").Methods(http.MethodGet).Handler(s.getPoolTokenTradeEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/trade/{id:[0-9]+}").Methods(http.MethodPost).Handler(s.postPoolTokenTradeIDEndpoint())
 	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/square").Methods(http.MethodGet).Handler(s.getPoolTokenSquareEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/square/request").Methods(http.MethodGet).Handler(s.getPoolTokenSquareRequestEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/square/request").Methods(http.MethodPost).Handler(s.postPoolTokenSquareRequestEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/square/request").Methods(http.MethodDelete).Handler(s.deletePoolTokenSquareRequestEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/square/trade").Methods(http.MethodPost).Handler(s.postPoolTokenSquareTradeEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/square/batch").Methods(http.MethodPost).Handler(s.postPoolTokenSquareBatchEndpoint())
 	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/square/{id:[0-9]+}").Methods(http.MethodGet).Handler(s.getPoolTokenSquareIDEndpoint())
-	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/square/{id:[0-9]+}").Methods(http.MethodPost).Handler(s.postPoolTokenSquareIDEndpoint())
+	authPoolRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/square/{id:[0-9]+}").Methods(http.MethodPost).Handler(s.idempotent(s.postPoolTokenSquareIDEndpoint()))
 
 	authPoolGridRouter := authPoolRouter.NewRoute().Subrouter()
 	authPoolGridRouter.Use(s.poolGridHandler)
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/score").Methods(http.MethodPost).Handler(s.postPoolTokenGridIDScoreEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/score/{period:[a-z0-9]+}").Methods(http.MethodDelete).Handler(s.deletePoolTokenGridIDScorePeriodEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/export.csv").Methods(http.MethodGet).Handler(s.getPoolTokenGridIDExportCSVEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/payout").Methods(http.MethodGet).Handler(s.getPoolTokenGridIDPayoutEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/draw").Methods(http.MethodGet).Handler(s.getPoolTokenGridIDDrawEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/image.svg").Methods(http.MethodGet).Handler(s.getPoolTokenGridIDImageSVGEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/image.png").Methods(http.MethodGet).Handler(s.getPoolTokenGridIDImagePNGEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/sheet.pdf").Methods(http.MethodGet).Handler(s.getPoolTokenGridIDSheetPDFEndpoint())
+
+	// the squares and logs of a single grid, for pools where each grid has its own squares
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/log").Methods(http.MethodGet).Handler(s.getPoolTokenLogEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/square").Methods(http.MethodGet).Handler(s.getPoolTokenSquareEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/square/request").Methods(http.MethodGet).Handler(s.getPoolTokenSquareRequestEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/square/request").Methods(http.MethodPost).Handler(s.postPoolTokenSquareRequestEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/square/request").Methods(http.MethodDelete).Handler(s.deletePoolTokenSquareRequestEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/square/trade").Methods(http.MethodPost).Handler(s.postPoolTokenSquareTradeEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/square/batch").Methods(http.MethodPost).Handler(s.postPoolTokenSquareBatchEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/square/{square_id:[0-9]+}").Methods(http.MethodGet).Handler(s.getPoolTokenSquareIDEndpoint())
+	authPoolGridRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/square/{square_id:[0-9]+}").Methods(http.MethodPost).Handler(s.idempotent(s.postPoolTokenSquareIDEndpoint()))
+
 	authPoolGridSquareAdminRouter := authPoolGridRouter.NewRoute().Subrouter()
 	authPoolGridSquareAdminRouter.Use(s.poolGridSquareAdminHandler)
 	authPoolGridSquareAdminRouter.Path("/pool/{token:[A-Za-z0-9_-]+}/grid/{id:[0-9]+}/square/{square_id:[0-9]+}/annotation").Methods(http.MethodPost).Handler(s.postPoolTokenGridIDSquareSquareIDAnnotationEndpoint())
@@ -88,7 +143,8 @@ func (s *Server) setupRoutes() {
 
 	c := cors.New(cors.Options{
 		AllowedMethods: []string{http.MethodGet, http.MethodDelete, http.MethodPost, http.MethodPatch},
-		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "If-Match", "If-None-Match", "Idempotency-Key"},
+		ExposedHeaders: []string{"ETag", "Idempotent-Replayed"},
 	})
 	s.Router.Use(c.Handler)
 }
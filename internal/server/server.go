@@ -20,8 +20,11 @@ import (
 	"database/sql"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/internal/broker"
 	"github.com/sqmgr/sqmgr-api/internal/config"
 	"github.com/sqmgr/sqmgr-api/internal/keylocker"
+	"github.com/sqmgr/sqmgr-api/internal/scheduler"
+	"github.com/sqmgr/sqmgr-api/internal/webhook"
 	"github.com/sqmgr/sqmgr-api/pkg/model"
 	"github.com/sqmgr/sqmgr-api/pkg/smjwt"
 )
@@ -33,6 +36,9 @@ type Server struct {
 	version   string
 	keyLocker *keylocker.KeyLocker
 	smjwt     *smjwt.SMJWT
+	broker    *broker.Broker
+	webhooks  *webhook.Dispatcher
+	scheduler *scheduler.Scheduler
 }
 
 // New returns a new server object
@@ -45,20 +51,34 @@ func New(version string, db *sql.DB) *Server {
 		logrus.WithError(err).Fatal("could not load private key")
 	}
 
+	b := broker.New(db)
+	if err := b.Listen(config.DSN()); err != nil {
+		logrus.WithError(err).Fatal("could not listen for pool events")
+	}
+
+	m := model.New(db)
 	s := &Server{
 		Router:    mux.NewRouter(),
-		model:     model.New(db),
+		model:     m,
 		keyLocker: keylocker.New("https://sqmgr.auth0.com/.well-known/jwks.json"),
 		smjwt:     sj,
+		broker:    b,
+		webhooks:  webhook.New(m),
+		scheduler: scheduler.New(m),
 		version:   version,
 	}
 
 	s.setupRoutes()
+	s.setupJobs()
+	s.webhooks.Start()
+	s.scheduler.Start()
 
 	return s
 }
 
 // Shutdown will handle any cleanup
 func (s *Server) Shutdown() error {
-	return nil
+	s.scheduler.Stop()
+	s.webhooks.Stop()
+	return s.broker.Close()
 }
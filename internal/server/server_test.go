@@ -0,0 +1,104 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+	_ "github.com/lib/pq"
+	"github.com/sqmgr/sqmgr-api/internal/broker"
+	"github.com/sqmgr/sqmgr-api/internal/scheduler"
+	"github.com/sqmgr/sqmgr-api/internal/webhook"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+var db *sql.DB
+
+func getDB() *sql.DB {
+	if db != nil {
+		return db
+	}
+
+	dsn := "sslmode=disable user=postgres database=integration"
+	if env := os.Getenv("SQMGR_CONF_DSN"); env != "" {
+		dsn = env
+	}
+
+	var err error
+	db, err = sql.Open("postgres", dsn)
+	if err != nil {
+		panic(err)
+	}
+	if err := db.Ping(); err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
+func ensureIntegration(t *testing.T) {
+	if len(os.Getenv("INTEGRATION")) == 0 {
+		t.Skip("skipping. to run, use -integration flag")
+	}
+}
+
+func randString() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// newTestServer returns a server without routes, config or running background workers for calling endpoints directly
+func newTestServer(m *model.Model) *Server {
+	return &Server{
+		model:     m,
+		broker:    broker.New(nil),
+		webhooks:  webhook.New(m),
+		scheduler: scheduler.New(m),
+	}
+}
+
+// newTestRequest returns a request from the user after the pool has been loaded
+func newTestRequest(method string, pool *model.Pool, user *model.User, body []byte, vars map[string]string) *http.Request {
+	ctx := context.WithValue(context.Background(), ctxUserKey, user)
+	ctx = context.WithValue(ctx, ctxPoolKey, pool)
+
+	r := httptest.NewRequest(method, "/", bytes.NewReader(body)).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/json")
+
+	return mux.SetURLVars(r, vars)
+}
+
+// serveTestRequest will call the handler with the request and return what was written
+func serveTestRequest(handler http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	return w
+}
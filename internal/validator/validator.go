@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math"
 	"net/mail"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -120,6 +121,17 @@ func (v *Validator) Email(key, email string) string {
 	return email
 }
 
+// URL will ensure that the string is an absolute http or https URL
+func (v *Validator) URL(key, val string) string {
+	u, err := url.Parse(val)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.AddError(key, "must be a valid http or https URL")
+		return ""
+	}
+
+	return val
+}
+
 // Color will ensure the color is a valid hex color in the form of #000000
 func (v *Validator) Color(key, val string, isOptional ...bool) string {
 	if len(isOptional) > 0 && isOptional[0] && len(val) == 0 {
@@ -0,0 +1,341 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package webhook delivers pool events to the URLs registered by pool admins
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sqmgr/sqmgr-api/internal/broker"
+	"github.com/sqmgr/sqmgr-api/pkg/model"
+)
+
+// Headers that are sent with each delivery
+const (
+	HeaderEvent     = "X-SqMGR-Event"
+	HeaderDelivery  = "X-SqMGR-Delivery"
+	HeaderSignature = "X-SqMGR-Signature"
+)
+
+// MaxAttempts is the number of times a delivery is attempted before giving up
+const MaxAttempts = 8
+
+const (
+	pollInterval    = time.Second * 10
+	requestTimeout  = time.Second * 10
+	leaseDuration   = time.Minute
+	batchSize       = 20
+	baseBackoff     = time.Second * 30
+	maxBackoff      = time.Hour * 6
+	maxErrorLength  = 500
+	userAgent       = "SqMGR-Webhook/1.0"
+	maxResponseRead = 4096
+)
+
+// Events are the events that a webhook can subscribe to
+var Events = []broker.EventType{
+	broker.EventSquareClaimed,
+	broker.EventSquareUnclaimed,
+	broker.EventSquareStateChanged,
+	broker.EventSquareTraded,
+	broker.EventNumbersDrawn,
+	broker.EventPoolLocked,
+	broker.EventPoolLockReminder,
+	broker.EventGridCreated,
+	broker.EventGridDeleted,
+}
+
+// IsEvent returns true if a webhook can subscribe to the event
+func IsEvent(eventType broker.EventType) bool {
+	for _, e := range Events {
+		if e == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrPrivateAddress is returned when a webhook URL resolves to an address that is not publicly routable
+var ErrPrivateAddress = errors.New("webhook: refusing to connect to a private address")
+
+// Payload is the body that is sent to a webhook
+type Payload struct {
+	Event   broker.EventType `json:"event"`
+	Pool    string           `json:"pool"`
+	Created time.Time        `json:"created"`
+	Data    interface{}      `json:"data"`
+}
+
+// Sign returns the signature of a delivery. The signature is the hex encoded HMAC-SHA256 of the timestamp, a
+// period and the body, using the webhook's secret as the key.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%d.", timestamp)
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureHeader returns the value of the signature header, e.g. "t=1577836800,v1=5257a869..."
+func SignatureHeader(secret string, timestamp int64, body []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(secret, timestamp, body))
+}
+
+// Backoff returns how long to wait before the next attempt, after the given number of attempts have failed
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	backoff := baseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return backoff
+}
+
+// Send will POST a signed payload to the URL. An error is returned if the request could not be made or the
+// response was not a 2xx. The status code is returned if a response was received.
+func Send(ctx context.Context, client *http.Client, url, secret, event string, deliveryID int64, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(deliveryID, 10))
+	req.Header.Set(HeaderSignature, SignatureHeader(secret, time.Now().Unix(), body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// drain some of the body so the connection can be reused
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxResponseRead))
+
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("webhook: received status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Dispatcher will deliver the queued webhook deliveries. It is safe to run a dispatcher in every instance of the
+// API, as each delivery is leased to a single dispatcher at a time.
+type Dispatcher struct {
+	model  *model.Model
+	client *http.Client
+	wake   chan struct{}
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// New returns a new Dispatcher. Its HTTP client will refuse to connect to private addresses.
+func New(m *model.Model) *Dispatcher {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return ErrPrivateAddress
+			}
+
+			return nil
+		},
+	}
+
+	return &Dispatcher{
+		model: m,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: requestTimeout,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+
+	for _, cidr := range privateCIDRs {
+		if cidr.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var privateCIDRs = func() []*net.IPNet {
+	cidrs := []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7"}
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+
+	return nets
+}()
+
+// Start will begin delivering in the background
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				select {
+				case <-d.stop:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			if _, err := d.DeliverPending(ctx); err != nil && err != context.Canceled {
+				logrus.WithError(err).Error("webhook: could not deliver pending webhooks")
+			}
+			cancel()
+
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+			case <-d.wake:
+			}
+		}
+	}()
+}
+
+// Wake will tell the dispatcher to check for deliveries now instead of waiting for the next poll
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop will stop delivering and wait for any deliveries in progress
+func (d *Dispatcher) Stop() {
+	d.once.Do(func() {
+		close(d.stop)
+	})
+	d.wg.Wait()
+}
+
+// DeliverPending will deliver every delivery that is due. It returns the number of deliveries that were attempted.
+func (d *Dispatcher) DeliverPending(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		deliveries, err := d.model.LeaseWebhookDeliveries(ctx, batchSize, leaseDuration)
+		if err != nil {
+			return total, err
+		}
+
+		if len(deliveries) == 0 {
+			return total, nil
+		}
+
+		for _, delivery := range deliveries {
+			if err := d.deliver(ctx, delivery); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	lr := logrus.WithFields(logrus.Fields{
+		"delivery": delivery.ID,
+		"webhook":  delivery.WebhookID,
+	})
+
+	statusCode, err := Send(ctx, d.client, delivery.URL, delivery.Secret, delivery.Event, delivery.ID, []byte(delivery.Payload))
+	if err == nil {
+		return delivery.Delivered(ctx, statusCode)
+	}
+
+	// the dispatcher is stopping, so leave the delivery to be picked up again once its lease expires
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var code *int
+	if statusCode > 0 {
+		code = &statusCode
+	}
+
+	errMsg := err.Error()
+	if len(errMsg) > maxErrorLength {
+		errMsg = errMsg[:maxErrorLength]
+	}
+
+	var retryAt *time.Time
+	if attempts := delivery.Attempts + 1; attempts < MaxAttempts {
+		t := time.Now().Add(Backoff(attempts))
+		retryAt = &t
+	}
+
+	lr.WithError(err).WithField("attempts", delivery.Attempts+1).Warn("webhook: delivery failed")
+	return delivery.Failed(ctx, code, errMsg, retryAt)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestSign(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	body := []byte(`{"event":"poolLocked"}`)
+	sig := Sign("secret", 1577836800, body)
+	g.Expect(sig).Should(gomega.HaveLen(64))
+	g.Expect(Sign("secret", 1577836800, body)).Should(gomega.Equal(sig))
+	g.Expect(Sign("other", 1577836800, body)).ShouldNot(gomega.Equal(sig))
+	g.Expect(Sign("secret", 1577836801, body)).ShouldNot(gomega.Equal(sig))
+	g.Expect(SignatureHeader("secret", 1577836800, body)).Should(gomega.Equal("t=1577836800,v1=" + sig))
+}
+
+func TestBackoff(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	g.Expect(Backoff(0)).Should(gomega.Equal(baseBackoff))
+	g.Expect(Backoff(1)).Should(gomega.Equal(baseBackoff))
+	g.Expect(Backoff(2)).Should(gomega.Equal(baseBackoff * 2))
+	g.Expect(Backoff(4)).Should(gomega.Equal(baseBackoff * 8))
+	g.Expect(Backoff(100)).Should(gomega.Equal(maxBackoff))
+}
+
+func TestIsEvent(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	for _, event := range Events {
+		g.Expect(IsEvent(event)).Should(gomega.BeTrue())
+	}
+
+	g.Expect(IsEvent("squareRenamed")).Should(gomega.BeFalse())
+}
+
+func TestSend(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+
+	requests := make(chan received, 1)
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		code := status
+		requests <- received{header: r.Header, body: body}
+		w.WriteHeader(code)
+	}))
+	defer srv.Close()
+
+	body := []byte(`{"event":"squareClaimed","pool":"abc","data":{"squareId":7}}`)
+	code, err := Send(context.Background(), srv.Client(), srv.URL, "s3cret", "squareClaimed", 42, body)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(code).Should(gomega.Equal(http.StatusNoContent))
+
+	req := <-requests
+	g.Expect(req.body).Should(gomega.Equal(body))
+	g.Expect(req.header.Get("Content-Type")).Should(gomega.Equal("application/json"))
+	g.Expect(req.header.Get(HeaderEvent)).Should(gomega.Equal("squareClaimed"))
+	g.Expect(req.header.Get(HeaderDelivery)).Should(gomega.Equal("42"))
+
+	// the receiver must be able to verify the signature with the secret
+	parts := strings.Split(req.header.Get(HeaderSignature), ",")
+	g.Expect(parts).Should(gomega.HaveLen(2))
+	g.Expect(parts[0]).Should(gomega.HavePrefix("t="))
+	g.Expect(parts[1]).Should(gomega.HavePrefix("v1="))
+	timestamp, err := strconv.ParseInt(strings.TrimPrefix(parts[0], "t="), 10, 64)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(strings.TrimPrefix(parts[1], "v1=")).Should(gomega.Equal(Sign("s3cret", timestamp, req.body)))
+
+	status = http.StatusInternalServerError
+	code, err = Send(context.Background(), srv.Client(), srv.URL, "s3cret", "squareClaimed", 43, body)
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(code).Should(gomega.Equal(http.StatusInternalServerError))
+	<-requests
+}
+
+func TestDispatcherRefusesPrivateAddresses(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the request should not have been made")
+	}))
+	defer srv.Close()
+
+	d := New(nil)
+	code, err := Send(context.Background(), d.client, srv.URL, "s3cret", "squareClaimed", 1, []byte(`{}`))
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring(ErrPrivateAddress.Error()))
+	g.Expect(code).Should(gomega.Equal(0))
+
+	g.Expect(isPublicIP(net.ParseIP("8.8.8.8"))).Should(gomega.BeTrue())
+	g.Expect(isPublicIP(net.ParseIP("2001:4860:4860::8888"))).Should(gomega.BeTrue())
+	g.Expect(isPublicIP(net.ParseIP("127.0.0.1"))).Should(gomega.BeFalse())
+	g.Expect(isPublicIP(net.ParseIP("10.1.2.3"))).Should(gomega.BeFalse())
+	g.Expect(isPublicIP(net.ParseIP("172.20.0.1"))).Should(gomega.BeFalse())
+	g.Expect(isPublicIP(net.ParseIP("192.168.1.1"))).Should(gomega.BeFalse())
+	g.Expect(isPublicIP(net.ParseIP("169.254.169.254"))).Should(gomega.BeFalse())
+	g.Expect(isPublicIP(net.ParseIP("::1"))).Should(gomega.BeFalse())
+}
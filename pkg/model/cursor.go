@@ -0,0 +1,80 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be parsed
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks the last row of a page so that the next page can start right after it, even if rows were added or
+// removed in the meantime. The zero value is the start of the first page.
+type Cursor struct {
+	// key is the value of the column that the rows are sorted by before the ID, if any
+	key int64
+	id  int64
+}
+
+// ParseCursor will parse a cursor that was returned by Cursor.String
+func ParseCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(b), ":")
+	if len(parts) != 2 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if c.key, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	if c.id, err = strconv.ParseInt(parts[1], 10, 64); err != nil || c.id <= 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	// only accept the exact form that String returns, so that a cursor cannot be spelled in more than one way
+	if c.String() != s {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// IsZero returns whether the cursor is the start of the first page. A zero cursor returned as the next cursor means
+// there are no more pages.
+func (c Cursor) IsZero() bool {
+	return c.id == 0
+}
+
+// String returns the opaque representation of the cursor
+func (c Cursor) String() string {
+	if c.IsZero() {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.key, c.id)))
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestCursor(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	g.Expect(Cursor{}.IsZero()).Should(gomega.BeTrue())
+	g.Expect(Cursor{}.String()).Should(gomega.Equal(""))
+
+	c := Cursor{key: -2, id: 42}
+	g.Expect(c.IsZero()).Should(gomega.BeFalse())
+
+	parsed, err := ParseCursor(c.String())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(parsed).Should(gomega.Equal(c))
+
+	invalid := []string{"", "42", "!!!", "MTow", "YTpi"}
+	for _, raw := range []string{"1:2x", "1:2:3", " 1:2", "1: 2", "+1:2", "01:2", "1:-2", "1:2\n"} {
+		invalid = append(invalid, base64.RawURLEncoding.EncodeToString([]byte(raw)))
+	}
+
+	// padded encoding of a valid cursor
+	invalid = append(invalid, base64.URLEncoding.EncodeToString([]byte("12:3")))
+
+	for _, s := range invalid {
+		_, err := ParseCursor(s)
+		g.Expect(err).Should(gomega.Equal(ErrInvalidCursor), s)
+	}
+}
@@ -54,6 +54,7 @@ type Grid struct {
 
 	id           int64
 	poolID       int64
+	gridType     GridType
 	ord          int
 	label        *string
 	homeTeamName *string
@@ -66,28 +67,35 @@ type Grid struct {
 	state        State
 	created      time.Time
 	modified     time.Time
+	version      int64
 
 	settings    *GridSettings
 	annotations map[int]*GridAnnotation
+	scores      map[GridScorePeriod]*GridScore
+	payouts     []*GridPayout
+	draw        *GridDraw
 }
 
 // GridJSON represents grid metadata that can be sent to the front-end
 type GridJSON struct {
-	ID           int64                   `json:"id"`
-	Name         string                  `json:"name"`
-	Label        string                  `json:"label"`
-	HomeTeamName string                  `json:"homeTeamName"`
-	HomeNumbers  []int                   `json:"homeNumbers"`
-	AwayTeamName string                  `json:"awayTeamName"`
-	AwayNumbers  []int                   `json:"awayNumbers"`
-	ManualDraw   bool                    `json:"manualDraw"`
-	EventDate    time.Time               `json:"eventDate"`
-	Rollover     bool                    `json:"rollover"`
-	State        State                   `json:"state"`
-	Created      time.Time               `json:"created"`
-	Modified     time.Time               `json:"modified"`
-	Settings     *GridSettings           `json:"settings"`
-	Annotations  map[int]*GridAnnotation `json:"annotations"`
+	ID           int64                          `json:"id"`
+	Name         string                         `json:"name"`
+	Label        string                         `json:"label"`
+	HomeTeamName string                         `json:"homeTeamName"`
+	HomeNumbers  []int                          `json:"homeNumbers"`
+	AwayTeamName string                         `json:"awayTeamName"`
+	AwayNumbers  []int                          `json:"awayNumbers"`
+	ManualDraw   bool                           `json:"manualDraw"`
+	EventDate    time.Time                      `json:"eventDate"`
+	Rollover     bool                           `json:"rollover"`
+	State        State                          `json:"state"`
+	Created      time.Time                      `json:"created"`
+	Modified     time.Time                      `json:"modified"`
+	Settings     *GridSettings                  `json:"settings"`
+	Annotations  map[int]*GridAnnotation        `json:"annotations"`
+	Scores       map[GridScorePeriod]*GridScore `json:"scores"`
+	Winners      map[GridScorePeriod]int        `json:"winners"`
+	Payouts      []*GridPayout                  `json:"payouts"`
 }
 
 // JSON will marshal the JSON using a custom marshaller
@@ -108,6 +116,9 @@ func (g *Grid) JSON() *GridJSON {
 		Modified:     g.modified,
 		Settings:     g.settings,
 		Annotations:  g.annotations,
+		Scores:       g.scores,
+		Winners:      g.Winners(),
+		Payouts:      g.payouts,
 	}
 }
 
@@ -192,6 +203,12 @@ func (g *Grid) Created() time.Time {
 	return g.created
 }
 
+// Version returns the version of the grid, which goes up every time the grid, its settings, annotations, scores or
+// payouts are changed
+func (g *Grid) Version() int64 {
+	return g.version
+}
+
 // EventDate returns the date of the event
 func (g *Grid) EventDate() time.Time {
 	return g.eventDate
@@ -228,11 +245,25 @@ func (g *Grid) SetLabel(label string) {
 
 // Save will save the grid. It will also save any dependent objects
 func (g *Grid) Save(ctx context.Context) error {
-	tx, err := g.model.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
+	return g.SaveVersion(ctx, 0)
+}
 
+// SaveTx will save the grid and any dependent objects within the transaction
+func (g *Grid) SaveTx(ctx context.Context, tx *sql.Tx) error {
+	return g.SaveVersionTx(ctx, tx, 0)
+}
+
+// SaveVersion will save the grid and any dependent objects only if the grid is still at the version, e.g. the one that
+// a client last loaded. If it has been changed since, ErrVersionMismatch is returned.
+func (g *Grid) SaveVersion(ctx context.Context, version int64) error {
+	return g.model.inTx(ctx, func(tx *sql.Tx) error {
+		return g.SaveVersionTx(ctx, tx, version)
+	})
+}
+
+// SaveVersionTx will save the grid and any dependent objects within the transaction only if the grid is still at the
+// version. The version is not checked if it is 0.
+func (g *Grid) SaveVersionTx(ctx context.Context, tx *sql.Tx, version int64) error {
 	if g.id == 0 {
 		const query = `
 SELECT ` + gridColumns + `
@@ -242,10 +273,6 @@ FROM
 		row := tx.QueryRowContext(ctx, query, g.poolID, MaxGridsPerPool)
 		newGrid, err := g.model.gridByRow(row.Scan)
 		if err != nil {
-			if err2 := tx.Rollback(); err2 != nil {
-				return fmt.Errorf("error found: %#v. Another error found when trying to rollback: %#v", err, err2)
-			}
-
 			if err.Error() == "pq: limit reached" {
 				return ErrGridLimit
 			}
@@ -261,14 +288,8 @@ FROM
 		if g.settings != nil {
 			g.settings.gridID = g.id
 		}
-	}
-
-	if g.settings != nil {
-		if err := g.settings.Save(ctx, tx); err != nil {
-			if err2 := tx.Rollback(); err2 != nil {
-				return fmt.Errorf("error found: %#v. Another error found when trying to rollback: %#v", err, err2)
-			}
 
+		if err := g.createSquares(ctx, tx); err != nil {
 			return err
 		}
 	}
@@ -292,17 +313,62 @@ FROM
 		    label = $10,
 			modified = (now() at time zone 'utc')
 		WHERE id = $11
+		  AND (version = $12 OR $12 = 0)
 	`
 
-	if _, err := tx.ExecContext(ctx, query, g.ord, g.homeTeamName, pq.Array(g.homeNumbers), g.awayTeamName, pq.Array(g.awayNumbers), g.manualDraw, eventDate, g.rollover, g.state, g.label, g.id); err != nil {
-		if err2 := tx.Rollback(); err2 != nil {
-			return fmt.Errorf("error found: %#v. Another error found when trying to rollback: %#v", err, err2)
+	// the grid is updated before its settings, since changing the settings changes the version of the grid
+	res, err := tx.ExecContext(ctx, query, g.ord, g.homeTeamName, pq.Array(g.homeNumbers), g.awayTeamName, pq.Array(g.awayNumbers), g.manualDraw, eventDate, g.rollover, g.state, g.label, g.id, version)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 && version != 0 {
+		return ErrVersionMismatch
+	}
+
+	if g.settings != nil {
+		if err := g.settings.Save(ctx, tx); err != nil {
+			return err
 		}
+	}
 
+	return g.saveDraw(ctx, tx)
+}
+
+// lockVersion will lock the grid for the rest of the transaction and return ErrVersionMismatch if it is no longer at
+// the version. A change that waited for the lock sees the version that the other change left behind. The version is
+// not checked if it is 0.
+func (g *Grid) lockVersion(ctx context.Context, q Queryable, version int64) error {
+	if version == 0 {
+		return nil
+	}
+
+	var ok bool
+	row := q.QueryRowContext(ctx, "SELECT version = $2 FROM grids WHERE id = $1 FOR NO KEY UPDATE", g.id, version)
+	if err := row.Scan(&ok); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	if !ok {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// createSquares will create the squares of a new grid if each grid of the pool has its own squares
+func (g *Grid) createSquares(ctx context.Context, q Queryable) error {
+	const query = `
+INSERT INTO pool_squares (pool_id, grid_id, square_id)
+SELECT pools.id, $1, square_id
+FROM pools
+CROSS JOIN generate_series(1, $3) AS square_id
+WHERE pools.id = $2 AND pools.squares_per_grid`
+
+	_, err := q.ExecContext(ctx, query, g.id, g.poolID, g.gridType.Squares())
+	return err
 }
 
 // Settings will return the settings
@@ -380,8 +446,17 @@ func (g *Grid) SelectRandomNumbers() error {
 
 // Delete the grid. By delete, we mean set the row to 'deleted'
 func (g *Grid) Delete(ctx context.Context) error {
+	return g.delete(ctx, g.model.DB)
+}
+
+// DeleteTx will delete the grid within the transaction
+func (g *Grid) DeleteTx(ctx context.Context, tx *sql.Tx) error {
+	return g.delete(ctx, tx)
+}
+
+func (g *Grid) delete(ctx context.Context, q Queryable) error {
 	const query = "SELECT * FROM delete_grid($1)"
-	row := g.model.DB.QueryRowContext(ctx, query, g.id)
+	row := q.QueryRowContext(ctx, query, g.id)
 	var ok bool
 	if err := row.Scan(&ok); err != nil {
 		return err
@@ -400,7 +475,8 @@ func (g *Grid) LoadSettings(ctx context.Context) error {
 		SELECT grid_id,
 			   home_team_color_1, home_team_color_2,
 			   away_team_color_1, away_team_color_2,
-			   notes, modified
+			   notes, square_price, house_cut,
+			   unclaimed_rule, modified
 		FROM grid_settings
 		WHERE grid_id = $1
 	`, g.id)
@@ -416,6 +492,9 @@ func (g *Grid) LoadSettings(ctx context.Context) error {
 		&g.settings.awayTeamColor1,
 		&g.settings.awayTeamColor2,
 		&g.settings.notes,
+		&g.settings.squarePrice,
+		&g.settings.houseCut,
+		&g.settings.unclaimedRule,
 		&g.settings.modified,
 	)
 }
@@ -456,7 +535,7 @@ func (m *Model) gridByRow(scan scanFunc) (*Grid, error) {
 	var homeNumbers, awayNumbers []sql.NullInt64
 	var eventDate *time.Time
 
-	if err := scan(&grid.id, &grid.poolID, &grid.ord, &grid.label, &grid.homeTeamName, pq.Array(&homeNumbers), &grid.awayTeamName, pq.Array(&awayNumbers), &eventDate, &grid.rollover, &grid.state, &grid.created, &grid.modified, &grid.manualDraw); err != nil {
+	if err := scan(&grid.id, &grid.poolID, &grid.ord, &grid.label, &grid.homeTeamName, pq.Array(&homeNumbers), &grid.awayTeamName, pq.Array(&awayNumbers), &eventDate, &grid.rollover, &grid.state, &grid.created, &grid.modified, &grid.manualDraw, &grid.version); err != nil {
 		return nil, err
 	}
 
@@ -498,4 +577,5 @@ const gridColumns = `
 	state,
 	created,
 	modified,
-	manual_draw`
+	manual_draw,
+	version`
@@ -38,7 +38,16 @@ const gridAnnotationColumns = `id, grid_id, square_id, annotation, icon, created
 
 // DeleteAnnotationBySquareID will delete the annotation
 func (g *Grid) DeleteAnnotationBySquareID(ctx context.Context, squareID int) error {
-	_, err := g.model.DB.ExecContext(ctx, "DELETE FROM grid_annotations WHERE grid_id = $1 AND square_id = $2", g.ID(), squareID)
+	return g.deleteAnnotationBySquareID(ctx, g.model.DB, squareID)
+}
+
+// DeleteAnnotationBySquareIDTx will delete the annotation within the transaction
+func (g *Grid) DeleteAnnotationBySquareIDTx(ctx context.Context, tx *sql.Tx, squareID int) error {
+	return g.deleteAnnotationBySquareID(ctx, tx, squareID)
+}
+
+func (g *Grid) deleteAnnotationBySquareID(ctx context.Context, q Queryable, squareID int) error {
+	_, err := q.ExecContext(ctx, "DELETE FROM grid_annotations WHERE grid_id = $1 AND square_id = $2", g.ID(), squareID)
 	return err
 }
 
@@ -65,6 +74,15 @@ func (g *Grid) AnnotationBySquareID(ctx context.Context, squareID int) (*GridAnn
 
 // Save will save the data to the database
 func (a *GridAnnotation) Save(ctx context.Context) error {
+	return a.save(ctx, a.model.DB)
+}
+
+// SaveTx will save the data to the database within the transaction
+func (a *GridAnnotation) SaveTx(ctx context.Context, tx *sql.Tx) error {
+	return a.save(ctx, tx)
+}
+
+func (a *GridAnnotation) save(ctx context.Context, q Queryable) error {
 	// insert
 	if a.ID == 0 {
 		const query = `
@@ -75,7 +93,7 @@ VALUES
 RETURNING ` + gridAnnotationColumns
 
 		model := a.model
-		row := model.DB.QueryRowContext(ctx, query, a.GridID, a.SquareID, a.Annotation, a.Icon)
+		row := q.QueryRowContext(ctx, query, a.GridID, a.SquareID, a.Annotation, a.Icon)
 		a2, err := model.gridAnnotationByRow(row.Scan)
 		if err != nil {
 			return err
@@ -97,7 +115,7 @@ WHERE
 	id = $3
 `
 
-	_, err := a.model.DB.ExecContext(ctx, query, a.Annotation, a.Icon, a.ID)
+	_, err := q.ExecContext(ctx, query, a.Annotation, a.Icon, a.ID)
 	return err
 }
 
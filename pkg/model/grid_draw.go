@@ -0,0 +1,359 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DrawAlgorithmVersion is the version of the draw algorithm used for new commitments
+const DrawAlgorithmVersion = 1
+
+// DrawEntropyMaxLength is the maximum number of characters in the entropy string supplied when drawing numbers
+const DrawEntropyMaxLength = 200
+
+// drawSeedBytes is the number of random bytes in a draw seed
+const drawSeedBytes = 32
+
+// drawAlgorithms are the names of each version of the draw algorithm
+var drawAlgorithms = map[int]string{
+	1: "hmac-sha256-fisher-yates",
+}
+
+// ErrUnknownDrawAlgorithm happens when numbers are drawn with an algorithm version that does not exist
+var ErrUnknownDrawAlgorithm = errors.New("error: unknown draw algorithm")
+
+// ErrDrawSeedRevealed happens when numbers are drawn with a seed that is already public
+var ErrDrawSeedRevealed = errors.New("error: the draw seed has already been revealed")
+
+// DrawCommitment is a secret seed that a pool is committed to before any numbers are drawn with it. The commitment is
+// the SHA-256 hash of the seed and is public from the start. The seed is only revealed once numbers have been drawn
+// with it so that anyone can check that the numbers were not picked after the fact.
+type DrawCommitment struct {
+	model            *Model
+	id               int64
+	poolID           int64
+	seed             []byte
+	commitment       string
+	algorithmVersion int
+	created          time.Time
+	revealed         *time.Time
+}
+
+// DrawCommitmentJSON is the JSON representation of a DrawCommitment. The seed is only included once it has been
+// revealed.
+type DrawCommitmentJSON struct {
+	ID               int64      `json:"id"`
+	Commitment       string     `json:"commitment"`
+	Seed             string     `json:"seed,omitempty"`
+	Algorithm        string     `json:"algorithm"`
+	AlgorithmVersion int        `json:"algorithmVersion"`
+	Created          time.Time  `json:"created"`
+	Revealed         *time.Time `json:"revealed"`
+}
+
+// ID is a getter
+func (d *DrawCommitment) ID() int64 {
+	return d.id
+}
+
+// Commitment is the hex encoded SHA-256 hash of the seed
+func (d *DrawCommitment) Commitment() string {
+	return d.commitment
+}
+
+// IsRevealed returns whether the seed is public
+func (d *DrawCommitment) IsRevealed() bool {
+	return d.revealed != nil
+}
+
+// JSON will return the JSON representation of the commitment
+func (d *DrawCommitment) JSON() *DrawCommitmentJSON {
+	j := &DrawCommitmentJSON{
+		ID:               d.id,
+		Commitment:       d.commitment,
+		Algorithm:        drawAlgorithms[d.algorithmVersion],
+		AlgorithmVersion: d.algorithmVersion,
+		Created:          d.created,
+		Revealed:         d.revealed,
+	}
+
+	if d.IsRevealed() {
+		j.Seed = hex.EncodeToString(d.seed)
+	}
+
+	return j
+}
+
+// DrawCommitmentHash returns the commitment of a seed
+func DrawCommitmentHash(seed []byte) string {
+	sum := sha256.Sum256(seed)
+	return hex.EncodeToString(sum[:])
+}
+
+// DrawNumbers will deterministically derive the home and away numbers of a grid from the seed, the grid ID and the
+// entropy string.
+//
+// Version 1 shuffles the digits 0-9 for each team with a Fisher-Yates shuffle. The random values are read as big
+// endian uint32s from a stream of HMAC-SHA256 blocks, keyed with the seed, where block n is the HMAC of n as a big
+// endian uint32 followed by "sqmgr-draw/v1", the grid ID in decimal, the team ("home" or "away") and the entropy,
+// each separated by a NUL byte. For each i from 9 down to 1, values of at least 2^32 - (2^32 mod (i+1)) are skipped
+// to avoid bias, and the digit at i is swapped with the digit at value mod (i+1).
+func DrawNumbers(version int, seed []byte, gridID int64, entropy string) (home, away []int, err error) {
+	if version != 1 {
+		return nil, nil, ErrUnknownDrawAlgorithm
+	}
+
+	return drawShuffleV1(seed, gridID, "home", entropy), drawShuffleV1(seed, gridID, "away", entropy), nil
+}
+
+func drawShuffleV1(seed []byte, gridID int64, team, entropy string) []int {
+	info := []byte(fmt.Sprintf("sqmgr-draw/v1\x00%d\x00%s\x00%s", gridID, team, entropy))
+
+	var block []byte
+	var counter uint32
+	next := func() uint32 {
+		if len(block) == 0 {
+			mac := hmac.New(sha256.New, seed)
+			var n [4]byte
+			binary.BigEndian.PutUint32(n[:], counter)
+			mac.Write(n[:])
+			mac.Write(info)
+			block = mac.Sum(nil)
+			counter++
+		}
+
+		val := binary.BigEndian.Uint32(block)
+		block = block[4:]
+		return val
+	}
+
+	nums := make([]int, 10)
+	for i := range nums {
+		nums[i] = i
+	}
+
+	for i := len(nums) - 1; i > 0; i-- {
+		n := uint64(i + 1)
+		limit := (1 << 32) - (1<<32)%n
+
+		val := uint64(next())
+		for val >= limit {
+			val = uint64(next())
+		}
+
+		j := int(val % n)
+		nums[i], nums[j] = nums[j], nums[i]
+	}
+
+	return nums
+}
+
+const drawCommitmentColumns = `id, pool_id, seed, commitment, algorithm_version, created, revealed`
+
+// CommitDrawSeed will commit the pool to a new random seed unless it is already committed to one that hasn't been
+// revealed yet. The current commitment is returned.
+func (p *Pool) CommitDrawSeed(ctx context.Context) (*DrawCommitment, error) {
+	if err := commitDrawSeed(ctx, p.model.DB, p.id); err != nil {
+		return nil, err
+	}
+
+	return p.DrawCommitment(ctx)
+}
+
+// CommitDrawSeedTx will commit the pool to a new random seed within the transaction, as CommitDrawSeed does
+func (p *Pool) CommitDrawSeedTx(ctx context.Context, tx *sql.Tx) error {
+	return commitDrawSeed(ctx, tx, p.id)
+}
+
+func commitDrawSeed(ctx context.Context, q Queryable, poolID int64) error {
+	seed := make([]byte, drawSeedBytes)
+	if _, err := rand.Read(seed); err != nil {
+		return err
+	}
+
+	const query = `
+INSERT INTO pool_draw_commitments (pool_id, seed, commitment, algorithm_version)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (pool_id) WHERE revealed IS NULL DO NOTHING
+`
+
+	_, err := q.ExecContext(ctx, query, poolID, seed, DrawCommitmentHash(seed), DrawAlgorithmVersion)
+	return err
+}
+
+// DrawCommitment returns the most recent commitment of the pool, which is the one that numbers will be drawn with.
+// If the pool has never been committed to a seed, sql.ErrNoRows is returned.
+func (p *Pool) DrawCommitment(ctx context.Context) (*DrawCommitment, error) {
+	const query = `
+SELECT ` + drawCommitmentColumns + `
+FROM pool_draw_commitments
+WHERE pool_id = $1
+ORDER BY id DESC
+LIMIT 1
+`
+
+	return p.model.drawCommitmentByRow(p.model.DB.QueryRowContext(ctx, query, p.id).Scan)
+}
+
+func (m *Model) drawCommitmentByRow(scan scanFunc) (*DrawCommitment, error) {
+	d := &DrawCommitment{model: m}
+	if err := scan(&d.id, &d.poolID, &d.seed, &d.commitment, &d.algorithmVersion, &d.created, &d.revealed); err != nil {
+		return nil, err
+	}
+
+	d.created = d.created.In(locationNewYork)
+	if d.revealed != nil {
+		revealed := d.revealed.In(locationNewYork)
+		d.revealed = &revealed
+	}
+
+	return d, nil
+}
+
+// GridDraw records the commitment and entropy that the numbers of a grid were drawn with
+type GridDraw struct {
+	gridID      int64
+	commitment  *DrawCommitment
+	entropy     string
+	homeNumbers []int
+	awayNumbers []int
+	created     time.Time
+}
+
+// GridDrawJSON is the JSON representation of a GridDraw. It has everything needed to derive the numbers again.
+type GridDrawJSON struct {
+	GridID      int64               `json:"gridId"`
+	Commitment  *DrawCommitmentJSON `json:"commitment"`
+	Entropy     string              `json:"entropy"`
+	HomeNumbers []int               `json:"homeNumbers"`
+	AwayNumbers []int               `json:"awayNumbers"`
+	Created     time.Time           `json:"created"`
+}
+
+// JSON will return the JSON representation of the draw
+func (d *GridDraw) JSON() *GridDrawJSON {
+	return &GridDrawJSON{
+		GridID:      d.gridID,
+		Commitment:  d.commitment.JSON(),
+		Entropy:     d.entropy,
+		HomeNumbers: d.homeNumbers,
+		AwayNumbers: d.awayNumbers,
+		Created:     d.created,
+	}
+}
+
+// SelectCommittedNumbers will draw the numbers for the home and away team from the seed of the commitment. The seed
+// is revealed when the grid is saved, so a commitment can only be drawn with once and ErrDrawSeedRevealed is returned
+// for a seed that is already public.
+func (g *Grid) SelectCommittedNumbers(commitment *DrawCommitment, entropy string) error {
+	if g.homeNumbers != nil || g.awayNumbers != nil {
+		return ErrNumbersAlreadyDrawn
+	}
+
+	if commitment.IsRevealed() {
+		return ErrDrawSeedRevealed
+	}
+
+	home, away, err := DrawNumbers(commitment.algorithmVersion, commitment.seed, g.id, entropy)
+	if err != nil {
+		return err
+	}
+
+	g.manualDraw = false
+	g.homeNumbers = home
+	g.awayNumbers = away
+	g.draw = &GridDraw{
+		gridID:      g.id,
+		commitment:  commitment,
+		entropy:     entropy,
+		homeNumbers: home,
+		awayNumbers: away,
+	}
+
+	return nil
+}
+
+// saveDraw will record a draw that has not been saved yet and reveal the seed it was drawn with. The pool is then
+// committed to a fresh seed for the next draw. If the seed was revealed by another draw in the meantime,
+// ErrDrawSeedRevealed is returned.
+func (g *Grid) saveDraw(ctx context.Context, q Queryable) error {
+	if g.draw == nil || !g.draw.created.IsZero() {
+		return nil
+	}
+
+	res, err := q.ExecContext(ctx, "UPDATE pool_draw_commitments SET revealed = (now() at time zone 'utc') WHERE id = $1 AND revealed IS NULL", g.draw.commitment.id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrDrawSeedRevealed
+	}
+
+	var created time.Time
+	row := q.QueryRowContext(ctx, "INSERT INTO grid_draws (grid_id, commitment_id, entropy) VALUES ($1, $2, $3) RETURNING created", g.id, g.draw.commitment.id, g.draw.entropy)
+	if err := row.Scan(&created); err != nil {
+		return err
+	}
+
+	if err := commitDrawSeed(ctx, q, g.poolID); err != nil {
+		return err
+	}
+
+	g.draw.created = created
+	return nil
+}
+
+// Draw returns how the numbers of the grid were drawn. If they were drawn manually, or before draws were committed to
+// a seed, sql.ErrNoRows is returned.
+func (g *Grid) Draw(ctx context.Context) (*GridDraw, error) {
+	const query = `
+SELECT
+	d.entropy, d.created,
+	c.id, c.pool_id, c.seed, c.commitment, c.algorithm_version, c.created, c.revealed
+FROM
+	grid_draws d
+	INNER JOIN pool_draw_commitments c ON d.commitment_id = c.id
+WHERE
+	d.grid_id = $1
+`
+
+	draw := &GridDraw{gridID: g.id, homeNumbers: g.homeNumbers, awayNumbers: g.awayNumbers}
+	row := g.model.DB.QueryRowContext(ctx, query, g.id)
+	commitment, err := g.model.drawCommitmentByRow(func(dest ...interface{}) error {
+		return row.Scan(append([]interface{}{&draw.entropy, &draw.created}, dest...)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	draw.commitment = commitment
+	draw.created = draw.created.In(locationNewYork)
+	return draw, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestDrawNumbers(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	seed := make([]byte, drawSeedBytes)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+
+	g.Expect(DrawCommitmentHash(seed)).Should(gomega.Equal("630dcd2966c4336691125448bbb25b4ff412a49c732db2c8abc1b8581bd710dd"))
+
+	home, away, err := DrawNumbers(1, seed, 1, "")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(home).Should(gomega.Equal([]int{1, 7, 3, 9, 6, 5, 0, 2, 4, 8}))
+	g.Expect(away).Should(gomega.Equal([]int{8, 3, 1, 6, 4, 2, 9, 0, 5, 7}))
+
+	home, away, err = DrawNumbers(1, seed, 1, "Patriots 2026")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(home).Should(gomega.Equal([]int{9, 0, 2, 7, 3, 4, 1, 8, 5, 6}))
+	g.Expect(away).Should(gomega.Equal([]int{8, 1, 7, 2, 5, 9, 3, 4, 6, 0}))
+
+	home, away, err = DrawNumbers(1, seed, 2, "")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(numbersAreValid(home)).Should(gomega.BeTrue())
+	g.Expect(numbersAreValid(away)).Should(gomega.BeTrue())
+	g.Expect(home).ShouldNot(gomega.Equal([]int{1, 7, 3, 9, 6, 5, 0, 2, 4, 8}))
+
+	_, _, err = DrawNumbers(2, seed, 1, "")
+	g.Expect(err).Should(gomega.Equal(ErrUnknownDrawAlgorithm))
+}
+
+func TestDrawCommitment(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	pool := getPool(m)
+	_, err := pool.DrawCommitment(ctx)
+	g.Expect(err).Should(gomega.Equal(sql.ErrNoRows))
+
+	commitment, err := pool.CommitDrawSeed(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(commitment.IsRevealed()).Should(gomega.BeFalse())
+	g.Expect(commitment.Commitment()).Should(gomega.Equal(DrawCommitmentHash(commitment.seed)))
+	g.Expect(commitment.JSON().Seed).Should(gomega.Equal(""))
+	g.Expect(commitment.JSON().Algorithm).Should(gomega.Equal("hmac-sha256-fisher-yates"))
+
+	// committing again keeps the same seed until it has been revealed
+	again, err := pool.CommitDrawSeed(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(again.ID()).Should(gomega.Equal(commitment.ID()))
+
+	grid, err := pool.DefaultGrid(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	_, err = grid.Draw(ctx)
+	g.Expect(err).Should(gomega.Equal(sql.ErrNoRows))
+
+	g.Expect(grid.SelectCommittedNumbers(commitment, "my entropy")).Should(gomega.Succeed())
+	g.Expect(grid.SelectCommittedNumbers(commitment, "my entropy")).Should(gomega.Equal(ErrNumbersAlreadyDrawn))
+	g.Expect(grid.Save(ctx)).Should(gomega.Succeed())
+
+	grid, err = pool.GridByID(ctx, grid.ID())
+	g.Expect(err).Should(gomega.Succeed())
+
+	draw, err := grid.Draw(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	drawJSON := draw.JSON()
+	g.Expect(drawJSON.Entropy).Should(gomega.Equal("my entropy"))
+	g.Expect(drawJSON.Commitment.ID).Should(gomega.Equal(commitment.ID()))
+	g.Expect(drawJSON.Commitment.Revealed).ShouldNot(gomega.BeNil())
+	g.Expect(drawJSON.Commitment.Seed).Should(gomega.HaveLen(drawSeedBytes * 2))
+
+	// anyone can derive the numbers from what was revealed
+	home, away, err := DrawNumbers(drawJSON.Commitment.AlgorithmVersion, draw.commitment.seed, grid.ID(), drawJSON.Entropy)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(grid.HomeNumbers()).Should(gomega.Equal(home))
+	g.Expect(grid.AwayNumbers()).Should(gomega.Equal(away))
+
+	// once revealed, a new seed is committed to
+	next, err := pool.DrawCommitment(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(next.ID()).ShouldNot(gomega.Equal(commitment.ID()))
+	g.Expect(next.IsRevealed()).Should(gomega.BeFalse())
+
+	again, err = pool.CommitDrawSeed(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(again.ID()).Should(gomega.Equal(next.ID()))
+
+	// a second grid cannot be drawn with the seed that was revealed
+	second := pool.NewGrid()
+	g.Expect(second.Save(ctx)).Should(gomega.Succeed())
+	g.Expect(second.SelectCommittedNumbers(draw.commitment, "my entropy")).Should(gomega.Equal(ErrDrawSeedRevealed))
+	g.Expect(second.HomeNumbers()).Should(gomega.BeNil())
+
+	// not even when the commitment was loaded before it was revealed
+	g.Expect(second.SelectCommittedNumbers(commitment, "my entropy")).Should(gomega.Succeed())
+	g.Expect(second.Save(ctx)).Should(gomega.Equal(ErrDrawSeedRevealed))
+
+	second, err = pool.GridByID(ctx, second.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(second.HomeNumbers()).Should(gomega.BeNil())
+
+	g.Expect(second.SelectCommittedNumbers(next, "my entropy")).Should(gomega.Succeed())
+	g.Expect(second.Save(ctx)).Should(gomega.Succeed())
+}
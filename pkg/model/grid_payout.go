@@ -0,0 +1,450 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrInvalidPayouts is an error when the payout schedule cannot be saved
+var ErrInvalidPayouts = errors.New("error: invalid payouts")
+
+// UnclaimedRule determines what happens to the winnings of a period when the winning square was not sold
+type UnclaimedRule string
+
+// Allowed unclaimed rules
+const (
+	// UnclaimedRuleHouse will return the winnings to the house
+	UnclaimedRuleHouse UnclaimedRule = "house"
+	// UnclaimedRuleRollover will add the winnings to the next period in the payout schedule
+	UnclaimedRuleRollover UnclaimedRule = "rollover"
+	// UnclaimedRuleSplit will split the winnings evenly among every square that was sold
+	UnclaimedRuleSplit UnclaimedRule = "split"
+)
+
+// UnclaimedRules are the valid unclaimed rules
+var UnclaimedRules = []UnclaimedRule{
+	UnclaimedRuleHouse,
+	UnclaimedRuleRollover,
+	UnclaimedRuleSplit,
+}
+
+// IsValid will ensure that it's a valid rule
+func (u UnclaimedRule) IsValid() bool {
+	for _, rule := range UnclaimedRules {
+		if u == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description returns a human friendly description of the rule
+func (u UnclaimedRule) Description() string {
+	switch u {
+	case UnclaimedRuleHouse:
+		return "Return to the house"
+	case UnclaimedRuleRollover:
+		return "Roll over to the next period"
+	case UnclaimedRuleSplit:
+		return "Split among all squares"
+	}
+
+	return string(u)
+}
+
+// PayoutAmountType is how the amount of a GridPayout is interpreted
+type PayoutAmountType string
+
+// Allowed payout amount types
+const (
+	// PayoutAmountTypePercent is a percentage of the pot after the house cut
+	PayoutAmountTypePercent PayoutAmountType = "percent"
+	// PayoutAmountTypeFixed is a fixed amount in cents
+	PayoutAmountTypeFixed PayoutAmountType = "fixed"
+)
+
+// IsValid will ensure that it's a valid amount type
+func (p PayoutAmountType) IsValid() bool {
+	return p == PayoutAmountTypePercent || p == PayoutAmountTypeFixed
+}
+
+// GridPayout is the amount that is paid to the winner of a period
+type GridPayout struct {
+	Period     GridScorePeriod  `json:"period"`
+	AmountType PayoutAmountType `json:"amountType"`
+	Amount     int64            `json:"amount"`
+}
+
+// Payouts returns the payout schedule of the grid in the order of the periods
+func (g *Grid) Payouts(ctx context.Context) ([]*GridPayout, error) {
+	const query = `
+SELECT
+	period, amount_type, amount
+FROM
+	grid_payouts
+WHERE
+	grid_id = $1
+ORDER BY
+	period
+`
+
+	rows, err := g.model.DB.QueryContext(ctx, query, g.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payouts := make([]*GridPayout, 0)
+	for rows.Next() {
+		payout := GridPayout{}
+		if err := rows.Scan(&payout.Period, &payout.AmountType, &payout.Amount); err != nil {
+			return nil, err
+		}
+
+		payouts = append(payouts, &payout)
+	}
+
+	return payouts, nil
+}
+
+// LoadPayouts will load the payout schedule for the grid
+func (g *Grid) LoadPayouts(ctx context.Context) error {
+	payouts, err := g.Payouts(ctx)
+	if err != nil {
+		return err
+	}
+
+	g.payouts = payouts
+	return nil
+}
+
+// MaxPot returns the most the grid can pay out, which is the pot after the house cut once every square has been
+// sold. The settings must be loaded first.
+func (g *Grid) MaxPot() int64 {
+	if g.settings == nil {
+		return 0
+	}
+
+	pot := g.settings.SquarePrice() * int64(g.gridType.Squares())
+	return pot - pot*int64(g.settings.HouseCut())/100
+}
+
+// SetPayouts will replace the payout schedule of the grid. Each period may only appear once, the percentages may
+// not add up to more than 100 and the fixed amounts must fit in the pot. The settings must be loaded first.
+func (g *Grid) SetPayouts(ctx context.Context, payouts []*GridPayout) error {
+	return g.model.inTx(ctx, func(tx *sql.Tx) error {
+		return g.SetPayoutsTx(ctx, tx, payouts, 0)
+	})
+}
+
+// SetPayoutsTx will replace the payout schedule of the grid within the transaction. If version is not 0, the schedule
+// is only replaced if the grid is still at that version, and ErrVersionMismatch is returned if it is not.
+func (g *Grid) SetPayoutsTx(ctx context.Context, tx *sql.Tx, payouts []*GridPayout, version int64) error {
+	if err := ValidatePayouts(payouts, g.MaxPot()); err != nil {
+		return err
+	}
+
+	if err := g.lockVersion(ctx, tx, version); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM grid_payouts WHERE grid_id = $1", g.id); err != nil {
+		return err
+	}
+
+	for _, payout := range payouts {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO grid_payouts (grid_id, period, amount_type, amount) VALUES ($1, $2, $3, $4)", g.id, payout.Period, payout.AmountType, payout.Amount); err != nil {
+			return err
+		}
+	}
+
+	sorted := make([]*GridPayout, len(payouts))
+	copy(sorted, payouts)
+	sortPayouts(sorted)
+	g.payouts = sorted
+
+	return nil
+}
+
+// ValidatePayouts will ensure that a payout schedule is valid. The pot is the most that can be paid out, see
+// MaxPot, and the fixed amounts along with the percentages of it cannot add up to more than the pot.
+func ValidatePayouts(payouts []*GridPayout, pot int64) error {
+	seen := make(map[GridScorePeriod]bool)
+	var percent, fixed int64
+	for _, payout := range payouts {
+		if payout == nil || !payout.Period.IsValid() || !payout.AmountType.IsValid() || payout.Amount < 0 || seen[payout.Period] {
+			return ErrInvalidPayouts
+		}
+
+		seen[payout.Period] = true
+		if payout.AmountType == PayoutAmountTypePercent {
+			percent += payout.Amount
+		} else {
+			fixed += payout.Amount
+		}
+	}
+
+	if percent > 100 || fixed+pot*percent/100 > pot {
+		return ErrInvalidPayouts
+	}
+
+	return nil
+}
+
+func sortPayouts(payouts []*GridPayout) {
+	order := make(map[GridScorePeriod]int)
+	for i, period := range GridScorePeriods {
+		order[period] = i
+	}
+
+	sort.Slice(payouts, func(i, j int) bool {
+		return order[payouts[i].Period] < order[payouts[j].Period]
+	})
+}
+
+// PayoutStatus is the status of the winnings of a single period
+type PayoutStatus string
+
+// Payout statuses
+const (
+	// PayoutStatusPending means that no score has been recorded for the period
+	PayoutStatusPending PayoutStatus = "pending"
+	// PayoutStatusWon means that a claimed square won the period
+	PayoutStatusWon PayoutStatus = "won"
+	// PayoutStatusUnclaimed means that the winning square was not sold, e.g. it was never claimed or only held
+	PayoutStatusUnclaimed PayoutStatus = "unclaimed"
+)
+
+// PeriodWinnings is the result of a single period of the payout schedule
+type PeriodWinnings struct {
+	Period       GridScorePeriod `json:"period"`
+	Status       PayoutStatus    `json:"status"`
+	Amount       int64           `json:"amount"`
+	RolledOverIn int64           `json:"rolledOverIn"`
+	SquareID     int             `json:"squareId,omitempty"`
+	UserID       int64           `json:"userId,omitempty"`
+	Claimant     string          `json:"claimant,omitempty"`
+}
+
+// ClaimantWinnings is the total that is owed to a single claimant
+type ClaimantWinnings struct {
+	UserID    int64  `json:"userId"`
+	Claimant  string `json:"claimant"`
+	SquareIDs []int  `json:"squareIds"`
+	Amount    int64  `json:"amount"`
+}
+
+// GridWinnings is how much is owed to each winning square and claimant of a grid. All amounts are in cents.
+type GridWinnings struct {
+	SquarePrice int64               `json:"squarePrice"`
+	SquaresSold int                 `json:"squaresSold"`
+	Pot         int64               `json:"pot"`
+	HouseCut    int64               `json:"houseCut"`
+	House       int64               `json:"house"`
+	Periods     []*PeriodWinnings   `json:"periods"`
+	Squares     map[int]int64       `json:"squares"`
+	Claimants   []*ClaimantWinnings `json:"claimants"`
+	Computed    time.Time           `json:"computed"`
+}
+
+// Winnings will compute how much is owed to each winning square and claimant based on the settings, scores and
+// payouts of the grid, which must all be loaded first. The squares are the squares of the sheet the grid uses.
+// Percentage payouts are taken from the pot after the house cut. Fixed payouts are scaled down to fit in what is left
+// of it when not enough squares were sold to pay them in full, and whatever is left of the pot after the payout
+// schedule goes to the house. Periods without a score are pending.
+func (g *Grid) Winnings(squares map[int]*PoolSquare) *GridWinnings {
+	settings := g.settings
+	if settings == nil {
+		settings = &GridSettings{}
+	}
+
+	w := &GridWinnings{
+		SquarePrice: settings.SquarePrice(),
+		Periods:     make([]*PeriodWinnings, 0, len(g.payouts)),
+		Squares:     make(map[int]int64),
+		Claimants:   make([]*ClaimantWinnings, 0),
+		Computed:    time.Now().In(locationNewYork),
+	}
+
+	sold := make([]*PoolSquare, 0, len(squares))
+	for _, square := range squares {
+		if square.State.IsSold() {
+			sold = append(sold, square)
+		}
+	}
+	sort.Slice(sold, func(i, j int) bool {
+		return sold[i].SquareID < sold[j].SquareID
+	})
+
+	w.SquaresSold = len(sold)
+	w.Pot = w.SquarePrice * int64(w.SquaresSold)
+	w.HouseCut = w.Pot * int64(settings.HouseCut()) / 100
+	net := w.Pot - w.HouseCut
+
+	var percent, fixed int64
+	for _, payout := range g.payouts {
+		pw := &PeriodWinnings{
+			Period: payout.Period,
+			Amount: payout.Amount,
+		}
+
+		if payout.AmountType == PayoutAmountTypePercent {
+			pw.Amount = net * payout.Amount / 100
+			percent += pw.Amount
+		} else {
+			fixed += pw.Amount
+		}
+
+		w.Periods = append(w.Periods, pw)
+	}
+
+	// the fixed amounts are validated against the pot with every square sold, so when fewer squares were sold they
+	// are scaled down to what is left of the pot that was actually collected
+	available := net - percent
+	if available < 0 {
+		available = 0
+	}
+
+	if fixed > available {
+		for i, payout := range g.payouts {
+			if payout.AmountType == PayoutAmountTypeFixed {
+				w.Periods[i].Amount = payout.Amount * available / fixed
+			}
+		}
+	}
+
+	var allocated int64
+	for _, pw := range w.Periods {
+		allocated += pw.Amount
+	}
+
+	// anything left over from the pot after the payout schedule goes to the house
+	if remainder := net - allocated; remainder > 0 {
+		w.House += remainder
+	}
+
+	// when unclaimed winnings roll over, nothing after a pending period can be known yet
+	rollover := settings.UnclaimedRule() == UnclaimedRuleRollover
+	var carry int64
+	pending := false
+	claimants := make(map[string]*ClaimantWinnings)
+	for _, pw := range w.Periods {
+		pw.RolledOverIn = carry
+		carry = 0
+		total := pw.Amount + pw.RolledOverIn
+
+		score, ok := g.scores[pw.Period]
+		if !ok || pending {
+			pw.Status = PayoutStatusPending
+			pending = rollover
+			continue
+		}
+
+		squareID, ok := g.SquareIDForScore(score.HomeScore, score.AwayScore)
+		if !ok {
+			pw.Status = PayoutStatusPending
+			pending = rollover
+			continue
+		}
+
+		pw.SquareID = squareID
+		square := squares[squareID]
+		if square != nil && square.State.IsSold() {
+			pw.Status = PayoutStatusWon
+			pw.UserID = square.UserID()
+			pw.Claimant = square.Claimant()
+			w.Squares[squareID] += total
+			addClaimantWinnings(claimants, square, total)
+			continue
+		}
+
+		pw.Status = PayoutStatusUnclaimed
+		switch settings.UnclaimedRule() {
+		case UnclaimedRuleRollover:
+			carry = total
+		case UnclaimedRuleSplit:
+			if len(sold) == 0 {
+				w.House += total
+				break
+			}
+
+			share := total / int64(len(sold))
+			for _, square := range sold {
+				w.Squares[square.SquareID] += share
+				addClaimantWinnings(claimants, square, share)
+			}
+			w.House += total - share*int64(len(sold))
+		default:
+			w.House += total
+		}
+	}
+
+	// a rollover from the last period has nowhere else to go
+	w.House += carry + w.HouseCut
+
+	for _, cw := range claimants {
+		if cw.Amount > 0 {
+			w.Claimants = append(w.Claimants, cw)
+		}
+	}
+	sort.Slice(w.Claimants, func(i, j int) bool {
+		if w.Claimants[i].Amount == w.Claimants[j].Amount {
+			return w.Claimants[i].Claimant < w.Claimants[j].Claimant
+		}
+
+		return w.Claimants[i].Amount > w.Claimants[j].Amount
+	})
+
+	for squareID, amount := range w.Squares {
+		if amount == 0 {
+			delete(w.Squares, squareID)
+		}
+	}
+
+	return w
+}
+
+func addClaimantWinnings(claimants map[string]*ClaimantWinnings, square *PoolSquare, amount int64) {
+	// claimants are grouped by user and then the name they used, since an admin may rename a square
+	key := fmt.Sprintf("%d:%s", square.UserID(), square.Claimant())
+	cw, ok := claimants[key]
+	if !ok {
+		cw = &ClaimantWinnings{
+			UserID:    square.UserID(),
+			Claimant:  square.Claimant(),
+			SquareIDs: make([]int, 0),
+		}
+		claimants[key] = cw
+	}
+
+	cw.Amount += amount
+	for _, squareID := range cw.SquareIDs {
+		if squareID == square.SquareID {
+			return
+		}
+	}
+
+	cw.SquareIDs = append(cw.SquareIDs, square.SquareID)
+	sort.Ints(cw.SquareIDs)
+}
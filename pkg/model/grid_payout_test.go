@@ -0,0 +1,328 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestValidatePayouts(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	g.Expect(ValidatePayouts(nil, 0)).Should(gomega.Succeed())
+	g.Expect(ValidatePayouts([]*GridPayout{
+		{Period: GridScorePeriodHalf, AmountType: PayoutAmountTypePercent, Amount: 50},
+		{Period: GridScorePeriodFinal, AmountType: PayoutAmountTypePercent, Amount: 50},
+	}, 0)).Should(gomega.Succeed())
+	g.Expect(ValidatePayouts([]*GridPayout{
+		{Period: GridScorePeriodHalf, AmountType: PayoutAmountTypePercent, Amount: 40},
+		{Period: GridScorePeriodFinal, AmountType: PayoutAmountTypePercent, Amount: 50},
+		{Period: GridScorePeriodOT, AmountType: PayoutAmountTypeFixed, Amount: 5000},
+	}, 50000)).Should(gomega.Succeed())
+
+	// the fixed amounts have to fit in what is left of the pot
+	g.Expect(ValidatePayouts([]*GridPayout{
+		{Period: GridScorePeriodHalf, AmountType: PayoutAmountTypePercent, Amount: 40},
+		{Period: GridScorePeriodFinal, AmountType: PayoutAmountTypePercent, Amount: 50},
+		{Period: GridScorePeriodOT, AmountType: PayoutAmountTypeFixed, Amount: 5001},
+	}, 50000)).Should(gomega.Equal(ErrInvalidPayouts))
+	g.Expect(ValidatePayouts([]*GridPayout{
+		{Period: GridScorePeriodFinal, AmountType: PayoutAmountTypeFixed, Amount: 10},
+	}, 0)).Should(gomega.Equal(ErrInvalidPayouts))
+
+	g.Expect(ValidatePayouts([]*GridPayout{
+		{Period: GridScorePeriodHalf, AmountType: PayoutAmountTypePercent, Amount: 50},
+		{Period: GridScorePeriodFinal, AmountType: PayoutAmountTypePercent, Amount: 51},
+	}, 50000)).Should(gomega.Equal(ErrInvalidPayouts))
+
+	g.Expect(ValidatePayouts([]*GridPayout{
+		{Period: GridScorePeriodHalf, AmountType: PayoutAmountTypePercent, Amount: 10},
+		{Period: GridScorePeriodHalf, AmountType: PayoutAmountTypeFixed, Amount: 10},
+	}, 50000)).Should(gomega.Equal(ErrInvalidPayouts))
+
+	g.Expect(ValidatePayouts([]*GridPayout{{Period: "q4", AmountType: PayoutAmountTypeFixed, Amount: 10}}, 50000)).Should(gomega.Equal(ErrInvalidPayouts))
+	g.Expect(ValidatePayouts([]*GridPayout{{Period: GridScorePeriodQ1, AmountType: "other", Amount: 10}}, 50000)).Should(gomega.Equal(ErrInvalidPayouts))
+	g.Expect(ValidatePayouts([]*GridPayout{{Period: GridScorePeriodQ1, AmountType: PayoutAmountTypeFixed, Amount: -1}}, 50000)).Should(gomega.Equal(ErrInvalidPayouts))
+	g.Expect(ValidatePayouts([]*GridPayout{nil}, 50000)).Should(gomega.Equal(ErrInvalidPayouts))
+}
+
+func TestUnclaimedRule(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	for _, rule := range UnclaimedRules {
+		g.Expect(rule.IsValid()).Should(gomega.BeTrue())
+	}
+
+	g.Expect(UnclaimedRule("keep").IsValid()).Should(gomega.BeFalse())
+	g.Expect((&GridSettings{}).UnclaimedRule()).Should(gomega.Equal(UnclaimedRuleHouse))
+}
+
+func winningsGrid(rule UnclaimedRule) (*Grid, map[int]*PoolSquare) {
+	grid := &Grid{
+		gridType:    GridTypeStd100,
+		homeNumbers: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		awayNumbers: []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+		settings:    &GridSettings{},
+		scores: map[GridScorePeriod]*GridScore{
+			GridScorePeriodQ1:    {Period: GridScorePeriodQ1, HomeScore: 7, AwayScore: 0},      // 98
+			GridScorePeriodHalf:  {Period: GridScorePeriodHalf, HomeScore: 10, AwayScore: 14},  // 51
+			GridScorePeriodQ3:    {Period: GridScorePeriodQ3, HomeScore: 17, AwayScore: 14},    // 58
+			GridScorePeriodFinal: {Period: GridScorePeriodFinal, HomeScore: 20, AwayScore: 21}, // 81
+		},
+		payouts: []*GridPayout{
+			{Period: GridScorePeriodQ1, AmountType: PayoutAmountTypePercent, Amount: 20},
+			{Period: GridScorePeriodHalf, AmountType: PayoutAmountTypePercent, Amount: 20},
+			{Period: GridScorePeriodQ3, AmountType: PayoutAmountTypePercent, Amount: 20},
+			{Period: GridScorePeriodFinal, AmountType: PayoutAmountTypeFixed, Amount: 3000},
+		},
+	}
+
+	grid.settings.SetSquarePrice(1000)
+	grid.settings.SetHouseCut(10)
+	grid.settings.SetUnclaimedRule(rule)
+
+	squares := make(map[int]*PoolSquare)
+	for i := 1; i <= 100; i++ {
+		squares[i] = &PoolSquare{SquareID: i, State: PoolSquareStateUnclaimed}
+	}
+
+	claim := func(squareID int, userID int64, claimant string) {
+		squares[squareID].State = PoolSquareStateClaimed
+		squares[squareID].SetUserID(userID)
+		squares[squareID].SetClaimant(claimant)
+	}
+
+	claim(98, 1, "Alice")
+	claim(58, 1, "Alice")
+	claim(81, 2, "Bob")
+	for i := 1; i <= 7; i++ {
+		claim(i, 3, "Carol")
+	}
+
+	return grid, squares
+}
+
+func TestWinningsHouse(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	grid, squares := winningsGrid(UnclaimedRuleHouse)
+	w := grid.Winnings(squares)
+	g.Expect(w.SquaresSold).Should(gomega.Equal(10))
+	g.Expect(w.Pot).Should(gomega.Equal(int64(10000)))
+	g.Expect(w.HouseCut).Should(gomega.Equal(int64(1000)))
+	g.Expect(w.House).Should(gomega.Equal(int64(3400)))
+
+	g.Expect(len(w.Periods)).Should(gomega.Equal(4))
+	g.Expect(w.Periods[0].Status).Should(gomega.Equal(PayoutStatusWon))
+	g.Expect(w.Periods[0].SquareID).Should(gomega.Equal(98))
+	g.Expect(w.Periods[0].Amount).Should(gomega.Equal(int64(1800)))
+	g.Expect(w.Periods[1].Status).Should(gomega.Equal(PayoutStatusUnclaimed))
+	g.Expect(w.Periods[1].SquareID).Should(gomega.Equal(51))
+	g.Expect(w.Periods[3].Amount).Should(gomega.Equal(int64(3000)))
+
+	g.Expect(w.Squares).Should(gomega.Equal(map[int]int64{98: 1800, 58: 1800, 81: 3000}))
+	g.Expect(len(w.Claimants)).Should(gomega.Equal(2))
+	g.Expect(w.Claimants[0].Claimant).Should(gomega.Equal("Alice"))
+	g.Expect(w.Claimants[0].Amount).Should(gomega.Equal(int64(3600)))
+	g.Expect(w.Claimants[0].SquareIDs).Should(gomega.Equal([]int{58, 98}))
+	g.Expect(w.Claimants[1].Claimant).Should(gomega.Equal("Bob"))
+	g.Expect(w.Claimants[1].Amount).Should(gomega.Equal(int64(3000)))
+}
+
+func TestWinningsRollover(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	grid, squares := winningsGrid(UnclaimedRuleRollover)
+	w := grid.Winnings(squares)
+	g.Expect(w.House).Should(gomega.Equal(int64(1600)))
+	g.Expect(w.Periods[2].RolledOverIn).Should(gomega.Equal(int64(1800)))
+	g.Expect(w.Squares).Should(gomega.Equal(map[int]int64{98: 1800, 58: 3600, 81: 3000}))
+	g.Expect(w.Claimants[0].Amount).Should(gomega.Equal(int64(5400)))
+
+	// winnings that would roll into a period without a score cannot be known yet
+	delete(grid.scores, GridScorePeriodQ3)
+	w = grid.Winnings(squares)
+	g.Expect(w.Periods[2].Status).Should(gomega.Equal(PayoutStatusPending))
+	g.Expect(w.Periods[2].RolledOverIn).Should(gomega.Equal(int64(1800)))
+	g.Expect(w.Periods[3].Status).Should(gomega.Equal(PayoutStatusPending))
+	g.Expect(w.Squares).Should(gomega.Equal(map[int]int64{98: 1800}))
+	g.Expect(w.House).Should(gomega.Equal(int64(1600)))
+
+	// the last period has nowhere to roll over to
+	grid, squares = winningsGrid(UnclaimedRuleRollover)
+	squares[81].State = PoolSquareStateUnclaimed
+	w = grid.Winnings(squares)
+	g.Expect(w.Periods[3].Status).Should(gomega.Equal(PayoutStatusUnclaimed))
+	g.Expect(w.House).Should(gomega.Equal(int64(4140)))
+}
+
+func TestWinningsSplit(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	grid, squares := winningsGrid(UnclaimedRuleSplit)
+	w := grid.Winnings(squares)
+	g.Expect(w.House).Should(gomega.Equal(int64(1600)))
+	g.Expect(w.Squares[98]).Should(gomega.Equal(int64(1980)))
+	g.Expect(w.Squares[1]).Should(gomega.Equal(int64(180)))
+
+	amounts := make(map[string]int64)
+	for _, cw := range w.Claimants {
+		amounts[cw.Claimant] = cw.Amount
+	}
+
+	g.Expect(amounts).Should(gomega.Equal(map[string]int64{"Alice": 3960, "Bob": 3180, "Carol": 1260}))
+}
+
+func TestWinningsFixedScaled(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	grid, squares := winningsGrid(UnclaimedRuleHouse)
+	for i := 1; i <= 7; i++ {
+		squares[i].State = PoolSquareStateUnclaimed
+	}
+
+	// 3 squares were sold, so after the percentages only 1080 of the pot is left for the fixed 3000
+	w := grid.Winnings(squares)
+	g.Expect(w.SquaresSold).Should(gomega.Equal(3))
+	g.Expect(w.Pot).Should(gomega.Equal(int64(3000)))
+	g.Expect(w.Periods[0].Amount).Should(gomega.Equal(int64(540)))
+	g.Expect(w.Periods[3].Amount).Should(gomega.Equal(int64(1080)))
+	g.Expect(w.Squares).Should(gomega.Equal(map[int]int64{98: 540, 58: 540, 81: 1080}))
+	g.Expect(w.House).Should(gomega.Equal(int64(840)))
+
+	// nothing more than the pot is ever paid out
+	var paid int64
+	for _, amount := range w.Squares {
+		paid += amount
+	}
+	g.Expect(paid + w.House).Should(gomega.Equal(w.Pot))
+}
+
+func TestWinningsHeld(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	grid, squares := winningsGrid(UnclaimedRuleSplit)
+	want := grid.Winnings(squares)
+
+	// a held square is not sold, even if it is on the winning numbers of a period
+	hold := func(squareID int) {
+		squares[squareID].State = PoolSquareStateHeld
+		squares[squareID].SetUserID(4)
+		squares[squareID].SetClaimant("Dave")
+	}
+
+	hold(51)
+	hold(60)
+
+	w := grid.Winnings(squares)
+	g.Expect(w.SquaresSold).Should(gomega.Equal(10))
+	g.Expect(w.Pot).Should(gomega.Equal(want.Pot))
+	g.Expect(w.Periods[1].SquareID).Should(gomega.Equal(51))
+	g.Expect(w.Periods[1].Status).Should(gomega.Equal(PayoutStatusUnclaimed))
+	g.Expect(w.Periods[1].UserID).Should(gomega.BeZero())
+	g.Expect(w.House).Should(gomega.Equal(want.House))
+	g.Expect(w.Squares).Should(gomega.Equal(want.Squares))
+	g.Expect(w.Squares).ShouldNot(gomega.HaveKey(51))
+
+	for _, cw := range w.Claimants {
+		g.Expect(cw.UserID).ShouldNot(gomega.Equal(int64(4)))
+	}
+}
+
+func TestWinningsPending(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	grid, squares := winningsGrid(UnclaimedRuleHouse)
+	delete(grid.scores, GridScorePeriodQ1)
+	w := grid.Winnings(squares)
+	g.Expect(w.Periods[0].Status).Should(gomega.Equal(PayoutStatusPending))
+	g.Expect(w.Periods[0].SquareID).Should(gomega.Equal(0))
+	g.Expect(w.Periods[3].Status).Should(gomega.Equal(PayoutStatusWon))
+	g.Expect(w.Squares).Should(gomega.Equal(map[int]int64{58: 1800, 81: 3000}))
+
+	grid.homeNumbers = nil
+	w = grid.Winnings(squares)
+	g.Expect(w.Squares).Should(gomega.BeEmpty())
+	g.Expect(w.Claimants).Should(gomega.BeEmpty())
+}
+
+func TestGridPayouts(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	pool := getPool(m)
+	grid, err := pool.DefaultGrid(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(grid.LoadSettings(ctx)).Should(gomega.Succeed())
+
+	g.Expect(grid.SetPayouts(ctx, []*GridPayout{
+		{Period: GridScorePeriodFinal, AmountType: PayoutAmountTypeFixed, Amount: 10000},
+	})).Should(gomega.Equal(ErrInvalidPayouts))
+
+	grid.Settings().SetSquarePrice(500)
+	g.Expect(grid.Save(ctx)).Should(gomega.Succeed())
+
+	g.Expect(grid.SetPayouts(ctx, []*GridPayout{
+		{Period: GridScorePeriodFinal, AmountType: PayoutAmountTypePercent, Amount: 50},
+		{Period: GridScorePeriodHalf, AmountType: PayoutAmountTypePercent, Amount: 25},
+	})).Should(gomega.Succeed())
+	g.Expect(grid.payouts[0].Period).Should(gomega.Equal(GridScorePeriodHalf))
+
+	payouts, err := grid.Payouts(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(payouts)).Should(gomega.Equal(2))
+	g.Expect(payouts[0].Period).Should(gomega.Equal(GridScorePeriodHalf))
+	g.Expect(payouts[1].Amount).Should(gomega.Equal(int64(50)))
+
+	g.Expect(grid.SetPayouts(ctx, []*GridPayout{
+		{Period: GridScorePeriodFinal, AmountType: PayoutAmountTypeFixed, Amount: 10000},
+	})).Should(gomega.Succeed())
+	g.Expect(grid.LoadPayouts(ctx)).Should(gomega.Succeed())
+	g.Expect(len(grid.payouts)).Should(gomega.Equal(1))
+	g.Expect(grid.payouts[0].AmountType).Should(gomega.Equal(PayoutAmountTypeFixed))
+
+	grid.Settings().SetSquarePrice(500)
+	grid.Settings().SetHouseCut(5)
+	grid.Settings().SetUnclaimedRule(UnclaimedRuleSplit)
+	g.Expect(grid.Save(ctx)).Should(gomega.Succeed())
+
+	grid, err = pool.GridByID(ctx, grid.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(grid.LoadSettings(ctx)).Should(gomega.Succeed())
+	g.Expect(grid.Settings().SquarePrice()).Should(gomega.Equal(int64(500)))
+	g.Expect(grid.Settings().HouseCut()).Should(gomega.Equal(5))
+	g.Expect(grid.Settings().UnclaimedRule()).Should(gomega.Equal(UnclaimedRuleSplit))
+
+	// the schedule is only replaced if the grid is still at the version, and saving it changes the version
+	version := grid.Version()
+	final := []*GridPayout{{Period: GridScorePeriodFinal, AmountType: PayoutAmountTypePercent, Amount: 100}}
+	setPayouts := func(version int64) error {
+		return m.inTx(ctx, func(tx *sql.Tx) error {
+			return grid.SetPayoutsTx(ctx, tx, final, version)
+		})
+	}
+
+	g.Expect(setPayouts(version)).Should(gomega.Succeed())
+	g.Expect(setPayouts(version)).Should(gomega.Equal(ErrVersionMismatch))
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ScoreMax is the highest score that can be recorded for a team
+const ScoreMax = 999
+
+// ErrInvalidScorePeriod is an error when a string has been typecast to a period that does not exist
+var ErrInvalidScorePeriod = errors.New("error: invalid score period")
+
+// GridScorePeriod is a period of a game for which a score can be recorded
+type GridScorePeriod string
+
+// Allowed score periods. Final is the score at the end of regulation and OT is
+// only recorded when the game goes to overtime.
+const (
+	GridScorePeriodQ1    GridScorePeriod = "q1"
+	GridScorePeriodHalf  GridScorePeriod = "half"
+	GridScorePeriodQ3    GridScorePeriod = "q3"
+	GridScorePeriodFinal GridScorePeriod = "final"
+	GridScorePeriodOT    GridScorePeriod = "ot"
+)
+
+// GridScorePeriods are the valid periods in the order in which they occur
+var GridScorePeriods = []GridScorePeriod{
+	GridScorePeriodQ1,
+	GridScorePeriodHalf,
+	GridScorePeriodQ3,
+	GridScorePeriodFinal,
+	GridScorePeriodOT,
+}
+
+// IsValid will ensure that it's a valid period
+func (p GridScorePeriod) IsValid() bool {
+	for _, period := range GridScorePeriods {
+		if p == period {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description returns a human friendly description of the period
+func (p GridScorePeriod) Description() string {
+	switch p {
+	case GridScorePeriodQ1:
+		return "1st Quarter"
+	case GridScorePeriodHalf:
+		return "Halftime"
+	case GridScorePeriodQ3:
+		return "3rd Quarter"
+	case GridScorePeriodFinal:
+		return "Final"
+	case GridScorePeriodOT:
+		return "Overtime"
+	}
+
+	return string(p)
+}
+
+// GridScore is the score of a game at the end of a period
+type GridScore struct {
+	model     *Model
+	GridID    int64           `json:"-"`
+	Period    GridScorePeriod `json:"period"`
+	HomeScore int             `json:"homeScore"`
+	AwayScore int             `json:"awayScore"`
+	Created   time.Time       `json:"created"`
+	Modified  time.Time       `json:"modified"`
+}
+
+const gridScoreColumns = `grid_id, period, home_score, away_score, created, modified`
+
+// SetScore will record the score for the period. If a score was already recorded for the period, it will be replaced.
+func (g *Grid) SetScore(ctx context.Context, period GridScorePeriod, homeScore, awayScore int) (*GridScore, error) {
+	return g.setScore(ctx, g.model.DB, period, homeScore, awayScore)
+}
+
+// SetScoreTx will record the score for the period within the transaction
+func (g *Grid) SetScoreTx(ctx context.Context, tx *sql.Tx, period GridScorePeriod, homeScore, awayScore int) (*GridScore, error) {
+	return g.setScore(ctx, tx, period, homeScore, awayScore)
+}
+
+func (g *Grid) setScore(ctx context.Context, q Queryable, period GridScorePeriod, homeScore, awayScore int) (*GridScore, error) {
+	if !period.IsValid() {
+		return nil, ErrInvalidScorePeriod
+	}
+
+	const query = `
+INSERT INTO grid_scores
+	(grid_id, period, home_score, away_score)
+VALUES
+	($1, $2, $3, $4)
+ON CONFLICT (grid_id, period) DO UPDATE
+SET
+	home_score = excluded.home_score,
+	away_score = excluded.away_score,
+	modified = (NOW() AT TIME ZONE 'UTC')
+RETURNING ` + gridScoreColumns
+
+	row := q.QueryRowContext(ctx, query, g.id, period, homeScore, awayScore)
+	score, err := g.model.gridScoreByRow(row.Scan)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.scores != nil {
+		g.scores[period] = score
+	}
+
+	return score, nil
+}
+
+// DeleteScore will remove the score for the period
+func (g *Grid) DeleteScore(ctx context.Context, period GridScorePeriod) error {
+	return g.deleteScore(ctx, g.model.DB, period)
+}
+
+// DeleteScoreTx will remove the score for the period within the transaction
+func (g *Grid) DeleteScoreTx(ctx context.Context, tx *sql.Tx, period GridScorePeriod) error {
+	return g.deleteScore(ctx, tx, period)
+}
+
+func (g *Grid) deleteScore(ctx context.Context, q Queryable, period GridScorePeriod) error {
+	if _, err := q.ExecContext(ctx, "DELETE FROM grid_scores WHERE grid_id = $1 AND period = $2", g.id, period); err != nil {
+		return err
+	}
+
+	if g.scores != nil {
+		delete(g.scores, period)
+	}
+
+	return nil
+}
+
+// Scores returns a map of periods to GridScore objects, or an error
+func (g *Grid) Scores(ctx context.Context) (map[GridScorePeriod]*GridScore, error) {
+	const query = `
+SELECT ` + gridScoreColumns + `
+FROM
+	grid_scores
+WHERE
+	grid_id = $1
+`
+
+	rows, err := g.model.DB.QueryContext(ctx, query, g.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := make(map[GridScorePeriod]*GridScore)
+	for rows.Next() {
+		score, err := g.model.gridScoreByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		scores[score.Period] = score
+	}
+
+	return scores, nil
+}
+
+// LoadScores will load the scores for the grid
+func (g *Grid) LoadScores(ctx context.Context) error {
+	scores, err := g.Scores(ctx)
+	if err != nil {
+		return err
+	}
+
+	g.scores = scores
+	return nil
+}
+
+// Winners returns the winning square ID for each period that has a score. The scores
+// must be loaded with LoadScores() first. An empty map is returned if the numbers have not been drawn.
+func (g *Grid) Winners() map[GridScorePeriod]int {
+	winners := make(map[GridScorePeriod]int)
+	for period, score := range g.scores {
+		if squareID, ok := g.SquareIDForScore(score.HomeScore, score.AwayScore); ok {
+			winners[period] = squareID
+		}
+	}
+
+	return winners
+}
+
+// SquareIDForScore returns the square that wins for the given score. The home team's numbers
+// run across the columns and the away team's numbers run down the rows. Square IDs start at 1 in
+// the top-left corner and are counted left-to-right, top-to-bottom. On a 25 square grid, each row and
+// column covers two numbers. The second return value is false if the numbers have not been drawn.
+func (g *Grid) SquareIDForScore(homeScore, awayScore int) (int, bool) {
+	col := indexOfDigit(g.homeNumbers, homeScore%10)
+	row := indexOfDigit(g.awayNumbers, awayScore%10)
+	if col < 0 || row < 0 {
+		return 0, false
+	}
+
+	size := 10
+	if g.gridType == GridTypeStd25 {
+		size = 5
+	}
+
+	perSquare := 10 / size
+	return (row/perSquare)*size + col/perSquare + 1, true
+}
+
+// SquareNumbers returns the home and away numbers that belong to the square. It is the inverse of SquareIDForScore.
+// Each slice has a single number on a 100 square grid and two numbers on a 25 square grid. Both are nil if the
+// numbers have not been drawn.
+func (g *Grid) SquareNumbers(squareID int) ([]int, []int) {
+	if len(g.homeNumbers) != 10 || len(g.awayNumbers) != 10 {
+		return nil, nil
+	}
+
+	size := 10
+	if g.gridType == GridTypeStd25 {
+		size = 5
+	}
+
+	if squareID < 1 || squareID > size*size {
+		return nil, nil
+	}
+
+	perSquare := 10 / size
+	row := (squareID - 1) / size
+	col := (squareID - 1) % size
+
+	return g.homeNumbers[col*perSquare : (col+1)*perSquare], g.awayNumbers[row*perSquare : (row+1)*perSquare]
+}
+
+func indexOfDigit(nums []int, digit int) int {
+	for i, n := range nums {
+		if n == digit {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (m *Model) gridScoreByRow(scan scanFunc) (*GridScore, error) {
+	gs := GridScore{}
+	if err := scan(&gs.GridID, &gs.Period, &gs.HomeScore, &gs.AwayScore, &gs.Created, &gs.Modified); err != nil {
+		return nil, err
+	}
+
+	gs.model = m
+	gs.Created = gs.Created.In(locationNewYork)
+	gs.Modified = gs.Modified.In(locationNewYork)
+	return &gs, nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestGridScorePeriod(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	for _, period := range GridScorePeriods {
+		g.Expect(period.IsValid()).Should(gomega.BeTrue())
+		g.Expect(period.Description()).ShouldNot(gomega.Equal(string(period)))
+	}
+
+	g.Expect(GridScorePeriod("q4").IsValid()).Should(gomega.BeFalse())
+	g.Expect(GridScorePeriod("q4").Description()).Should(gomega.Equal("q4"))
+}
+
+func TestSquareIDForScore(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	grid := &Grid{gridType: GridTypeStd100}
+	_, ok := grid.SquareIDForScore(7, 3)
+	g.Expect(ok).Should(gomega.BeFalse())
+
+	grid.homeNumbers = []int{3, 1, 4, 0, 5, 9, 2, 6, 8, 7}
+	grid.awayNumbers = []int{8, 6, 7, 5, 3, 0, 9, 2, 4, 1}
+
+	squareID, ok := grid.SquareIDForScore(17, 13)
+	g.Expect(ok).Should(gomega.BeTrue())
+	g.Expect(squareID).Should(gomega.Equal(50)) // row 4, column 9
+
+	squareID, _ = grid.SquareIDForScore(3, 8)
+	g.Expect(squareID).Should(gomega.Equal(1))
+
+	squareID, _ = grid.SquareIDForScore(0, 0)
+	g.Expect(squareID).Should(gomega.Equal(54))
+
+	grid.gridType = GridTypeStd25
+	squareID, _ = grid.SquareIDForScore(17, 13)
+	g.Expect(squareID).Should(gomega.Equal(15)) // row 2, column 4
+
+	squareID, _ = grid.SquareIDForScore(31, 26)
+	g.Expect(squareID).Should(gomega.Equal(1))
+
+	squareID, _ = grid.SquareIDForScore(7, 21)
+	g.Expect(squareID).Should(gomega.Equal(25))
+}
+
+func TestSquareNumbers(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	grid := &Grid{gridType: GridTypeStd100}
+	home, away := grid.SquareNumbers(1)
+	g.Expect(home).Should(gomega.BeNil())
+	g.Expect(away).Should(gomega.BeNil())
+
+	grid.homeNumbers = []int{3, 1, 4, 0, 5, 9, 2, 6, 8, 7}
+	grid.awayNumbers = []int{8, 6, 7, 5, 3, 0, 9, 2, 4, 1}
+
+	home, away = grid.SquareNumbers(50)
+	g.Expect(home).Should(gomega.Equal([]int{7}))
+	g.Expect(away).Should(gomega.Equal([]int{3}))
+
+	home, away = grid.SquareNumbers(101)
+	g.Expect(home).Should(gomega.BeNil())
+	g.Expect(away).Should(gomega.BeNil())
+
+	grid.gridType = GridTypeStd25
+	home, away = grid.SquareNumbers(15)
+	g.Expect(home).Should(gomega.Equal([]int{8, 7}))
+	g.Expect(away).Should(gomega.Equal([]int{3, 0}))
+
+	for squareID := 1; squareID <= 25; squareID++ {
+		home, away := grid.SquareNumbers(squareID)
+		id, ok := grid.SquareIDForScore(home[1], away[0])
+		g.Expect(ok).Should(gomega.BeTrue())
+		g.Expect(id).Should(gomega.Equal(squareID))
+	}
+}
+
+func TestWinners(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	grid := &Grid{gridType: GridTypeStd100}
+	grid.scores = map[GridScorePeriod]*GridScore{
+		GridScorePeriodQ1:   {Period: GridScorePeriodQ1, HomeScore: 7, AwayScore: 0},
+		GridScorePeriodHalf: {Period: GridScorePeriodHalf, HomeScore: 10, AwayScore: 14},
+	}
+	g.Expect(grid.Winners()).Should(gomega.BeEmpty())
+
+	grid.homeNumbers = []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	grid.awayNumbers = []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}
+	g.Expect(grid.Winners()).Should(gomega.Equal(map[GridScorePeriod]int{
+		GridScorePeriodQ1:   98,
+		GridScorePeriodHalf: 51,
+	}))
+}
+
+func TestGridScores(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	pool := getPool(m)
+	grid, err := pool.DefaultGrid(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+
+	_, err = grid.SetScore(ctx, GridScorePeriod("q4"), 1, 2)
+	g.Expect(err).Should(gomega.Equal(ErrInvalidScorePeriod))
+
+	score, err := grid.SetScore(ctx, GridScorePeriodQ1, 7, 3)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(score.HomeScore).Should(gomega.Equal(7))
+	g.Expect(score.AwayScore).Should(gomega.Equal(3))
+
+	_, err = grid.SetScore(ctx, GridScorePeriodQ1, 14, 3)
+	g.Expect(err).Should(gomega.Succeed())
+	_, err = grid.SetScore(ctx, GridScorePeriodHalf, 17, 10)
+	g.Expect(err).Should(gomega.Succeed())
+
+	g.Expect(grid.LoadScores(ctx)).Should(gomega.Succeed())
+	g.Expect(len(grid.scores)).Should(gomega.Equal(2))
+	g.Expect(grid.scores[GridScorePeriodQ1].HomeScore).Should(gomega.Equal(14))
+
+	g.Expect(grid.DeleteScore(ctx, GridScorePeriodQ1)).Should(gomega.Succeed())
+	g.Expect(len(grid.scores)).Should(gomega.Equal(1))
+
+	scores, err := grid.Scores(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(scores)).Should(gomega.Equal(1))
+	g.Expect(scores[GridScorePeriodHalf].AwayScore).Should(gomega.Equal(10))
+}
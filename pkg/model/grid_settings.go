@@ -45,16 +45,22 @@ type GridSettings struct {
 	awayTeamColor1 *string
 	awayTeamColor2 *string
 	notes          *string
+	squarePrice    int64
+	houseCut       int
+	unclaimedRule  UnclaimedRule
 	modified       *time.Time
 }
 
 // gridSettingsJSON is used for custom serialization
 type gridSettingsJSON struct {
-	HomeTeamColor1 string `json:"homeTeamColor1"`
-	HomeTeamColor2 string `json:"homeTeamColor2"`
-	AwayTeamColor1 string `json:"awayTeamColor1"`
-	AwayTeamColor2 string `json:"awayTeamColor2"`
-	Notes          string `json:"notes"`
+	HomeTeamColor1 string        `json:"homeTeamColor1"`
+	HomeTeamColor2 string        `json:"homeTeamColor2"`
+	AwayTeamColor1 string        `json:"awayTeamColor1"`
+	AwayTeamColor2 string        `json:"awayTeamColor2"`
+	Notes          string        `json:"notes"`
+	SquarePrice    int64         `json:"squarePrice"`
+	HouseCut       int           `json:"houseCut"`
+	UnclaimedRule  UnclaimedRule `json:"unclaimedRule"`
 }
 
 // MarshalJSON adds custom JSON marshalling support
@@ -65,6 +71,9 @@ func (g GridSettings) MarshalJSON() ([]byte, error) {
 		AwayTeamColor1: g.AwayTeamColor1(),
 		AwayTeamColor2: g.AwayTeamColor2(),
 		Notes:          g.Notes(),
+		SquarePrice:    g.SquarePrice(),
+		HouseCut:       g.HouseCut(),
+		UnclaimedRule:  g.UnclaimedRule(),
 	})
 }
 
@@ -77,14 +86,20 @@ func (g *GridSettings) Save(ctx context.Context, q Queryable) error {
 			away_team_color_1 = $3,
 			away_team_color_2 = $4,
 			notes = $5,
+			square_price = $6,
+			house_cut = $7,
+			unclaimed_rule = $8,
 			modified = (NOW() AT TIME ZONE 'utc')
-		WHERE grid_id = $6
+		WHERE grid_id = $9
 	`,
 		g.homeTeamColor1,
 		g.homeTeamColor2,
 		g.awayTeamColor1,
 		g.awayTeamColor2,
 		g.notes,
+		g.squarePrice,
+		g.houseCut,
+		g.UnclaimedRule(),
 		g.gridID,
 	)
 
@@ -116,6 +131,50 @@ func (g *GridSettings) Notes() string {
 	return *g.notes
 }
 
+// SquarePrice returns the price of a single square in cents
+func (g *GridSettings) SquarePrice() int64 {
+	return g.squarePrice
+}
+
+// SetSquarePrice is a setter for the price of a single square in cents
+func (g *GridSettings) SetSquarePrice(price int64) {
+	if price < 0 {
+		price = 0
+	}
+
+	g.squarePrice = price
+}
+
+// HouseCut returns the percentage of the pot that is kept by the house (or given to charity)
+func (g *GridSettings) HouseCut() int {
+	return g.houseCut
+}
+
+// SetHouseCut is a setter for the house cut. It will be clamped between 0 and 100.
+func (g *GridSettings) SetHouseCut(percent int) {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+
+	g.houseCut = percent
+}
+
+// UnclaimedRule returns what happens to the winnings of an unclaimed square
+func (g *GridSettings) UnclaimedRule() UnclaimedRule {
+	if g.unclaimedRule == "" {
+		return UnclaimedRuleHouse
+	}
+
+	return g.unclaimedRule
+}
+
+// SetUnclaimedRule is a setter for the unclaimed rule
+func (g *GridSettings) SetUnclaimedRule(rule UnclaimedRule) {
+	g.unclaimedRule = rule
+}
+
 // SetHomeTeamColor1 is a setter for the home team primary color
 func (g *GridSettings) SetHomeTeamColor1(color string) {
 	if color == "" {
@@ -213,6 +213,18 @@ func TestGridDelete(t *testing.T) {
 	g.Expect(err).Should(gomega.Succeed())
 	g.Expect(len(grids)).Should(gomega.Equal(2))
 
+	page, cursor, err := pool.GridsAfter(context.Background(), Cursor{}, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(page)).Should(gomega.Equal(1))
+	g.Expect(page[0].ID()).Should(gomega.Equal(grids[0].ID()))
+	g.Expect(cursor.IsZero()).Should(gomega.BeFalse())
+
+	page, cursor, err = pool.GridsAfter(context.Background(), cursor, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(page)).Should(gomega.Equal(1))
+	g.Expect(page[0].ID()).Should(gomega.Equal(grids[1].ID()))
+	g.Expect(cursor.IsZero()).Should(gomega.BeTrue())
+
 	g.Expect(grids[0].Delete(context.Background())).Should(gomega.Succeed())
 	grids, err = pool.Grids(context.Background(), 0, 10)
 	g.Expect(err).Should(gomega.Succeed())
@@ -249,3 +261,106 @@ func getPool(m *Model) *Pool {
 
 	return pool
 }
+
+func TestGridVersion(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	pool := getPool(m)
+	g.Expect(pool.Version()).Should(gomega.BeNumerically(">", 0))
+
+	grid, err := pool.DefaultGrid(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	version := grid.Version()
+
+	grid.SetLabel("Versioned")
+	g.Expect(grid.Save(ctx)).Should(gomega.Succeed())
+
+	grid, err = pool.GridByID(ctx, grid.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(grid.Version()).Should(gomega.BeNumerically(">", version))
+	version = grid.Version()
+
+	// the annotations are part of the grid
+	annotation, err := grid.AnnotationBySquareID(ctx, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	annotation.Annotation = "Versioned"
+	g.Expect(annotation.Save(ctx)).Should(gomega.Succeed())
+
+	grid, err = pool.GridByID(ctx, grid.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(grid.Version()).Should(gomega.BeNumerically(">", version))
+
+	square, err := pool.SquareBySquareID(1)
+	g.Expect(err).Should(gomega.Succeed())
+	version = square.Version()
+
+	square.SetClaimant("Versioned")
+	square.State = PoolSquareStateClaimed
+	g.Expect(square.Save(ctx, m.DB, true, PoolSquareLog{})).Should(gomega.Succeed())
+
+	square, err = pool.SquareBySquareID(1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.Version()).Should(gomega.BeNumerically(">", version))
+}
+
+func TestSaveExpectedVersion(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	// two saves of a pool loaded at the same version: only the first one is made
+	pool := getPool(m)
+	other, err := m.PoolByID(pool.ID())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool.SetName("First")
+	g.Expect(pool.SaveVersion(ctx, pool.Version())).Should(gomega.Succeed())
+	other.SetName("Second")
+	g.Expect(other.SaveVersion(ctx, other.Version())).Should(gomega.Equal(ErrVersionMismatch))
+
+	pool, err = m.PoolByID(pool.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(pool.Name()).Should(gomega.Equal("First"))
+
+	// a save without a version is always made
+	g.Expect(other.Save(ctx)).Should(gomega.Succeed())
+
+	// the settings are saved after the grid, so they do not change the version that the grid is checked against
+	grid, err := pool.DefaultGrid(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(grid.LoadSettings(ctx)).Should(gomega.Succeed())
+	otherGrid, err := pool.GridByID(ctx, grid.ID())
+	g.Expect(err).Should(gomega.Succeed())
+
+	grid.SetLabel("First")
+	grid.Settings().SetNotes("First")
+	g.Expect(grid.SaveVersion(ctx, grid.Version())).Should(gomega.Succeed())
+	otherGrid.SetLabel("Second")
+	g.Expect(otherGrid.SaveVersion(ctx, otherGrid.Version())).Should(gomega.Equal(ErrVersionMismatch))
+
+	grid, err = pool.GridByID(ctx, grid.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(grid.Label()).Should(gomega.Equal("First"))
+
+	square, err := pool.SquareBySquareID(1)
+	g.Expect(err).Should(gomega.Succeed())
+	otherSquare, err := pool.SquareBySquareID(1)
+	g.Expect(err).Should(gomega.Succeed())
+
+	square.SetClaimant("First")
+	square.State = PoolSquareStateClaimed
+	g.Expect(square.SaveVersion(ctx, m.DB, true, square.Version(), PoolSquareLog{})).Should(gomega.Succeed())
+	otherSquare.SetClaimant("Second")
+	otherSquare.State = PoolSquareStateClaimed
+	g.Expect(otherSquare.SaveVersion(ctx, m.DB, true, otherSquare.Version(), PoolSquareLog{})).Should(gomega.Equal(ErrVersionMismatch))
+
+	square, err = pool.SquareBySquareID(1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.Claimant()).Should(gomega.Equal("First"))
+}
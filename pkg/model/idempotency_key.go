@@ -0,0 +1,210 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// IdempotencyKeyTTL is how long the response to a request with an idempotency key is replayed for
+const IdempotencyKeyTTL = time.Hour * 24
+
+// IdempotencyKeyMaxLength is the maximum length of an idempotency key
+const IdempotencyKeyMaxLength = 255
+
+// idempotencyKeyAbandonedAfter is how long a key can be reserved without a response being saved before it can be
+// reserved again, e.g. because the instance handling the request went away. It is well beyond how long any request
+// is handled for, which is at most about a minute for an event stream, so that a request that is merely slow is not
+// taken for abandoned.
+const idempotencyKeyAbandonedAfter = time.Minute * 10
+
+// ErrIdempotencyKeyInProgress happens when a request is retried before the original request was handled
+var ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being handled")
+
+// ErrIdempotencyKeyMismatch happens when an idempotency key is reused for a different request
+var ErrIdempotencyKeyMismatch = errors.New("this idempotency key was already used for a different request")
+
+// ErrIdempotencyKeyNotReserved happens when the response of a request is saved, or its key released, after the key
+// was given up on as abandoned and reserved by another request
+var ErrIdempotencyKeyNotReserved = errors.New("the idempotency key is no longer reserved for this request")
+
+// IdempotencyKey is a key that a client sent with a request so that the request can safely be retried
+type IdempotencyKey struct {
+	model       *Model
+	userID      int64
+	key         string
+	requestHash []byte
+	statusCode  int
+	headers     map[string][]string
+	response    []byte
+	created     time.Time
+	reservation int64
+}
+
+// ReserveIdempotencyKey will reserve the key for a request of the user and return whether it was reserved. If it was,
+// the request should be handled and its response saved. If the key was already used for the same request, the key is
+// returned with the response to replay. Keys expire after IdempotencyKeyTTL, after which they can be reserved again.
+func (m *Model) ReserveIdempotencyKey(ctx context.Context, userID int64, key string, requestHash []byte) (*IdempotencyKey, bool, error) {
+	const reserveQuery = `
+		INSERT INTO idempotency_keys (user_id, key, request_hash)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, key) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash,
+			    status_code = NULL,
+			    headers = NULL,
+			    response = NULL,
+			    created = (NOW() AT TIME ZONE 'utc'),
+			    reservation = EXCLUDED.reservation
+			WHERE idempotency_keys.created < (NOW() AT TIME ZONE 'utc') - $4 * INTERVAL '1 second'
+			   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created < (NOW() AT TIME ZONE 'utc') - $5 * INTERVAL '1 second')
+		RETURNING ` + idempotencyKeyColumns
+
+	row := m.DB.QueryRowContext(ctx, reserveQuery, userID, key, requestHash, IdempotencyKeyTTL.Seconds(), idempotencyKeyAbandonedAfter.Seconds())
+	k, err := m.idempotencyKeyByRow(row.Scan)
+	if err == nil {
+		return k, true, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	// the key is in use
+	const query = "SELECT " + idempotencyKeyColumns + " FROM idempotency_keys WHERE user_id = $1 AND key = $2"
+	k, err = m.idempotencyKeyByRow(m.DB.QueryRowContext(ctx, query, userID, key).Scan)
+	if err == sql.ErrNoRows {
+		// the request that held it failed and released it in the meantime
+		return nil, false, ErrIdempotencyKeyInProgress
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !bytes.Equal(k.requestHash, requestHash) {
+		return nil, false, ErrIdempotencyKeyMismatch
+	}
+
+	if k.statusCode == 0 {
+		return nil, false, ErrIdempotencyKeyInProgress
+	}
+
+	return k, false, nil
+}
+
+// Response returns the response that was saved for the key, along with the headers that were saved with it
+func (k *IdempotencyKey) Response() (statusCode int, headers map[string][]string, body []byte) {
+	return k.statusCode, k.headers, k.response
+}
+
+// Save will save the response to the request so that it can be replayed. Only the headers that should be replayed are
+// expected to be passed. ErrIdempotencyKeyNotReserved is returned if the key was reserved by another request in the
+// meantime.
+func (k *IdempotencyKey) Save(ctx context.Context, statusCode int, headers map[string][]string, body []byte) error {
+	var headersJSON *string
+	if len(headers) > 0 {
+		b, err := json.Marshal(headers)
+		if err != nil {
+			return err
+		}
+
+		s := string(b)
+		headersJSON = &s
+	}
+
+	const query = `
+		UPDATE idempotency_keys
+		SET status_code = $1,
+		    headers = $2,
+		    response = $3
+		WHERE user_id = $4 AND key = $5 AND reservation = $6`
+	res, err := k.model.DB.ExecContext(ctx, query, statusCode, headersJSON, body, k.userID, k.key, k.reservation)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrIdempotencyKeyNotReserved
+	}
+
+	k.statusCode = statusCode
+	k.headers = headers
+	k.response = body
+	return nil
+}
+
+// Release will give up the key without saving a response, e.g. because the request failed and may be retried. A key
+// that was reserved by another request in the meantime is left alone and ErrIdempotencyKeyNotReserved is returned.
+func (k *IdempotencyKey) Release(ctx context.Context) error {
+	const query = "DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND reservation = $3"
+	res, err := k.model.DB.ExecContext(ctx, query, k.userID, k.key, k.reservation)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrIdempotencyKeyNotReserved
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys will delete the keys that are older than IdempotencyKeyTTL and return how many were
+// deleted
+func (m *Model) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	const query = "DELETE FROM idempotency_keys WHERE created < (NOW() AT TIME ZONE 'utc') - $1 * INTERVAL '1 second'"
+	res, err := m.DB.ExecContext(ctx, query, IdempotencyKeyTTL.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+const idempotencyKeyColumns = `
+	user_id,
+	key,
+	request_hash,
+	COALESCE(status_code, 0),
+	COALESCE(headers, '{}'),
+	response,
+	created,
+	reservation`
+
+func (m *Model) idempotencyKeyByRow(scan scanFunc) (*IdempotencyKey, error) {
+	k := IdempotencyKey{model: m}
+	var headers []byte
+	if err := scan(&k.userID, &k.key, &k.requestHash, &k.statusCode, &headers, &k.response, &k.created, &k.reservation); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(headers, &k.headers); err != nil {
+		return nil, err
+	}
+
+	k.created = k.created.In(locationNewYork)
+	return &k, nil
+}
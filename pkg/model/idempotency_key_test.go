@@ -0,0 +1,102 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	user, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	other, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	key := randString()
+	k, reserved, err := m.ReserveIdempotencyKey(ctx, user.ID, key, []byte("request"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(reserved).Should(gomega.BeTrue())
+
+	_, _, err = m.ReserveIdempotencyKey(ctx, user.ID, key, []byte("request"))
+	g.Expect(err).Should(gomega.Equal(ErrIdempotencyKeyInProgress))
+
+	// keys belong to the user
+	_, reserved, err = m.ReserveIdempotencyKey(ctx, other.ID, key, []byte("request"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(reserved).Should(gomega.BeTrue())
+
+	headers := map[string][]string{
+		"Content-Type": {"application/json"},
+		"Etag":         {`"1"`},
+		"Location":     {"/pool/abc"},
+	}
+	g.Expect(k.Save(ctx, 201, headers, []byte(`{"token":"abc"}`))).Should(gomega.Succeed())
+
+	replay, reserved, err := m.ReserveIdempotencyKey(ctx, user.ID, key, []byte("request"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(reserved).Should(gomega.BeFalse())
+
+	statusCode, replayedHeaders, body := replay.Response()
+	g.Expect(statusCode).Should(gomega.Equal(201))
+	g.Expect(replayedHeaders).Should(gomega.Equal(headers))
+	g.Expect(string(body)).Should(gomega.Equal(`{"token":"abc"}`))
+
+	_, _, err = m.ReserveIdempotencyKey(ctx, user.ID, key, []byte("another request"))
+	g.Expect(err).Should(gomega.Equal(ErrIdempotencyKeyMismatch))
+
+	// a released key can be used again
+	g.Expect(replay.Release(ctx)).Should(gomega.Succeed())
+	_, reserved, err = m.ReserveIdempotencyKey(ctx, user.ID, key, []byte("another request"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(reserved).Should(gomega.BeTrue())
+
+	// a request that is given up on as abandoned no longer owns the key once another request reserves it
+	abandoned, reserved, err := m.ReserveIdempotencyKey(ctx, other.ID, randString(), []byte("request"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(reserved).Should(gomega.BeTrue())
+
+	_, err = m.DB.ExecContext(ctx, "UPDATE idempotency_keys SET created = created - INTERVAL '11 minutes' WHERE user_id = $1 AND key = $2", other.ID, abandoned.key)
+	g.Expect(err).Should(gomega.Succeed())
+
+	retry, reserved, err := m.ReserveIdempotencyKey(ctx, other.ID, abandoned.key, []byte("request"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(reserved).Should(gomega.BeTrue())
+
+	g.Expect(abandoned.Save(ctx, 200, nil, nil)).Should(gomega.Equal(ErrIdempotencyKeyNotReserved))
+	g.Expect(abandoned.Release(ctx)).Should(gomega.Equal(ErrIdempotencyKeyNotReserved))
+
+	_, _, err = m.ReserveIdempotencyKey(ctx, other.ID, abandoned.key, []byte("request"))
+	g.Expect(err).Should(gomega.Equal(ErrIdempotencyKeyInProgress))
+	g.Expect(retry.Save(ctx, 201, nil, nil)).Should(gomega.Succeed())
+
+	_, err = m.DB.ExecContext(ctx, "UPDATE idempotency_keys SET created = created - INTERVAL '25 hours' WHERE user_id = $1", user.ID)
+	g.Expect(err).Should(gomega.Succeed())
+
+	deleted, err := m.DeleteExpiredIdempotencyKeys(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(deleted).Should(gomega.BeNumerically(">=", 1))
+}
@@ -17,7 +17,14 @@ limitations under the License.
 // Package model handles various models
 package model
 
-import "database/sql"
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrVersionMismatch is returned when something is saved with the version it is expected to be at, but it has been
+// changed since
+var ErrVersionMismatch = errors.New("this was changed by someone else since you last loaded it")
 
 // Model is an object that can be used to interact with a database
 type Model struct {
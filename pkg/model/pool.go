@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/lib/pq"
+	"strings"
 	"time"
 	"unicode/utf8"
 
@@ -29,6 +30,16 @@ import (
 	"github.com/synacor/argon2id"
 )
 
+// ErrSquaresPerGrid happens when the squares of a pool are requested without a grid, but each grid of the pool has its
+// own squares
+var ErrSquaresPerGrid = errors.New("each grid of this pool has its own squares")
+
+// ErrSquaresClaimed happens when trying to change whether each grid has its own squares after a square was claimed
+var ErrSquaresClaimed = errors.New("this cannot be changed once a square has been claimed")
+
+// ErrInvalidTimeZone is returned when a pool is given a time zone that does not exist
+var ErrInvalidTimeZone = errors.New("invalid time zone")
+
 // NameMaxLength is the maximum length the pool name may be
 const NameMaxLength = 50
 
@@ -38,19 +49,24 @@ const MaxGridsPerPool = 50
 // Pool is an individual pool board
 // This object uses getters and setters to help guard against user input.
 type Pool struct {
-	model            *Model
-	id               int64
-	token            string
-	userID           int64
-	name             string
-	gridType         GridType
-	passwordHash     string
-	checkID          int
-	archived         bool
-	openAccessOnLock bool
-	locks            time.Time
-	created          time.Time
-	modified         time.Time
+	model             *Model
+	id                int64
+	token             string
+	userID            int64
+	name              string
+	gridType          GridType
+	passwordHash      string
+	checkID           int
+	archived          bool
+	openAccessOnLock  bool
+	squaresPerGrid    bool
+	maxSquaresPerUser int
+	randomAssignment  bool
+	timeZone          string
+	locks             time.Time
+	created           time.Time
+	modified          time.Time
+	version           int64
 
 	squares map[int]*PoolSquare
 }
@@ -77,6 +93,60 @@ func (p *Pool) SetArchived(archived bool) {
 	p.archived = archived
 }
 
+// MaxSquaresPerUser returns the number of squares that each user may claim in the pool. Zero means that there is no
+// limit.
+func (p *Pool) MaxSquaresPerUser() int {
+	return p.maxSquaresPerUser
+}
+
+// SetMaxSquaresPerUser sets the number of squares that each user may claim in the pool. Zero means that there is no
+// limit.
+func (p *Pool) SetMaxSquaresPerUser(maxSquaresPerUser int) {
+	p.maxSquaresPerUser = maxSquaresPerUser
+}
+
+// RandomAssignment returns whether squares are assigned to members at random instead of being picked by them
+func (p *Pool) RandomAssignment() bool {
+	return p.randomAssignment
+}
+
+// SetRandomAssignment sets whether squares are assigned to members at random instead of being picked by them
+func (p *Pool) SetRandomAssignment(randomAssignment bool) {
+	p.randomAssignment = randomAssignment
+}
+
+// TimeZone returns the name of the time zone that the dates of the pool are shown in
+func (p *Pool) TimeZone() string {
+	return p.timeZone
+}
+
+// SetTimeZone sets the time zone that the dates of the pool are shown in. The name must be a zone of the IANA Time Zone
+// database, such as "America/Chicago".
+func (p *Pool) SetTimeZone(name string) error {
+	if _, err := time.LoadLocation(name); err != nil || name == "" || name == "Local" {
+		return ErrInvalidTimeZone
+	}
+
+	p.timeZone = name
+	return nil
+}
+
+// Location returns the time zone that the dates of the pool are shown in
+func (p *Pool) Location() *time.Location {
+	loc, err := time.LoadLocation(p.timeZone)
+	if err != nil || p.timeZone == "" {
+		return locationNewYork
+	}
+
+	return loc
+}
+
+// SquaresPerGrid returns whether each grid of the pool has its own square sheet. By default, every grid is played
+// with the same squares.
+func (p *Pool) SquaresPerGrid() bool {
+	return p.squaresPerGrid
+}
+
 // CheckID will return the current check ID.
 func (p *Pool) CheckID() int {
 	return p.checkID
@@ -114,14 +184,18 @@ func PoolWithID(id int64) *Pool {
 
 // PoolJSON represents an object that can be exposed to an end-user
 type PoolJSON struct {
-	Token            string    `json:"token"`
-	Name             string    `json:"name"`
-	GridType         GridType  `json:"gridType"`
-	Archived         bool      `json:"archived"`
-	OpenAccessOnLock bool      `json:"openAccessOnLock"`
-	Locks            time.Time `json:"locks"`
-	Created          time.Time `json:"created"`
-	Modified         time.Time `json:"modified"`
+	Token             string    `json:"token"`
+	Name              string    `json:"name"`
+	GridType          GridType  `json:"gridType"`
+	Archived          bool      `json:"archived"`
+	OpenAccessOnLock  bool      `json:"openAccessOnLock"`
+	SquaresPerGrid    bool      `json:"squaresPerGrid"`
+	MaxSquaresPerUser int       `json:"maxSquaresPerUser"`
+	RandomAssignment  bool      `json:"randomAssignment"`
+	TimeZone          string    `json:"timeZone"`
+	Locks             time.Time `json:"locks"`
+	Created           time.Time `json:"created"`
+	Modified          time.Time `json:"modified"`
 }
 
 // ID returns the id
@@ -129,6 +203,11 @@ func (p *Pool) ID() int64 {
 	return p.id
 }
 
+// UserID is a getter for the ID of the user who owns the pool
+func (p *Pool) UserID() int64 {
+	return p.userID
+}
+
 // Token is a getter for the token
 func (p *Pool) Token() string {
 	return p.token
@@ -149,6 +228,11 @@ func (p *Pool) Modified() time.Time {
 	return p.modified
 }
 
+// Version returns the version of the pool, which goes up every time the pool is changed
+func (p *Pool) Version() int64 {
+	return p.version
+}
+
 // SetName is a setter for the name
 func (p *Pool) SetName(name string) {
 	if utf8.RuneCountInString(name) > NameMaxLength {
@@ -181,21 +265,25 @@ func (p *Pool) NumberOfSquares() int {
 // JSON returns JSON that can be sent to the front-end
 func (p *Pool) JSON() *PoolJSON {
 	return &PoolJSON{
-		Token:            p.token,
-		Name:             p.name,
-		OpenAccessOnLock: p.OpenAccessOnLock(),
-		Locks:            p.Locks(),
-		Archived:         p.Archived(),
-		GridType:         p.gridType,
-		Created:          p.created,
-		Modified:         p.modified,
+		Token:             p.token,
+		Name:              p.name,
+		OpenAccessOnLock:  p.OpenAccessOnLock(),
+		SquaresPerGrid:    p.SquaresPerGrid(),
+		MaxSquaresPerUser: p.MaxSquaresPerUser(),
+		RandomAssignment:  p.RandomAssignment(),
+		TimeZone:          p.TimeZone(),
+		Locks:             p.Locks(),
+		Archived:          p.Archived(),
+		GridType:          p.gridType,
+		Created:           p.created,
+		Modified:          p.modified,
 	}
 }
 
 func (m *Model) poolByRow(scan scanFunc) (*Pool, error) {
 	pool := Pool{model: m}
 	var locks *time.Time
-	if err := scan(&pool.id, &pool.token, &pool.userID, &pool.name, &pool.gridType, &pool.passwordHash, &pool.openAccessOnLock, &locks, &pool.created, &pool.modified, &pool.checkID, &pool.archived); err != nil {
+	if err := scan(&pool.id, &pool.token, &pool.userID, &pool.name, &pool.gridType, &pool.passwordHash, &pool.openAccessOnLock, &locks, &pool.created, &pool.modified, &pool.checkID, &pool.archived, &pool.squaresPerGrid, &pool.maxSquaresPerUser, &pool.randomAssignment, &pool.timeZone, &pool.version); err != nil {
 		return nil, err
 	}
 
@@ -212,16 +300,32 @@ func (m *Model) poolByRow(scan scanFunc) (*Pool, error) {
 
 // PoolsJoinedByUserID will return a collection of pools that the user joined
 func (m *Model) PoolsJoinedByUserID(ctx context.Context, userID int64, offset int64, limit int) ([]*Pool, error) {
+	return m.poolsJoinedByUserID(ctx, userID, Cursor{}, offset, limit)
+}
+
+// PoolsJoinedByUserIDAfter will return up to limit pools that the user joined that come after the cursor, along with
+// the cursor of the next page
+func (m *Model) PoolsJoinedByUserIDAfter(ctx context.Context, userID int64, cursor Cursor, limit int) ([]*Pool, Cursor, error) {
+	pools, err := m.poolsJoinedByUserID(ctx, userID, cursor, 0, limit+1)
+	if err != nil {
+		return nil, Cursor{}, err
+	}
+
+	pools, next := poolsPage(pools, limit)
+	return pools, next, nil
+}
+
+func (m *Model) poolsJoinedByUserID(ctx context.Context, userID int64, after Cursor, offset int64, limit int) ([]*Pool, error) {
 	const query = `
 		SELECT ` + poolColumns + `
 		FROM pools
 		LEFT JOIN pools_users ON pools.id = pools_users.pool_id
-		WHERE pools_users.user_id = $1
+		WHERE pools_users.user_id = $1 AND ($2 = 0 OR pools.id < $2)
 		ORDER BY pools.id DESC
-		OFFSET $2
-		LIMIT $3`
+		OFFSET $3
+		LIMIT $4`
 
-	return m.poolsByRows(m.DB.QueryContext(ctx, query, userID, offset, limit))
+	return m.poolsByRows(m.DB.QueryContext(ctx, query, userID, after.id, offset, limit))
 }
 
 // PoolsJoinedByUserIDCount will return a how many pools the user joined
@@ -237,13 +341,29 @@ func (m *Model) PoolsJoinedByUserIDCount(ctx context.Context, userID int64) (int
 
 // PoolsOwnedByUserID will return a collection of pools that were created by the user
 func (m *Model) PoolsOwnedByUserID(ctx context.Context, userID int64, includeArchived bool, offset int64, limit int) ([]*Pool, error) {
+	return m.poolsOwnedByUserID(ctx, userID, includeArchived, Cursor{}, offset, limit)
+}
+
+// PoolsOwnedByUserIDAfter will return up to limit pools that were created by the user that come after the cursor,
+// along with the cursor of the next page
+func (m *Model) PoolsOwnedByUserIDAfter(ctx context.Context, userID int64, includeArchived bool, cursor Cursor, limit int) ([]*Pool, Cursor, error) {
+	pools, err := m.poolsOwnedByUserID(ctx, userID, includeArchived, cursor, 0, limit+1)
+	if err != nil {
+		return nil, Cursor{}, err
+	}
+
+	pools, next := poolsPage(pools, limit)
+	return pools, next, nil
+}
+
+func (m *Model) poolsOwnedByUserID(ctx context.Context, userID int64, includeArchived bool, after Cursor, offset int64, limit int) ([]*Pool, error) {
 	const baseQuery = `
 		SELECT ` + poolColumns + `
 		FROM pools
-		WHERE user_id = $1%s
+		WHERE user_id = $1 AND ($2 = 0 OR pools.id < $2)%s
 		ORDER BY pools.id DESC
-		OFFSET $2
-		LIMIT $3`
+		OFFSET $3
+		LIMIT $4`
 
 	var query string
 	if includeArchived {
@@ -252,7 +372,7 @@ func (m *Model) PoolsOwnedByUserID(ctx context.Context, userID int64, includeArc
 		query = fmt.Sprintf(baseQuery, " AND archived = 'f'")
 	}
 
-	return m.poolsByRows(m.DB.QueryContext(ctx, query, userID, offset, limit))
+	return m.poolsByRows(m.DB.QueryContext(ctx, query, userID, after.id, offset, limit))
 }
 
 // PoolsOwnedByUserIDCount will return how many pools were created by the user
@@ -288,6 +408,17 @@ func (m *Model) poolsByRows(rows *sql.Rows, err error) ([]*Pool, error) {
 	return collection, nil
 }
 
+// poolsPage will trim the extra pool that was fetched to know whether there is another page and return the cursor of
+// that page
+func poolsPage(pools []*Pool, limit int) ([]*Pool, Cursor) {
+	if len(pools) <= limit {
+		return pools, Cursor{}
+	}
+
+	pools = pools[:limit]
+	return pools, Cursor{id: pools[limit-1].id}
+}
+
 func (m *Model) poolsCount(row *sql.Row) (int64, error) {
 	var count int64
 	if err := row.Scan(&count); err != nil {
@@ -353,6 +484,27 @@ func (p *Pool) SetPassword(password string) error {
 
 // Save will save the pool
 func (p *Pool) Save(ctx context.Context) error {
+	return p.save(ctx, p.model.DB, 0)
+}
+
+// SaveTx will save the pool within the transaction
+func (p *Pool) SaveTx(ctx context.Context, tx *sql.Tx) error {
+	return p.save(ctx, tx, 0)
+}
+
+// SaveVersion will save the pool only if it is still at the version, e.g. the one that a client last loaded. If it
+// has been changed since, ErrVersionMismatch is returned.
+func (p *Pool) SaveVersion(ctx context.Context, version int64) error {
+	return p.save(ctx, p.model.DB, version)
+}
+
+// SaveVersionTx will save the pool within the transaction only if it is still at the version
+func (p *Pool) SaveVersionTx(ctx context.Context, tx *sql.Tx, version int64) error {
+	return p.save(ctx, tx, version)
+}
+
+// save will save the pool. The version is not checked if it is 0.
+func (p *Pool) save(ctx context.Context, q Queryable, version int64) error {
 	const query = `
 UPDATE pools
 SET name = $1,
@@ -362,8 +514,12 @@ SET name = $1,
     check_id = $5,
     archived = $6,
     open_access_on_lock = $7,
+    max_squares_per_user = $8,
+    random_assignment = $9,
+    time_zone = $10,
     modified = (NOW() AT TIME ZONE 'utc')
-WHERE id = $8`
+WHERE id = $11
+  AND (version = $12 OR $12 = 0)`
 
 	var locks *time.Time
 	if !p.locks.IsZero() {
@@ -371,8 +527,18 @@ WHERE id = $8`
 		locks = &locksInUTC
 	}
 
-	_, err := p.model.DB.ExecContext(ctx, query, p.name, p.gridType, p.passwordHash, locks, p.checkID, p.archived, p.openAccessOnLock, p.id)
-	return err
+	res, err := q.ExecContext(ctx, query, p.name, p.gridType, p.passwordHash, locks, p.checkID, p.archived, p.openAccessOnLock, p.maxSquaresPerUser, p.randomAssignment, p.timeZone, p.id, version)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 && version != 0 {
+		return ErrVersionMismatch
+	}
+
+	return nil
 }
 
 // PasswordIsValid is will return true if the password matches
@@ -394,73 +560,152 @@ func (p *Pool) CheckIDIsValid(check int) bool {
 	return check == p.checkID
 }
 
-// Squares will return the squares that belong to a pool. This method will lazily load the squares
+// Squares will return the squares that are shared by every grid of the pool. This method will lazily load the squares.
+// If each grid of the pool has its own squares, ErrSquaresPerGrid is returned.
 func (p *Pool) Squares() (map[int]*PoolSquare, error) {
 	if p.squares == nil {
-		const query = `
-		SELECT
-		       ps.id,
-		       ps.square_id,
-		       ps.parent_id,
-		       ps.user_id,
-		       ps.state,
-		       ps.claimant,
-		       ps.modified,
-		       ps2.square_id AS parent_square_id,
-		       (SELECT array_agg(square_id) FROM pool_squares ps3 WHERE ps3.parent_id = ps.id) AS child_square_ids
+		squares, err := p.GridSquares(context.Background(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		p.squares = squares
+	}
+
+	return p.squares, nil
+}
+
+// GridSquares will return the squares that the grid is played with. Unless each grid of the pool has its own squares,
+// these are the squares that are shared by every grid. A nil grid will return the shared squares.
+func (p *Pool) GridSquares(ctx context.Context, grid *Grid) (map[int]*PoolSquare, error) {
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+		SELECT ` + poolSquareColumns + `
 		FROM
 		     pool_squares ps
 		LEFT JOIN
 		         pool_squares ps2 ON ps.parent_id = ps2.id
 		WHERE
-		      ps.pool_id = $1
+		      ps.pool_id = $1 AND
+		      COALESCE(ps.grid_id, 0) = $2
 		ORDER BY
 		         ps.square_id`
 
-		rows, err := p.model.DB.Query(query, p.id)
+	rows, err := p.model.DB.QueryContext(ctx, query, p.id, sheetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	squares := make(map[int]*PoolSquare)
+	for rows.Next() {
+		gs, err := p.squareByRow(rows.Scan)
 		if err != nil {
 			return nil, err
 		}
-		defer rows.Close()
-
-		squares := make(map[int]*PoolSquare)
-		for rows.Next() {
-			gs, err := p.squareByRow(rows.Scan)
-			if err != nil {
-				return nil, err
-			}
-			squares[gs.SquareID] = gs
-		}
-
-		p.squares = squares
+		squares[gs.SquareID] = gs
 	}
 
-	return p.squares, nil
+	return squares, nil
 }
 
-// SquareBySquareID will return a single square based on the square ID
+// SquareBySquareID will return a single square, that is shared by every grid, based on the square ID
 func (p *Pool) SquareBySquareID(squareID int) (*PoolSquare, error) {
+	return p.GridSquareBySquareID(context.Background(), nil, squareID)
+}
+
+// GridSquareBySquareID will return a single square that the grid is played with based on the square ID. A nil grid
+// will return a square that is shared by every grid.
+func (p *Pool) GridSquareBySquareID(ctx context.Context, grid *Grid, squareID int) (*PoolSquare, error) {
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return nil, err
+	}
+
 	const query = `
-	SELECT
-	       ps.id,
-	       ps.square_id,
-	       ps.parent_id,
-	       ps.user_id,
-	       ps.state,
-	       ps.claimant,
-	       ps.modified,
-	       ps2.square_id AS parent_square_id,
-	       (SELECT array_agg(square_id) FROM pool_squares ps3 WHERE ps3.parent_id = ps.id) AS child_square_ids
+	SELECT ` + poolSquareColumns + `
 	FROM pool_squares ps
 	LEFT JOIN pool_squares ps2 ON ps.parent_id = ps2.id
 	WHERE
 	      ps.pool_id = $1 AND
-	      ps.square_id = $2`
+	      COALESCE(ps.grid_id, 0) = $2 AND
+	      ps.square_id = $3`
 
-	row := p.model.DB.QueryRow(query, p.id, squareID)
+	row := p.model.DB.QueryRowContext(ctx, query, p.id, sheetID, squareID)
 	return p.squareByRow(row.Scan)
 }
 
+// sheetID returns the grid ID that the squares of the grid are stored under. Squares that are shared by every grid
+// are stored under 0.
+func (p *Pool) sheetID(grid *Grid) (int64, error) {
+	if !p.squaresPerGrid {
+		return 0, nil
+	}
+
+	if grid == nil {
+		return 0, ErrSquaresPerGrid
+	}
+
+	return grid.id, nil
+}
+
+// SetSquaresPerGrid will change whether each grid of the pool has its own squares. The squares of every grid are
+// created when it is enabled. This can only be changed before any square has been claimed.
+func (p *Pool) SetSquaresPerGrid(ctx context.Context, squaresPerGrid bool) error {
+	return p.model.inTx(ctx, func(tx *sql.Tx) error {
+		return p.SetSquaresPerGridTx(ctx, tx, squaresPerGrid)
+	})
+}
+
+// SetSquaresPerGridTx will change whether each grid of the pool has its own squares within the transaction
+func (p *Pool) SetSquaresPerGridTx(ctx context.Context, tx *sql.Tx, squaresPerGrid bool) error {
+	if err := p.setSquaresPerGrid(ctx, tx, squaresPerGrid); err != nil {
+		return err
+	}
+
+	p.squaresPerGrid = squaresPerGrid
+	p.squares = nil
+	return nil
+}
+
+func (p *Pool) setSquaresPerGrid(ctx context.Context, tx *sql.Tx, squaresPerGrid bool) error {
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM pools WHERE id = $1 FOR UPDATE", p.id); err != nil {
+		return err
+	}
+
+	var claimed bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pool_squares WHERE pool_id = $1 AND state <> 'unclaimed')", p.id).Scan(&claimed); err != nil {
+		return err
+	}
+
+	if claimed {
+		return ErrSquaresClaimed
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE pools SET squares_per_grid = $1, modified = (NOW() AT TIME ZONE 'utc') WHERE id = $2", squaresPerGrid, p.id); err != nil {
+		return err
+	}
+
+	if !squaresPerGrid {
+		return nil
+	}
+
+	const query = `
+INSERT INTO pool_squares (pool_id, grid_id, square_id)
+SELECT grids.pool_id, grids.id, square_id
+FROM grids
+CROSS JOIN generate_series(1, $2) AS square_id
+WHERE grids.pool_id = $1
+ON CONFLICT DO NOTHING`
+
+	_, err := tx.ExecContext(ctx, query, p.id, p.gridType.Squares())
+	return err
+}
+
 func (p *Pool) squareByRow(scan scanFunc) (*PoolSquare, error) {
 	gs := PoolSquare{
 		Model:  p.model,
@@ -469,10 +714,12 @@ func (p *Pool) squareByRow(scan scanFunc) (*PoolSquare, error) {
 
 	var claimant *string
 	var userID *int64
+	var gridID *int64
 	var parentID *int64
 	var parentSquareID *int
+	var holdExpires *time.Time
 	var childSquareIDs []sql.NullInt64
-	if err := scan(&gs.ID, &gs.SquareID, &parentID, &userID, &gs.State, &claimant, &gs.Modified, &parentSquareID, pq.Array(&childSquareIDs)); err != nil {
+	if err := scan(&gs.ID, &gridID, &gs.SquareID, &parentID, &userID, &gs.State, &claimant, &gs.participantID, &holdExpires, &gs.Modified, &gs.version, &parentSquareID, pq.Array(&childSquareIDs)); err != nil {
 		return nil, err
 	}
 
@@ -484,6 +731,10 @@ func (p *Pool) squareByRow(scan scanFunc) (*PoolSquare, error) {
 		gs.userID = *userID
 	}
 
+	if gridID != nil {
+		gs.GridID = *gridID
+	}
+
 	if parentID != nil {
 		gs.ParentID = *parentID
 	}
@@ -499,22 +750,90 @@ func (p *Pool) squareByRow(scan scanFunc) (*PoolSquare, error) {
 		}
 	}
 
+	if holdExpires != nil {
+		t := holdExpires.In(locationNewYork)
+		gs.holdExpires = &t
+	}
+
 	gs.Modified = gs.Modified.In(locationNewYork)
 
 	return &gs, nil
 }
 
-// Logs will return all pool square logs for the pool
-func (p *Pool) Logs(ctx context.Context, offset int64, limit int) ([]*PoolSquareLog, error) {
-	const query = `
-		SELECT pool_squares_logs.id, pool_square_id, square_id, pool_squares_logs.user_id, pool_squares_logs.state, pool_squares_logs.claimant, remote_addr, note, pool_squares_logs.created
+// LogFilter narrows down the square logs of a pool. Fields that are left as their zero value are not filtered on.
+type LogFilter struct {
+	SquareID int
+	UserID   int64
+	// Claimant and Note match any log that contains them, ignoring case
+	Claimant string
+	Note     string
+	State    PoolSquareState
+	From     time.Time
+	To       time.Time
+}
+
+// Logs will return the pool square logs for the pool that match the filter
+func (p *Pool) Logs(ctx context.Context, filter LogFilter, offset int64, limit int) ([]*PoolSquareLog, error) {
+	where, args := filter.where(p.id)
+	return p.logs(ctx, where, args, offset, limit)
+}
+
+// GridLogs will return the pool square logs of the squares that the grid is played with that match the filter
+func (p *Pool) GridLogs(ctx context.Context, grid *Grid, filter LogFilter, offset int64, limit int) ([]*PoolSquareLog, error) {
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := filter.where(p.id, sheetID)
+	return p.logs(ctx, where, args, offset, limit)
+}
+
+// LogsAfter will return up to limit pool square logs for the pool that match the filter and come after the cursor,
+// along with the cursor of the next page
+func (p *Pool) LogsAfter(ctx context.Context, filter LogFilter, cursor Cursor, limit int) ([]*PoolSquareLog, Cursor, error) {
+	where, args := filter.where(p.id)
+	return p.logsAfter(ctx, where, args, cursor, limit)
+}
+
+// GridLogsAfter will return up to limit pool square logs of the squares that the grid is played with that match the
+// filter and come after the cursor, along with the cursor of the next page
+func (p *Pool) GridLogsAfter(ctx context.Context, grid *Grid, filter LogFilter, cursor Cursor, limit int) ([]*PoolSquareLog, Cursor, error) {
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return nil, Cursor{}, err
+	}
+
+	where, args := filter.where(p.id, sheetID)
+	return p.logsAfter(ctx, where, args, cursor, limit)
+}
+
+func (p *Pool) logsAfter(ctx context.Context, where string, args []interface{}, cursor Cursor, limit int) ([]*PoolSquareLog, Cursor, error) {
+	if !cursor.IsZero() {
+		args = append(args, cursor.id)
+		where += fmt.Sprintf(" AND pool_squares_logs.id < $%d", len(args))
+	}
+
+	logs, err := p.logs(ctx, where, args, 0, limit+1)
+	if err != nil || len(logs) <= limit {
+		return logs, Cursor{}, err
+	}
+
+	logs = logs[:limit]
+	return logs, Cursor{id: logs[limit-1].id}, nil
+}
+
+func (p *Pool) logs(ctx context.Context, where string, args []interface{}, offset int64, limit int) ([]*PoolSquareLog, error) {
+	args = append(args, offset, limit)
+	query := fmt.Sprintf(`
+		SELECT `+poolSquareLogColumns+`
 		FROM pool_squares_logs
 		INNER JOIN pool_squares ON pool_squares_logs.pool_square_id = pool_squares.id
-		WHERE pool_squares.pool_id = $1
+		WHERE %s
 		ORDER BY pool_squares_logs.id DESC
-		OFFSET $2
-		LIMIT $3`
-	rows, err := p.model.DB.QueryContext(ctx, query, p.id, offset, limit)
+		OFFSET $%d
+		LIMIT $%d`, where, len(args)-1, len(args))
+	rows, err := p.model.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -533,14 +852,30 @@ func (p *Pool) Logs(ctx context.Context, offset int64, limit int) ([]*PoolSquare
 	return logs, nil
 }
 
-// LogsCount will return how many logs exist for the given pool
-func (p *Pool) LogsCount(ctx context.Context) (int64, error) {
-	const query = `
+// LogsCount will return how many logs for the given pool match the filter
+func (p *Pool) LogsCount(ctx context.Context, filter LogFilter) (int64, error) {
+	where, args := filter.where(p.id)
+	return p.logsCount(ctx, where, args...)
+}
+
+// GridLogsCount will return how many logs for the squares that the grid is played with match the filter
+func (p *Pool) GridLogsCount(ctx context.Context, grid *Grid, filter LogFilter) (int64, error) {
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return 0, err
+	}
+
+	where, args := filter.where(p.id, sheetID)
+	return p.logsCount(ctx, where, args...)
+}
+
+func (p *Pool) logsCount(ctx context.Context, where string, args ...interface{}) (int64, error) {
+	query := `
 		SELECT COUNT(pool_squares_logs.*)
 		FROM pool_squares_logs
 		INNER JOIN pool_squares ON pool_squares_logs.pool_square_id = pool_squares.id
-		WHERE pool_squares.pool_id = $1`
-	row := p.model.DB.QueryRowContext(ctx, query, p.id)
+		WHERE ` + where
+	row := p.model.DB.QueryRowContext(ctx, query, args...)
 
 	var count int64
 	if err := row.Scan(&count); err != nil {
@@ -550,6 +885,97 @@ func (p *Pool) LogsCount(ctx context.Context) (int64, error) {
 	return count, nil
 }
 
+// where returns the conditions and arguments of a query for the logs of the pool that match the filter. If a sheet ID
+// is passed, only the logs of the squares of that sheet will match.
+func (f LogFilter) where(poolID int64, sheetID ...int64) (string, []interface{}) {
+	conds := []string{"pool_squares.pool_id = $1"}
+	args := []interface{}{poolID}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if len(sheetID) > 0 {
+		add("COALESCE(pool_squares.grid_id, 0) = $%d", sheetID[0])
+	}
+
+	if f.SquareID > 0 {
+		add("pool_squares.square_id = $%d", f.SquareID)
+	}
+
+	if f.UserID > 0 {
+		add("pool_squares_logs.user_id = $%d", f.UserID)
+	}
+
+	if f.Claimant != "" {
+		add("pool_squares_logs.claimant ILIKE $%d", containsPattern(f.Claimant))
+	}
+
+	if f.Note != "" {
+		add("pool_squares_logs.note ILIKE $%d", containsPattern(f.Note))
+	}
+
+	if f.State != "" {
+		add("pool_squares_logs.state = $%d", f.State)
+	}
+
+	if !f.From.IsZero() {
+		add("pool_squares_logs.created >= $%d", f.From.UTC())
+	}
+
+	if !f.To.IsZero() {
+		add("pool_squares_logs.created < $%d", f.To.UTC())
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+// containsPattern returns a LIKE pattern that matches any text containing s
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// LastNotes will return the most recent non-empty log note of each square in the pool, keyed by square ID
+func (p *Pool) LastNotes(ctx context.Context) (map[int]string, error) {
+	return p.GridLastNotes(ctx, nil)
+}
+
+// GridLastNotes will return the most recent non-empty log note of each square that the grid is played with, keyed by
+// square ID. A nil grid will return the notes of the squares that are shared by every grid.
+func (p *Pool) GridLastNotes(ctx context.Context, grid *Grid) (map[int]string, error) {
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+		SELECT DISTINCT ON (pool_squares.square_id) pool_squares.square_id, note
+		FROM pool_squares_logs
+		INNER JOIN pool_squares ON pool_squares_logs.pool_square_id = pool_squares.id
+		WHERE pool_squares.pool_id = $1 AND COALESCE(pool_squares.grid_id, 0) = $2 AND note <> ''
+		ORDER BY pool_squares.square_id, pool_squares_logs.id DESC`
+	rows, err := p.model.DB.QueryContext(ctx, query, p.id, sheetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := make(map[int]string)
+	for rows.Next() {
+		var squareID int
+		var note string
+		if err := rows.Scan(&squareID, &note); err != nil {
+			return nil, err
+		}
+
+		notes[squareID] = note
+	}
+
+	return notes, nil
+}
+
 // DefaultGrid will return the default grid for the pool
 func (p *Pool) DefaultGrid(ctx context.Context) (*Grid, error) {
 	grids, err := p.Grids(ctx, 0, 1)
@@ -567,22 +993,46 @@ func (p *Pool) DefaultGrid(ctx context.Context) (*Grid, error) {
 // Grids returns all grids assigned to the pool. By default, this will only return "active" grids. Pass true to as the allStates
 // argument to return grids with all states
 func (p *Pool) Grids(ctx context.Context, offset int64, limit int, allStates ...bool) ([]*Grid, error) {
+	return p.grids(ctx, Cursor{}, offset, limit, allStates)
+}
+
+// GridsAfter returns up to limit grids assigned to the pool that come after the cursor, along with the cursor of the
+// next page. Like Grids, pass true as the allStates argument to return grids with all states.
+func (p *Pool) GridsAfter(ctx context.Context, cursor Cursor, limit int, allStates ...bool) ([]*Grid, Cursor, error) {
+	grids, err := p.grids(ctx, cursor, 0, limit+1, allStates)
+	if err != nil || len(grids) <= limit {
+		return grids, Cursor{}, err
+	}
+
+	grids = grids[:limit]
+	last := grids[limit-1]
+	return grids, Cursor{key: int64(last.ord), id: last.id}, nil
+}
+
+func (p *Pool) grids(ctx context.Context, after Cursor, offset int64, limit int, allStates []bool) ([]*Grid, error) {
 	activeOnly := len(allStates) == 0 || !allStates[0]
 	stateClause := ""
 	if activeOnly {
 		stateClause = " AND state = 'active'"
 	}
 
-	query := `
-SELECT ` + gridColumns + `
+	args := []interface{}{p.id}
+	if !after.IsZero() {
+		args = append(args, after.key, after.id)
+		stateClause += " AND (ord, id) > ($2, $3)"
+	}
+
+	args = append(args, offset, limit)
+	query := fmt.Sprintf(`
+SELECT `+gridColumns+`
 FROM grids
-WHERE pool_id = $1` + stateClause + `
+WHERE pool_id = $1%s
 ORDER BY ord, id
-OFFSET $2
-LIMIT $3
-`
+OFFSET $%d
+LIMIT $%d
+`, stateClause, len(args)-1, len(args))
 
-	rows, err := p.model.DB.QueryContext(ctx, query, p.id, offset, limit)
+	rows, err := p.model.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -595,6 +1045,7 @@ LIMIT $3
 			return nil, err
 		}
 
+		grid.gridType = p.gridType
 		grids = append(grids, grid)
 	}
 
@@ -623,20 +1074,15 @@ func (p *Pool) GridsCount(ctx context.Context, allStates ...bool) (int64, error)
 
 // SetGridsOrder will re-arrange the order of the grids
 func (p *Pool) SetGridsOrder(ctx context.Context, gridIDs []int64) error {
-	tx, err := p.model.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-
-	rollback := func() {
-		if err := tx.Rollback(); err != nil {
-			logrus.WithError(err).Warn("could not rollback transaction")
-		}
-	}
+	return p.model.inTx(ctx, func(tx *sql.Tx) error {
+		return p.SetGridsOrderTx(ctx, tx, gridIDs)
+	})
+}
 
+// SetGridsOrderTx will re-arrange the order of the grids within the transaction
+func (p *Pool) SetGridsOrderTx(ctx context.Context, tx *sql.Tx, gridIDs []int64) error {
 	rows, err := tx.QueryContext(ctx, "SELECT id, ord FROM grids WHERE pool_id = $1", p.id)
 	if err != nil {
-		rollback()
 		return err
 	}
 	defer rows.Close()
@@ -646,7 +1092,6 @@ func (p *Pool) SetGridsOrder(ctx context.Context, gridIDs []int64) error {
 		var id int64
 		var ord int
 		if err := rows.Scan(&id, &ord); err != nil {
-			rollback()
 			return err
 		}
 
@@ -669,7 +1114,6 @@ func (p *Pool) SetGridsOrder(ctx context.Context, gridIDs []int64) error {
 		if ord != curOrd {
 			result, err := stmt.ExecContext(ctx, ord, id, p.id)
 			if err != nil {
-				rollback()
 				return err
 			}
 
@@ -677,13 +1121,12 @@ func (p *Pool) SetGridsOrder(ctx context.Context, gridIDs []int64) error {
 
 			if rowsAffected == 0 {
 				l.Warn("no rows affected")
-				rollback()
 				return errors.New("no rows affected")
 			}
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 // NewGrid will create a new grid for the pool with some default settings
@@ -691,6 +1134,7 @@ func (p *Pool) NewGrid() *Grid {
 	return &Grid{
 		model:    p.model,
 		poolID:   p.id,
+		gridType: p.gridType,
 		settings: &GridSettings{},
 	}
 }
@@ -706,12 +1150,44 @@ func (p *Pool) GridByID(ctx context.Context, id int64) (*Grid, error) {
 	      pool_id = $2 AND
 	      state = 'active'`
 	row := p.model.DB.QueryRowContext(ctx, query, id, p.id)
-	return p.model.gridByRow(row.Scan)
+	grid, err := p.model.gridByRow(row.Scan)
+	if err != nil {
+		return nil, err
+	}
+
+	grid.gridType = p.gridType
+	return grid, nil
+}
+
+// SquaresClaimedBy will return the number of squares that count towards the limit of the user. A secondary square
+// counts as part of the square it was claimed with.
+func (p *Pool) SquaresClaimedBy(ctx context.Context, userID int64) (int, error) {
+	const query = `
+		SELECT COUNT(*)
+		FROM pool_squares
+		WHERE pool_id = $1 AND user_id = $2 AND state <> 'unclaimed' AND parent_id IS NULL`
+	row := p.model.DB.QueryRowContext(ctx, query, p.id, userID)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
 }
 
 // RemoveAllMembers will boot all members from the pool
 func (p *Pool) RemoveAllMembers(ctx context.Context) error {
-	_, err := p.model.DB.ExecContext(ctx, "DELETE FROM pools_users WHERE pool_id = $1", p.ID())
+	return p.removeAllMembers(ctx, p.model.DB)
+}
+
+// RemoveAllMembersTx will boot all members from the pool within the transaction
+func (p *Pool) RemoveAllMembersTx(ctx context.Context, tx *sql.Tx) error {
+	return p.removeAllMembers(ctx, tx)
+}
+
+func (p *Pool) removeAllMembers(ctx context.Context, q Queryable) error {
+	_, err := q.ExecContext(ctx, "DELETE FROM pools_users WHERE pool_id = $1", p.ID())
 	return err
 }
 
@@ -727,5 +1203,10 @@ pools.locks,
 pools.created,
 pools.modified,
 pools.check_id,
-pools.archived
+pools.archived,
+pools.squares_per_grid,
+pools.max_squares_per_user,
+pools.random_assignment,
+pools.time_zone,
+pools.version
 `
@@ -0,0 +1,389 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuditEvent is something that an admin did to a pool
+type AuditEvent string
+
+// Audit events
+const (
+	AuditEventMemberRemoved  AuditEvent = "memberRemoved"
+	AuditEventMemberPromoted AuditEvent = "memberPromoted"
+	AuditEventMemberDemoted  AuditEvent = "memberDemoted"
+	AuditEventMemberBanned   AuditEvent = "memberBanned"
+	AuditEventMemberUnbanned AuditEvent = "memberUnbanned"
+
+	AuditEventOwnershipTransferStarted   AuditEvent = "ownershipTransferStarted"
+	AuditEventOwnershipTransferCancelled AuditEvent = "ownershipTransferCancelled"
+	AuditEventOwnershipTransferred       AuditEvent = "ownershipTransferred"
+
+	AuditEventPoolRenamed                 AuditEvent = "poolRenamed"
+	AuditEventPoolLocked                  AuditEvent = "poolLocked"
+	AuditEventPoolUnlocked                AuditEvent = "poolUnlocked"
+	AuditEventPoolLocksChanged            AuditEvent = "poolLocksChanged"
+	AuditEventPoolAccessOnLockChanged     AuditEvent = "poolAccessOnLockChanged"
+	AuditEventPoolSquaresPerGridChanged   AuditEvent = "poolSquaresPerGridChanged"
+	AuditEventPoolMaxSquaresChanged       AuditEvent = "poolMaxSquaresChanged"
+	AuditEventPoolRandomAssignmentChanged AuditEvent = "poolRandomAssignmentChanged"
+	AuditEventPoolSquaresAssigned         AuditEvent = "poolSquaresAssigned"
+	AuditEventPoolArchived                AuditEvent = "poolArchived"
+	AuditEventPoolUnarchived              AuditEvent = "poolUnarchived"
+	AuditEventPoolJoinPasswordChanged     AuditEvent = "poolJoinPasswordChanged"
+	AuditEventPoolTimeZoneChanged         AuditEvent = "poolTimeZoneChanged"
+
+	AuditEventGridCreated       AuditEvent = "gridCreated"
+	AuditEventGridUpdated       AuditEvent = "gridUpdated"
+	AuditEventGridDeleted       AuditEvent = "gridDeleted"
+	AuditEventGridsReordered    AuditEvent = "gridsReordered"
+	AuditEventGridPayoutsSaved  AuditEvent = "gridPayoutsSaved"
+	AuditEventNumbersDrawn      AuditEvent = "numbersDrawn"
+	AuditEventScoreSaved        AuditEvent = "scoreSaved"
+	AuditEventScoreDeleted      AuditEvent = "scoreDeleted"
+	AuditEventAnnotationSaved   AuditEvent = "annotationSaved"
+	AuditEventAnnotationDeleted AuditEvent = "annotationDeleted"
+
+	AuditEventParticipantAdded      AuditEvent = "participantAdded"
+	AuditEventParticipantUpdated    AuditEvent = "participantUpdated"
+	AuditEventParticipantsMerged    AuditEvent = "participantsMerged"
+	AuditEventParticipantTokenReset AuditEvent = "participantTokenReset"
+	AuditEventParticipantDeleted    AuditEvent = "participantDeleted"
+
+	AuditEventPaymentRecorded AuditEvent = "paymentRecorded"
+	AuditEventPaymentVoided   AuditEvent = "paymentVoided"
+)
+
+// AuditEvents are the valid audit events
+var AuditEvents = []AuditEvent{
+	AuditEventMemberRemoved,
+	AuditEventMemberPromoted,
+	AuditEventMemberDemoted,
+	AuditEventMemberBanned,
+	AuditEventMemberUnbanned,
+	AuditEventOwnershipTransferStarted,
+	AuditEventOwnershipTransferCancelled,
+	AuditEventOwnershipTransferred,
+	AuditEventPoolRenamed,
+	AuditEventPoolLocked,
+	AuditEventPoolUnlocked,
+	AuditEventPoolLocksChanged,
+	AuditEventPoolAccessOnLockChanged,
+	AuditEventPoolSquaresPerGridChanged,
+	AuditEventPoolMaxSquaresChanged,
+	AuditEventPoolRandomAssignmentChanged,
+	AuditEventPoolSquaresAssigned,
+	AuditEventPoolArchived,
+	AuditEventPoolUnarchived,
+	AuditEventPoolJoinPasswordChanged,
+	AuditEventPoolTimeZoneChanged,
+	AuditEventGridCreated,
+	AuditEventGridUpdated,
+	AuditEventGridDeleted,
+	AuditEventGridsReordered,
+	AuditEventGridPayoutsSaved,
+	AuditEventNumbersDrawn,
+	AuditEventScoreSaved,
+	AuditEventScoreDeleted,
+	AuditEventAnnotationSaved,
+	AuditEventAnnotationDeleted,
+	AuditEventParticipantAdded,
+	AuditEventParticipantUpdated,
+	AuditEventParticipantsMerged,
+	AuditEventParticipantTokenReset,
+	AuditEventParticipantDeleted,
+	AuditEventPaymentRecorded,
+	AuditEventPaymentVoided,
+}
+
+// IsValid will ensure that it's a valid event
+func (e AuditEvent) IsValid() bool {
+	for _, event := range AuditEvents {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AuditFilter narrows down the events of an audit log. Fields that are left as their zero value are not filtered on.
+type AuditFilter struct {
+	Event  AuditEvent
+	UserID int64
+	From   time.Time
+	To     time.Time
+}
+
+// PoolAuditEvent is a record of something that an admin did to a pool
+type PoolAuditEvent struct {
+	id           int64
+	poolID       int64
+	userID       int64
+	targetUserID int64
+	event        AuditEvent
+	remoteAddr   string
+	before       json.RawMessage
+	after        json.RawMessage
+	created      time.Time
+}
+
+// PoolAuditEventJSON is the JSON representation of a PoolAuditEvent
+type PoolAuditEventJSON struct {
+	ID           int64           `json:"id"`
+	UserID       int64           `json:"userId,omitempty"`
+	TargetUserID int64           `json:"targetUserId,omitempty"`
+	Event        AuditEvent      `json:"event"`
+	Before       json.RawMessage `json:"before,omitempty"`
+	After        json.RawMessage `json:"after,omitempty"`
+	Created      time.Time       `json:"created"`
+}
+
+// ID returns the ID
+func (e *PoolAuditEvent) ID() int64 {
+	return e.id
+}
+
+// UserID returns the ID of the user who did it. This will be zero if it was done by the system.
+func (e *PoolAuditEvent) UserID() int64 {
+	return e.userID
+}
+
+// TargetUserID returns the ID of the user that it was done to, if any
+func (e *PoolAuditEvent) TargetUserID() int64 {
+	return e.targetUserID
+}
+
+// Event returns the event
+func (e *PoolAuditEvent) Event() AuditEvent {
+	return e.event
+}
+
+// RemoteAddr returns the IP address of the user who did it
+func (e *PoolAuditEvent) RemoteAddr() string {
+	return e.remoteAddr
+}
+
+// Created returns when it happened
+func (e *PoolAuditEvent) Created() time.Time {
+	return e.created
+}
+
+// JSON will return the JSON representation. The remote address is not included.
+func (e *PoolAuditEvent) JSON() *PoolAuditEventJSON {
+	return &PoolAuditEventJSON{
+		ID:           e.id,
+		UserID:       e.userID,
+		TargetUserID: e.targetUserID,
+		Event:        e.event,
+		Before:       e.before,
+		After:        e.after,
+		Created:      e.created,
+	}
+}
+
+// RecordAuditEvent will add an event to the audit log of the pool. The actor is nil if the event was caused by the
+// system rather than an admin. The before and after states are stored as JSON and may be nil.
+func (p *Pool) RecordAuditEvent(ctx context.Context, actor *User, remoteAddr string, event AuditEvent, before, after interface{}) error {
+	return p.recordAuditEvent(ctx, p.model.DB, actor, remoteAddr, event, 0, before, after)
+}
+
+// Audited will make the change and add the event to the audit log of the pool in the same transaction, so that the
+// change is never made without being recorded. The change returns the after state, since some of it is only known once
+// the change has been made.
+func (p *Pool) Audited(ctx context.Context, actor *User, remoteAddr string, event AuditEvent, before interface{}, change func(tx *sql.Tx) (after interface{}, err error)) error {
+	return p.model.inTx(ctx, func(tx *sql.Tx) error {
+		after, err := change(tx)
+		if err != nil {
+			return err
+		}
+
+		return p.recordAuditEvent(ctx, tx, actor, remoteAddr, event, 0, before, after)
+	})
+}
+
+// recordAuditEvent will add an event to the audit log of the pool. The before and after states are stored as JSON
+// and may be nil.
+func (p *Pool) recordAuditEvent(ctx context.Context, q Queryable, actor *User, remoteAddr string, event AuditEvent, targetUserID int64, before, after interface{}) error {
+	var userID *int64
+	if actor != nil {
+		userID = &actor.ID
+	}
+
+	var target *int64
+	if targetUserID > 0 {
+		target = &targetUserID
+	}
+
+	beforeJSON, err := marshalAuditState(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := marshalAuditState(after)
+	if err != nil {
+		return err
+	}
+
+	const query = `
+		INSERT INTO pool_audit_events (pool_id, user_id, target_user_id, event, remote_addr, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = q.ExecContext(ctx, query, p.id, userID, target, event, ipFromRemoteAddr(remoteAddr), beforeJSON, afterJSON)
+	return err
+}
+
+func marshalAuditState(state interface{}) (*string, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	s := string(b)
+	return &s, nil
+}
+
+// AuditEvents will return the audit log of the pool that matches the filter, newest first
+func (p *Pool) AuditEvents(ctx context.Context, filter AuditFilter, offset int64, limit int) ([]*PoolAuditEvent, error) {
+	where, args := filter.where(p.id)
+	return p.auditEvents(ctx, where, args, offset, limit)
+}
+
+// AuditEventsAfter will return up to limit events in the audit log of the pool that match the filter and come after
+// the cursor, along with the cursor of the next page
+func (p *Pool) AuditEventsAfter(ctx context.Context, filter AuditFilter, cursor Cursor, limit int) ([]*PoolAuditEvent, Cursor, error) {
+	where, args := filter.where(p.id)
+	if !cursor.IsZero() {
+		args = append(args, cursor.id)
+		where += " AND id < $" + strconv.Itoa(len(args))
+	}
+
+	events, err := p.auditEvents(ctx, where, args, 0, limit+1)
+	if err != nil || len(events) <= limit {
+		return events, Cursor{}, err
+	}
+
+	events = events[:limit]
+	return events, Cursor{id: events[limit-1].id}, nil
+}
+
+func (p *Pool) auditEvents(ctx context.Context, where string, args []interface{}, offset int64, limit int) ([]*PoolAuditEvent, error) {
+	query := `
+		SELECT id, pool_id, user_id, target_user_id, event, remote_addr, before, after, created
+		FROM pool_audit_events
+		WHERE ` + where + `
+		ORDER BY id DESC
+		OFFSET $` + strconv.Itoa(len(args)+1) + `
+		LIMIT $` + strconv.Itoa(len(args)+2)
+	rows, err := p.model.DB.QueryContext(ctx, query, append(args, offset, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*PoolAuditEvent, 0)
+	for rows.Next() {
+		event, err := poolAuditEventByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// AuditEventsCount will return how many events in the audit log of the pool match the filter
+func (p *Pool) AuditEventsCount(ctx context.Context, filter AuditFilter) (int64, error) {
+	where, args := filter.where(p.id)
+	row := p.model.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM pool_audit_events WHERE "+where, args...)
+
+	var count int64
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// where returns the conditions and arguments of a query for the events of the pool that match the filter
+func (f AuditFilter) where(poolID int64) (string, []interface{}) {
+	conds := []string{"pool_id = $1"}
+	args := []interface{}{poolID}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.Event != "" {
+		add("event = $%d", f.Event)
+	}
+
+	if f.UserID > 0 {
+		add("user_id = $%d", f.UserID)
+	}
+
+	if !f.From.IsZero() {
+		add("created >= $%d", f.From.UTC())
+	}
+
+	if !f.To.IsZero() {
+		add("created < $%d", f.To.UTC())
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+func poolAuditEventByRow(scan scanFunc) (*PoolAuditEvent, error) {
+	var e PoolAuditEvent
+	var userID, targetUserID sql.NullInt64
+	var remoteAddr *string
+	var before, after []byte
+	if err := scan(&e.id, &e.poolID, &userID, &targetUserID, &e.event, &remoteAddr, &before, &after, &e.created); err != nil {
+		return nil, err
+	}
+
+	e.userID = userID.Int64
+	e.targetUserID = targetUserID.Int64
+	if remoteAddr != nil {
+		e.remoteAddr = *remoteAddr
+	}
+
+	if before != nil {
+		e.before = json.RawMessage(before)
+	}
+
+	if after != nil {
+		e.after = json.RawMessage(after)
+	}
+
+	e.created = e.created.In(locationNewYork)
+
+	return &e, nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestAuditEvent(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	for _, event := range AuditEvents {
+		g.Expect(event.IsValid()).Should(gomega.BeTrue())
+	}
+
+	g.Expect(AuditEvent("poolExploded").IsValid()).Should(gomega.BeFalse())
+}
+
+func TestPoolAuditFilter(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, IssuerAuth0, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	admin, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	g.Expect(pool.RecordAuditEvent(ctx, owner, "127.0.0.1:5000", AuditEventPoolRenamed, map[string]string{"name": "test"}, map[string]string{"name": "renamed"})).Should(gomega.Succeed())
+	g.Expect(pool.RecordAuditEvent(ctx, admin, "127.0.0.1:5000", AuditEventPoolLocked, nil, nil)).Should(gomega.Succeed())
+	g.Expect(pool.RecordAuditEvent(ctx, owner, "127.0.0.1:5000", AuditEventPoolUnlocked, nil, nil)).Should(gomega.Succeed())
+
+	events, err := pool.AuditEvents(ctx, AuditFilter{Event: AuditEventPoolRenamed}, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(events)).Should(gomega.Equal(1))
+	g.Expect(string(events[0].JSON().Before)).Should(gomega.MatchJSON(`{"name":"test"}`))
+
+	events, err = pool.AuditEvents(ctx, AuditFilter{UserID: owner.ID}, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(events)).Should(gomega.Equal(2))
+	g.Expect(events[0].Event()).Should(gomega.Equal(AuditEventPoolUnlocked))
+
+	events, cursor, err := pool.AuditEventsAfter(ctx, AuditFilter{UserID: owner.ID}, Cursor{}, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(events)).Should(gomega.Equal(1))
+	g.Expect(events[0].Event()).Should(gomega.Equal(AuditEventPoolUnlocked))
+
+	events, cursor, err = pool.AuditEventsAfter(ctx, AuditFilter{UserID: owner.ID}, cursor, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(events)).Should(gomega.Equal(1))
+	g.Expect(events[0].Event()).Should(gomega.Equal(AuditEventPoolRenamed))
+	g.Expect(cursor.IsZero()).Should(gomega.BeTrue())
+
+	count, err := pool.AuditEventsCount(ctx, AuditFilter{UserID: admin.ID, Event: AuditEventPoolLocked})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(1)))
+
+	count, err = pool.AuditEventsCount(ctx, AuditFilter{From: time.Now().Add(-time.Hour), To: time.Now().Add(time.Hour)})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(3)))
+
+	count, err = pool.AuditEventsCount(ctx, AuditFilter{To: time.Now().Add(-time.Hour)})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(0)))
+}
+
+func TestPoolAudited(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	rename := func(name string, after interface{}, changeErr error) error {
+		pool.SetName(name)
+		return pool.Audited(ctx, owner, "127.0.0.1:5000", AuditEventPoolRenamed, map[string]string{"name": "test"}, func(tx *sql.Tx) (interface{}, error) {
+			if err := pool.SaveTx(ctx, tx); err != nil {
+				return nil, err
+			}
+
+			return after, changeErr
+		})
+	}
+
+	name := func() string {
+		pool, err := m.PoolByID(pool.ID())
+		g.Expect(err).Should(gomega.Succeed())
+		return pool.Name()
+	}
+
+	count := func() int64 {
+		count, err := pool.AuditEventsCount(ctx, AuditFilter{Event: AuditEventPoolRenamed})
+		g.Expect(err).Should(gomega.Succeed())
+		return count
+	}
+
+	// the change and its event are saved together
+	g.Expect(rename("renamed", map[string]string{"name": "renamed"}, nil)).Should(gomega.Succeed())
+	g.Expect(name()).Should(gomega.Equal("renamed"))
+	g.Expect(count()).Should(gomega.Equal(int64(1)))
+
+	// a change that fails is not recorded
+	changeErr := errors.New("change failed")
+	g.Expect(rename("failed", nil, changeErr)).Should(gomega.Equal(changeErr))
+	g.Expect(name()).Should(gomega.Equal("renamed"))
+	g.Expect(count()).Should(gomega.Equal(int64(1)))
+
+	// a change that cannot be recorded is not made
+	g.Expect(rename("unrecorded", make(chan int), nil)).ShouldNot(gomega.Succeed())
+	g.Expect(name()).Should(gomega.Equal("renamed"))
+	g.Expect(count()).Should(gomega.Equal(int64(1)))
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// MaxJobOffsetHours is the furthest a job may be scheduled from the date it is relative to
+const MaxJobOffsetHours = 24 * 365
+
+// MaxJobAttempts is the number of times a job is run before giving up
+const MaxJobAttempts = 5
+
+// ErrJobNotPending happens when trying to cancel a job that has already run
+var ErrJobNotPending = errors.New("only pending jobs can be cancelled")
+
+// PoolJobAction is something that the scheduler will do to a pool
+type PoolJobAction string
+
+// Allowed job actions
+const (
+	// PoolJobActionDrawNumbers will draw the numbers for every grid when the pool locks
+	PoolJobActionDrawNumbers PoolJobAction = "drawNumbers"
+	// PoolJobActionArchive will archive the pool a number of hours after the last event date of its grids
+	PoolJobActionArchive PoolJobAction = "archive"
+	// PoolJobActionLockReminder will send a reminder to the pool a number of hours before it locks
+	PoolJobActionLockReminder PoolJobAction = "lockReminder"
+	// PoolJobActionAssignSquares will assign the requested squares at random when the pool locks
+	PoolJobActionAssignSquares PoolJobAction = "assignSquares"
+)
+
+// PoolJobActions are the valid job actions
+var PoolJobActions = []PoolJobAction{
+	PoolJobActionDrawNumbers,
+	PoolJobActionArchive,
+	PoolJobActionLockReminder,
+	PoolJobActionAssignSquares,
+}
+
+// IsValid will ensure that it's a valid action
+func (a PoolJobAction) IsValid() bool {
+	for _, action := range PoolJobActions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description returns a human readable description of the action
+func (a PoolJobAction) Description() string {
+	switch a {
+	case PoolJobActionDrawNumbers:
+		return "Draw the numbers when the pool locks"
+	case PoolJobActionArchive:
+		return "Archive the pool after the last event"
+	case PoolJobActionLockReminder:
+		return "Send a reminder before the pool locks"
+	case PoolJobActionAssignSquares:
+		return "Assign the requested squares at random when the pool locks"
+	}
+
+	return string(a)
+}
+
+// PoolJobState is the state of a job
+type PoolJobState string
+
+// Job states
+const (
+	PoolJobStatePending   PoolJobState = "pending"
+	PoolJobStateDone      PoolJobState = "done"
+	PoolJobStateFailed    PoolJobState = "failed"
+	PoolJobStateCancelled PoolJobState = "cancelled"
+)
+
+// PoolJob is an action that will be run against a pool at a later time. The time the job runs at is derived from
+// the pool, so it will follow any changes to the lock date or event dates.
+type PoolJob struct {
+	model       *Model
+	id          int64
+	poolID      int64
+	action      PoolJobAction
+	offsetHours int
+	runAt       *time.Time
+	state       PoolJobState
+	attempts    int
+	lastError   *string
+	created     time.Time
+	modified    time.Time
+}
+
+// PoolJobJSON is the JSON representation of a PoolJob
+type PoolJobJSON struct {
+	ID          int64         `json:"id"`
+	Action      PoolJobAction `json:"action"`
+	OffsetHours int           `json:"offsetHours"`
+	RunAt       *time.Time    `json:"runAt"`
+	State       PoolJobState  `json:"state"`
+	Attempts    int           `json:"attempts"`
+	LastError   *string       `json:"lastError"`
+	Created     time.Time     `json:"created"`
+	Modified    time.Time     `json:"modified"`
+}
+
+// ID is a getter
+func (j *PoolJob) ID() int64 {
+	return j.id
+}
+
+// PoolID is a getter
+func (j *PoolJob) PoolID() int64 {
+	return j.poolID
+}
+
+// Action is a getter
+func (j *PoolJob) Action() PoolJobAction {
+	return j.action
+}
+
+// OffsetHours is a getter
+func (j *PoolJob) OffsetHours() int {
+	return j.offsetHours
+}
+
+// RunAt returns when the job will run. It will be nil if the pool does not have the date the job is relative to.
+func (j *PoolJob) RunAt() *time.Time {
+	return j.runAt
+}
+
+// State is a getter
+func (j *PoolJob) State() PoolJobState {
+	return j.state
+}
+
+// Attempts is the number of times the job has failed
+func (j *PoolJob) Attempts() int {
+	return j.attempts
+}
+
+// JSON will return the JSON representation of the job
+func (j *PoolJob) JSON() *PoolJobJSON {
+	return &PoolJobJSON{
+		ID:          j.id,
+		Action:      j.action,
+		OffsetHours: j.offsetHours,
+		RunAt:       j.runAt,
+		State:       j.state,
+		Attempts:    j.attempts,
+		LastError:   j.lastError,
+		Created:     j.created,
+		Modified:    j.modified,
+	}
+}
+
+const poolJobColumns = `id, pool_id, action, offset_hours, run_at, state, attempts, last_error, created, modified`
+
+// ScheduleJob will schedule the action to run against the pool. If the action is already pending, its offset
+// will be updated instead.
+func (p *Pool) ScheduleJob(ctx context.Context, action PoolJobAction, offsetHours int) (*PoolJob, error) {
+	return p.scheduleJob(ctx, p.model.DB, action, offsetHours)
+}
+
+// ScheduleJobTx will schedule the action to run against the pool within the transaction
+func (p *Pool) ScheduleJobTx(ctx context.Context, tx *sql.Tx, action PoolJobAction, offsetHours int) (*PoolJob, error) {
+	return p.scheduleJob(ctx, tx, action, offsetHours)
+}
+
+func (p *Pool) scheduleJob(ctx context.Context, q Queryable, action PoolJobAction, offsetHours int) (*PoolJob, error) {
+	const query = `
+INSERT INTO pool_jobs (pool_id, action, offset_hours, run_at)
+VALUES ($1, $2, $3, pool_job_run_at($1, $2, $3))
+ON CONFLICT (pool_id, action) WHERE state = 'pending' DO UPDATE
+SET offset_hours = EXCLUDED.offset_hours,
+	run_at = EXCLUDED.run_at,
+	attempts = 0,
+	last_error = NULL,
+	modified = (NOW() AT TIME ZONE 'utc')
+RETURNING ` + poolJobColumns
+
+	row := q.QueryRowContext(ctx, query, p.id, action, offsetHours)
+	return p.model.poolJobByRow(row.Scan)
+}
+
+// RescheduleJobs will recalculate when each pending job of the pool will run. This must be called whenever the
+// lock date or the event date of a grid changes.
+func (p *Pool) RescheduleJobs(ctx context.Context) error {
+	const query = `
+UPDATE pool_jobs
+SET run_at = pool_job_run_at(pool_id, action, offset_hours),
+	modified = (NOW() AT TIME ZONE 'utc')
+WHERE pool_id = $1
+  AND state = 'pending'
+  AND run_at IS DISTINCT FROM pool_job_run_at(pool_id, action, offset_hours)
+`
+
+	_, err := p.model.DB.ExecContext(ctx, query, p.id)
+	return err
+}
+
+// Jobs returns all of the jobs of the pool, newest first
+func (p *Pool) Jobs(ctx context.Context) ([]*PoolJob, error) {
+	rows, err := p.model.DB.QueryContext(ctx, "SELECT "+poolJobColumns+" FROM pool_jobs WHERE pool_id = $1 ORDER BY id DESC", p.id)
+	return p.model.poolJobsByRows(rows, err)
+}
+
+// JobByID returns a single job of the pool
+func (p *Pool) JobByID(ctx context.Context, id int64) (*PoolJob, error) {
+	row := p.model.DB.QueryRowContext(ctx, "SELECT "+poolJobColumns+" FROM pool_jobs WHERE pool_id = $1 AND id = $2", p.id, id)
+	return p.model.poolJobByRow(row.Scan)
+}
+
+// DueJobs returns up to limit pending jobs that should have already run, oldest first
+func (m *Model) DueJobs(ctx context.Context, limit int) ([]*PoolJob, error) {
+	const query = `
+SELECT ` + poolJobColumns + `
+FROM pool_jobs
+WHERE state = 'pending'
+  AND run_at <= (NOW() AT TIME ZONE 'utc')
+ORDER BY run_at, id
+LIMIT $1
+`
+
+	rows, err := m.DB.QueryContext(ctx, query, limit)
+	return m.poolJobsByRows(rows, err)
+}
+
+// Cancel will stop a pending job from running
+func (j *PoolJob) Cancel(ctx context.Context) error {
+	if err := j.setState(ctx, PoolJobStateCancelled, PoolJobStatePending); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrJobNotPending
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Done will record that the job ran successfully
+func (j *PoolJob) Done(ctx context.Context) error {
+	return j.setState(ctx, PoolJobStateDone, PoolJobStatePending)
+}
+
+// Failed will record a failed attempt. If retryAt is nil, the job will not be tried again.
+func (j *PoolJob) Failed(ctx context.Context, errMsg string, retryAt *time.Time) error {
+	const query = `
+UPDATE pool_jobs
+SET state = $1,
+	attempts = attempts + 1,
+	last_error = $2,
+	run_at = COALESCE($3, run_at),
+	modified = (NOW() AT TIME ZONE 'utc')
+WHERE id = $4
+RETURNING ` + poolJobColumns
+
+	state := PoolJobStatePending
+	var runAt *time.Time
+	if retryAt == nil {
+		state = PoolJobStateFailed
+	} else {
+		retryAtInUTC := retryAt.UTC()
+		runAt = &retryAtInUTC
+	}
+
+	row := j.model.DB.QueryRowContext(ctx, query, state, errMsg, runAt, j.id)
+	return j.reload(row.Scan)
+}
+
+func (j *PoolJob) setState(ctx context.Context, state, fromState PoolJobState) error {
+	const query = `
+UPDATE pool_jobs
+SET state = $1,
+	modified = (NOW() AT TIME ZONE 'utc')
+WHERE id = $2
+  AND state = $3
+RETURNING ` + poolJobColumns
+
+	row := j.model.DB.QueryRowContext(ctx, query, state, j.id, fromState)
+	return j.reload(row.Scan)
+}
+
+func (j *PoolJob) reload(scan scanFunc) error {
+	job, err := j.model.poolJobByRow(scan)
+	if err != nil {
+		return err
+	}
+
+	*j = *job
+	return nil
+}
+
+func (m *Model) poolJobsByRows(rows *sql.Rows, err error) ([]*PoolJob, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]*PoolJob, 0)
+	for rows.Next() {
+		job, err := m.poolJobByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+func (m *Model) poolJobByRow(scan scanFunc) (*PoolJob, error) {
+	j := PoolJob{model: m}
+	if err := scan(&j.id, &j.poolID, &j.action, &j.offsetHours, &j.runAt, &j.state, &j.attempts, &j.lastError, &j.created, &j.modified); err != nil {
+		return nil, err
+	}
+
+	if j.runAt != nil {
+		runAt := j.runAt.In(locationNewYork)
+		j.runAt = &runAt
+	}
+
+	j.created = j.created.In(locationNewYork)
+	j.modified = j.modified.In(locationNewYork)
+	return &j, nil
+}
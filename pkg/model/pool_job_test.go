@@ -0,0 +1,134 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestPoolJobAction(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	for _, action := range PoolJobActions {
+		g.Expect(action.IsValid()).Should(gomega.BeTrue())
+		g.Expect(action.Description()).ShouldNot(gomega.Equal(string(action)))
+	}
+
+	g.Expect(PoolJobAction("explode").IsValid()).Should(gomega.BeFalse())
+}
+
+func TestPoolJobs(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	pool := getPool(m)
+
+	due := func() []*PoolJob {
+		jobs, err := m.DueJobs(ctx, 1000)
+		g.Expect(err).Should(gomega.Succeed())
+
+		mine := make([]*PoolJob, 0)
+		for _, job := range jobs {
+			if job.PoolID() == pool.ID() {
+				mine = append(mine, job)
+			}
+		}
+
+		return mine
+	}
+
+	// the pool has no lock date yet, so the reminder cannot be scheduled
+	reminder, err := pool.ScheduleJob(ctx, PoolJobActionLockReminder, 2)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(reminder.State()).Should(gomega.Equal(PoolJobStatePending))
+	g.Expect(reminder.RunAt()).Should(gomega.BeNil())
+	g.Expect(due()).Should(gomega.BeEmpty())
+
+	locks := time.Now().Add(time.Hour).Truncate(time.Second)
+	pool.SetLocks(locks)
+	g.Expect(pool.Save(ctx)).Should(gomega.Succeed())
+	g.Expect(pool.RescheduleJobs(ctx)).Should(gomega.Succeed())
+
+	reminder, err = pool.JobByID(ctx, reminder.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(reminder.RunAt()).ShouldNot(gomega.BeNil())
+	g.Expect(*reminder.RunAt()).Should(gomega.BeTemporally("==", locks.Add(-2*time.Hour)))
+
+	// scheduling a pending action again only changes its offset
+	updated, err := pool.ScheduleJob(ctx, PoolJobActionLockReminder, 3)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(updated.ID()).Should(gomega.Equal(reminder.ID()))
+	g.Expect(updated.OffsetHours()).Should(gomega.Equal(3))
+	g.Expect(*updated.RunAt()).Should(gomega.BeTemporally("==", locks.Add(-3*time.Hour)))
+
+	jobs := due()
+	g.Expect(len(jobs)).Should(gomega.Equal(1))
+	g.Expect(jobs[0].ID()).Should(gomega.Equal(reminder.ID()))
+
+	retryAt := time.Now().Add(time.Hour)
+	g.Expect(jobs[0].Failed(ctx, "try again", &retryAt)).Should(gomega.Succeed())
+	g.Expect(jobs[0].State()).Should(gomega.Equal(PoolJobStatePending))
+	g.Expect(jobs[0].Attempts()).Should(gomega.Equal(1))
+	g.Expect(due()).Should(gomega.BeEmpty())
+
+	g.Expect(jobs[0].Failed(ctx, "give up", nil)).Should(gomega.Succeed())
+	g.Expect(jobs[0].State()).Should(gomega.Equal(PoolJobStateFailed))
+	g.Expect(jobs[0].Attempts()).Should(gomega.Equal(2))
+	g.Expect(*jobs[0].JSON().LastError).Should(gomega.Equal("give up"))
+
+	// the action can be scheduled again once the previous job is no longer pending
+	reminder, err = pool.ScheduleJob(ctx, PoolJobActionLockReminder, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(reminder.ID()).ShouldNot(gomega.Equal(jobs[0].ID()))
+	g.Expect(reminder.Attempts()).Should(gomega.Equal(0))
+
+	draw, err := pool.ScheduleJob(ctx, PoolJobActionDrawNumbers, 0)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(*draw.RunAt()).Should(gomega.BeTemporally("==", locks))
+	g.Expect(draw.Cancel(ctx)).Should(gomega.Succeed())
+	g.Expect(draw.State()).Should(gomega.Equal(PoolJobStateCancelled))
+	g.Expect(draw.Cancel(ctx)).Should(gomega.Equal(ErrJobNotPending))
+
+	archive, err := pool.ScheduleJob(ctx, PoolJobActionArchive, 48)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(archive.RunAt()).Should(gomega.BeNil())
+
+	grid, err := pool.DefaultGrid(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	eventDate := time.Date(2020, 2, 2, 18, 30, 0, 0, time.UTC)
+	grid.SetEventDate(eventDate)
+	g.Expect(grid.Save(ctx)).Should(gomega.Succeed())
+	g.Expect(pool.RescheduleJobs(ctx)).Should(gomega.Succeed())
+
+	archive, err = pool.JobByID(ctx, archive.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(*archive.RunAt()).Should(gomega.BeTemporally("==", eventDate.Add(48*time.Hour)))
+	g.Expect(archive.Done(ctx)).Should(gomega.Succeed())
+	g.Expect(archive.State()).Should(gomega.Equal(PoolJobStateDone))
+
+	all, err := pool.Jobs(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(all)).Should(gomega.Equal(4))
+	g.Expect(all[0].ID()).Should(gomega.Equal(archive.ID()))
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// BanReasonMaxLength is the maximum length of the reason that a user was banned from a pool
+const BanReasonMaxLength = 200
+
+// ErrUserBanned happens when a user who was banned from a pool tries to join it
+var ErrUserBanned = errors.New("you have been banned from this pool")
+
+// ErrPoolOwner happens when trying to remove, ban or demote the owner of a pool
+var ErrPoolOwner = errors.New("the owner of the pool cannot be removed, banned or demoted")
+
+// PoolMember is a user who belongs to a pool
+type PoolMember struct {
+	pool    *Pool
+	userID  int64
+	isOwner bool
+	isAdmin bool
+	squares int
+	joined  time.Time
+}
+
+// PoolMemberJSON is the JSON representation of a PoolMember
+type PoolMemberJSON struct {
+	UserID  int64     `json:"userId"`
+	IsOwner bool      `json:"isOwner"`
+	IsAdmin bool      `json:"isAdmin"`
+	Squares int       `json:"squares"`
+	Joined  time.Time `json:"joined"`
+}
+
+// UserID returns the ID of the user
+func (m *PoolMember) UserID() int64 {
+	return m.userID
+}
+
+// IsOwner returns whether the member created the pool
+func (m *PoolMember) IsOwner() bool {
+	return m.isOwner
+}
+
+// IsAdmin returns whether the member is an admin of the pool. The owner is always an admin.
+func (m *PoolMember) IsAdmin() bool {
+	return m.isAdmin
+}
+
+// Squares returns the number of squares that the member has claimed
+func (m *PoolMember) Squares() int {
+	return m.squares
+}
+
+// Joined returns when the member joined the pool
+func (m *PoolMember) Joined() time.Time {
+	return m.joined
+}
+
+// JSON will return the JSON representation
+func (m *PoolMember) JSON() *PoolMemberJSON {
+	return &PoolMemberJSON{
+		UserID:  m.userID,
+		IsOwner: m.isOwner,
+		IsAdmin: m.isAdmin,
+		Squares: m.squares,
+		Joined:  m.joined,
+	}
+}
+
+type memberState struct {
+	IsAdmin bool `json:"isAdmin"`
+}
+
+// SetAdmin will promote or demote the member. The change is recorded in the audit log of the pool.
+func (m *PoolMember) SetAdmin(ctx context.Context, actor *User, remoteAddr string, isAdmin bool) error {
+	if m.isOwner {
+		return ErrPoolOwner
+	}
+
+	if m.isAdmin == isAdmin {
+		return nil
+	}
+
+	event := AuditEventMemberDemoted
+	if isAdmin {
+		event = AuditEventMemberPromoted
+	}
+
+	user := &User{Model: m.pool.model, ID: m.userID}
+	err := m.pool.model.inTx(ctx, func(tx *sql.Tx) error {
+		if err := user.setAdminOf(ctx, tx, m.pool, isAdmin); err != nil {
+			return err
+		}
+
+		return m.pool.recordAuditEvent(ctx, tx, actor, remoteAddr, event, m.userID, memberState{IsAdmin: m.isAdmin}, memberState{IsAdmin: isAdmin})
+	})
+	if err != nil {
+		return err
+	}
+
+	m.isAdmin = isAdmin
+	return nil
+}
+
+// Remove will remove the member from the pool. They will be able to join again unless they are banned. The removal is
+// recorded in the audit log of the pool.
+func (m *PoolMember) Remove(ctx context.Context, actor *User, remoteAddr string) error {
+	if m.isOwner {
+		return ErrPoolOwner
+	}
+
+	return m.pool.model.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM pools_users WHERE pool_id = $1 AND user_id = $2", m.pool.id, m.userID); err != nil {
+			return err
+		}
+
+		return m.pool.recordAuditEvent(ctx, tx, actor, remoteAddr, AuditEventMemberRemoved, m.userID, memberState{IsAdmin: m.isAdmin}, nil)
+	})
+}
+
+// Members will return the members of the pool. The owner is always first, followed by everyone else in the order they
+// joined.
+func (p *Pool) Members(ctx context.Context, offset int64, limit int) ([]*PoolMember, error) {
+	return p.members(ctx, Cursor{}, offset, limit)
+}
+
+// MembersAfter will return up to limit members of the pool that come after the cursor, along with the cursor of the
+// next page. The members are in the same order as Members.
+func (p *Pool) MembersAfter(ctx context.Context, cursor Cursor, limit int) ([]*PoolMember, Cursor, error) {
+	members, err := p.members(ctx, cursor, 0, limit+1)
+	if err != nil || len(members) <= limit {
+		return members, Cursor{}, err
+	}
+
+	members = members[:limit]
+	last := members[limit-1]
+	return members, Cursor{key: last.joined.UnixNano() / int64(time.Microsecond), id: last.userID}, nil
+}
+
+func (p *Pool) members(ctx context.Context, after Cursor, offset int64, limit int) ([]*PoolMember, error) {
+	// the key of the cursor is when the member joined in microseconds, which is as precise as the column. The owner is
+	// not in pools_users, so a cursor with their ID was the first page.
+	const query = `
+		SELECT ` + poolMemberColumns + `
+		FROM (` + poolMembersQuery + `) AS members
+		WHERE $4 = 0 OR (NOT members.is_owner AND ($4 = $2 OR
+			(members.joined, members.user_id) > (TIMESTAMP 'epoch' + $3::float8 * INTERVAL '1 microsecond', $4)))
+		ORDER BY members.is_owner DESC, members.joined, members.user_id
+		OFFSET $5
+		LIMIT $6`
+	rows, err := p.model.DB.QueryContext(ctx, query, p.id, p.userID, after.key, after.id, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]*PoolMember, 0)
+	for rows.Next() {
+		member, err := p.memberByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// MembersCount will return how many members the pool has, including the owner
+func (p *Pool) MembersCount(ctx context.Context) (int64, error) {
+	row := p.model.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+poolMembersQuery+") AS members", p.id, p.userID)
+
+	var count int64
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// MemberByUserID will return the member of the pool with the given user ID. If the user is not a member, sql.ErrNoRows
+// will be returned.
+func (p *Pool) MemberByUserID(ctx context.Context, userID int64) (*PoolMember, error) {
+	const query = `
+		SELECT ` + poolMemberColumns + `
+		FROM (` + poolMembersQuery + `) AS members
+		WHERE members.user_id = $3`
+	return p.memberByRow(p.model.DB.QueryRowContext(ctx, query, p.id, p.userID, userID).Scan)
+}
+
+func (p *Pool) memberByRow(scan scanFunc) (*PoolMember, error) {
+	m := PoolMember{pool: p}
+	if err := scan(&m.userID, &m.isOwner, &m.isAdmin, &m.joined, &m.squares); err != nil {
+		return nil, err
+	}
+
+	m.joined = m.joined.In(locationNewYork)
+
+	return &m, nil
+}
+
+// poolMembersQuery selects the owner of the pool ($2) along with everyone who joined it
+const poolMembersQuery = `
+	SELECT $2::bigint AS user_id, true AS is_owner, true AS is_admin, pools.created AS joined
+	FROM pools
+	WHERE pools.id = $1
+	UNION ALL
+	SELECT pools_users.user_id, false, pools_users.is_admin, pools_users.created
+	FROM pools_users
+	WHERE pools_users.pool_id = $1 AND pools_users.user_id <> $2`
+
+const poolMemberColumns = `
+	members.user_id,
+	members.is_owner,
+	members.is_admin,
+	members.joined,
+	(
+		SELECT COUNT(*)
+		FROM pool_squares
+		WHERE pool_squares.pool_id = $1 AND pool_squares.user_id = members.user_id AND pool_squares.state <> 'unclaimed'
+	)`
+
+// PoolBan is a user who is not allowed to join a pool
+type PoolBan struct {
+	userID   int64
+	bannedBy int64
+	reason   string
+	created  time.Time
+}
+
+// PoolBanJSON is the JSON representation of a PoolBan
+type PoolBanJSON struct {
+	UserID   int64     `json:"userId"`
+	BannedBy int64     `json:"bannedBy"`
+	Reason   string    `json:"reason"`
+	Created  time.Time `json:"created"`
+}
+
+// UserID returns the ID of the banned user
+func (b *PoolBan) UserID() int64 {
+	return b.userID
+}
+
+// Reason returns why the user was banned
+func (b *PoolBan) Reason() string {
+	return b.reason
+}
+
+// JSON will return the JSON representation
+func (b *PoolBan) JSON() *PoolBanJSON {
+	return &PoolBanJSON{
+		UserID:   b.userID,
+		BannedBy: b.bannedBy,
+		Reason:   b.reason,
+		Created:  b.created,
+	}
+}
+
+type banState struct {
+	Reason string `json:"reason"`
+}
+
+// Ban will remove the user from the pool and prevent them from joining it again, whether with the password or with an
+// invite token. The user does not need to be a member. The ban is recorded in the audit log of the pool.
+func (p *Pool) Ban(ctx context.Context, actor *User, remoteAddr string, userID int64, reason string) error {
+	if userID == p.userID {
+		return ErrPoolOwner
+	}
+
+	return p.model.inTx(ctx, func(tx *sql.Tx) error {
+		const query = `
+			INSERT INTO pool_bans (pool_id, user_id, banned_by, reason)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (pool_id, user_id) DO UPDATE SET banned_by = EXCLUDED.banned_by, reason = EXCLUDED.reason`
+		if _, err := tx.ExecContext(ctx, query, p.id, userID, actor.ID, reason); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM pools_users WHERE pool_id = $1 AND user_id = $2", p.id, userID); err != nil {
+			return err
+		}
+
+		return p.recordAuditEvent(ctx, tx, actor, remoteAddr, AuditEventMemberBanned, userID, nil, banState{Reason: reason})
+	})
+}
+
+// Unban will allow a banned user to join the pool again. If the user is not banned, sql.ErrNoRows will be returned.
+func (p *Pool) Unban(ctx context.Context, actor *User, remoteAddr string, userID int64) error {
+	return p.model.inTx(ctx, func(tx *sql.Tx) error {
+		var reason string
+		row := tx.QueryRowContext(ctx, "DELETE FROM pool_bans WHERE pool_id = $1 AND user_id = $2 RETURNING reason", p.id, userID)
+		if err := row.Scan(&reason); err != nil {
+			return err
+		}
+
+		return p.recordAuditEvent(ctx, tx, actor, remoteAddr, AuditEventMemberUnbanned, userID, banState{Reason: reason}, nil)
+	})
+}
+
+// Bans will return the users who are banned from the pool, newest first
+func (p *Pool) Bans(ctx context.Context) ([]*PoolBan, error) {
+	const query = `
+		SELECT user_id, banned_by, reason, created
+		FROM pool_bans
+		WHERE pool_id = $1
+		ORDER BY created DESC, user_id`
+	rows, err := p.model.DB.QueryContext(ctx, query, p.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bans := make([]*PoolBan, 0)
+	for rows.Next() {
+		var b PoolBan
+		if err := rows.Scan(&b.userID, &b.bannedBy, &b.reason, &b.created); err != nil {
+			return nil, err
+		}
+
+		b.created = b.created.In(locationNewYork)
+		bans = append(bans, &b)
+	}
+
+	return bans, rows.Err()
+}
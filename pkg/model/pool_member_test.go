@@ -0,0 +1,148 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestPoolMembers(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	u, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(u.JoinPool(ctx, pool)).Should(gomega.Succeed())
+
+	members, err := pool.Members(ctx, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(members)).Should(gomega.Equal(2))
+	g.Expect(members[0].UserID()).Should(gomega.Equal(owner.ID))
+	g.Expect(members[0].IsOwner()).Should(gomega.BeTrue())
+	g.Expect(members[0].IsAdmin()).Should(gomega.BeTrue())
+	g.Expect(members[1].UserID()).Should(gomega.Equal(u.ID))
+	g.Expect(members[1].IsOwner()).Should(gomega.BeFalse())
+	g.Expect(members[1].IsAdmin()).Should(gomega.BeFalse())
+
+	count, err := pool.MembersCount(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(2)))
+
+	page, cursor, err := pool.MembersAfter(ctx, Cursor{}, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(page)).Should(gomega.Equal(1))
+	g.Expect(page[0].UserID()).Should(gomega.Equal(owner.ID))
+	g.Expect(cursor.IsZero()).Should(gomega.BeFalse())
+
+	page, cursor, err = pool.MembersAfter(ctx, cursor, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(page)).Should(gomega.Equal(1))
+	g.Expect(page[0].UserID()).Should(gomega.Equal(u.ID))
+	g.Expect(cursor.IsZero()).Should(gomega.BeTrue())
+
+	member, err := pool.MemberByUserID(ctx, owner.ID)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(member.SetAdmin(ctx, owner, "127.0.0.1", false)).Should(gomega.Equal(ErrPoolOwner))
+	g.Expect(member.Remove(ctx, owner, "127.0.0.1")).Should(gomega.Equal(ErrPoolOwner))
+	g.Expect(pool.Ban(ctx, owner, "127.0.0.1", owner.ID, "")).Should(gomega.Equal(ErrPoolOwner))
+
+	member, err = pool.MemberByUserID(ctx, u.ID)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(member.SetAdmin(ctx, owner, "127.0.0.1:5000", true)).Should(gomega.Succeed())
+	g.Expect(member.IsAdmin()).Should(gomega.BeTrue())
+
+	isAdmin, err := u.IsAdminOf(ctx, pool)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(isAdmin).Should(gomega.BeTrue())
+
+	g.Expect(member.Remove(ctx, owner, "127.0.0.1:5000")).Should(gomega.Succeed())
+	_, err = pool.MemberByUserID(ctx, u.ID)
+	g.Expect(err).Should(gomega.Equal(sql.ErrNoRows))
+
+	// removed members may join again, but banned users may not
+	g.Expect(u.JoinPool(ctx, pool)).Should(gomega.Succeed())
+	g.Expect(pool.Ban(ctx, owner, "127.0.0.1:5000", u.ID, "spam")).Should(gomega.Succeed())
+
+	isMember, err := u.IsMemberOf(ctx, pool)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(isMember).Should(gomega.BeFalse())
+	g.Expect(u.JoinPool(ctx, pool)).Should(gomega.Equal(ErrUserBanned))
+
+	bans, err := pool.Bans(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(bans)).Should(gomega.Equal(1))
+	g.Expect(bans[0].UserID()).Should(gomega.Equal(u.ID))
+	g.Expect(bans[0].Reason()).Should(gomega.Equal("spam"))
+
+	g.Expect(pool.Unban(ctx, owner, "127.0.0.1:5000", u.ID)).Should(gomega.Succeed())
+	g.Expect(pool.Unban(ctx, owner, "127.0.0.1:5000", u.ID)).Should(gomega.Equal(sql.ErrNoRows))
+	g.Expect(u.JoinPool(ctx, pool)).Should(gomega.Succeed())
+
+	events, err := pool.AuditEvents(ctx, AuditFilter{}, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(events)).Should(gomega.Equal(4))
+	g.Expect(events[0].Event()).Should(gomega.Equal(AuditEventMemberUnbanned))
+	g.Expect(events[1].Event()).Should(gomega.Equal(AuditEventMemberBanned))
+	g.Expect(events[2].Event()).Should(gomega.Equal(AuditEventMemberRemoved))
+	g.Expect(events[3].Event()).Should(gomega.Equal(AuditEventMemberPromoted))
+	g.Expect(events[3].UserID()).Should(gomega.Equal(owner.ID))
+	g.Expect(events[3].TargetUserID()).Should(gomega.Equal(u.ID))
+	g.Expect(events[3].RemoteAddr()).Should(gomega.Equal("127.0.0.1"))
+	g.Expect(string(events[3].JSON().After)).Should(gomega.MatchJSON(`{"isAdmin":true}`))
+
+	count, err = pool.AuditEventsCount(ctx, AuditFilter{})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(4)))
+}
+
+func TestBanNonMember(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	// someone can be banned before they ever join
+	u, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(pool.Ban(ctx, owner, "127.0.0.1", u.ID, "known troll")).Should(gomega.Succeed())
+	g.Expect(u.JoinPool(ctx, pool)).Should(gomega.Equal(ErrUserBanned))
+
+	bans, err := pool.Bans(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(bans)).Should(gomega.Equal(1))
+	g.Expect(bans[0].UserID()).Should(gomega.Equal(u.ID))
+}
@@ -0,0 +1,668 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sqmgr/sqmgr-api/pkg/tokengen"
+)
+
+// ParticipantTokenLength is the length of the token that a participant uses to link their account
+const ParticipantTokenLength = 16
+
+// ParticipantContactMaxLength is the maximum number of characters allowed in the contact info of a participant
+const ParticipantContactMaxLength = 200
+
+// ErrParticipantLinked happens when a participant has already been linked to a different account
+var ErrParticipantLinked = errors.New("this participant has already been linked to another account")
+
+// ErrParticipantsLinked happens when merging participants that are linked to different accounts
+var ErrParticipantsLinked = errors.New("participants that are linked to different accounts cannot be merged")
+
+// ErrParticipantMergeSelf happens when merging a participant into itself
+var ErrParticipantMergeSelf = errors.New("a participant cannot be merged into itself")
+
+// PoolParticipant is someone who has squares in a pool. Every claimed square belongs to a participant, so that squares
+// can be totalled per person no matter what name they were claimed under. A member has a participant that is linked to
+// their account. Someone who does not sign in has a participant that an admin claims squares for, and they may link
+// their account later on to take the squares over.
+type PoolParticipant struct {
+	model    *Model
+	id       int64
+	poolID   int64
+	name     string
+	contact  string
+	token    string
+	userID   int64
+	created  time.Time
+	modified time.Time
+}
+
+// PoolParticipantJSON is the JSON representation of a PoolParticipant
+type PoolParticipantJSON struct {
+	ID       int64     `json:"id"`
+	Name     string    `json:"name"`
+	Contact  string    `json:"contact"`
+	Token    string    `json:"token,omitempty"`
+	UserID   int64     `json:"userId,omitempty"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+}
+
+// ID returns the ID
+func (p *PoolParticipant) ID() int64 {
+	return p.id
+}
+
+// PoolID returns the ID of the pool that the participant belongs to
+func (p *PoolParticipant) PoolID() int64 {
+	return p.poolID
+}
+
+// Name returns the name that the participant is shown as
+func (p *PoolParticipant) Name() string {
+	return p.name
+}
+
+// SetName will set the name and clamp the length to at most ClaimantMaxLength runes
+func (p *PoolParticipant) SetName(name string) {
+	if utf8.RuneCountInString(name) > ClaimantMaxLength {
+		name = string([]rune(name)[0:ClaimantMaxLength])
+	}
+
+	p.name = name
+}
+
+// Contact returns how to get a hold of the participant
+func (p *PoolParticipant) Contact() string {
+	return p.contact
+}
+
+// SetContact will set the contact info and clamp the length to at most ParticipantContactMaxLength runes
+func (p *PoolParticipant) SetContact(contact string) {
+	if utf8.RuneCountInString(contact) > ParticipantContactMaxLength {
+		contact = string([]rune(contact)[0:ParticipantContactMaxLength])
+	}
+
+	p.contact = contact
+}
+
+// Token returns the token that the participant uses to link their account. It is empty if the participant does not
+// have one.
+func (p *PoolParticipant) Token() string {
+	return p.token
+}
+
+// UserID returns the account that the participant is linked to. It is 0 if they are not linked to one.
+func (p *PoolParticipant) UserID() int64 {
+	return p.userID
+}
+
+// JSON will return the JSON representation
+func (p *PoolParticipant) JSON() *PoolParticipantJSON {
+	return &PoolParticipantJSON{
+		ID:       p.id,
+		Name:     p.name,
+		Contact:  p.contact,
+		Token:    p.token,
+		UserID:   p.userID,
+		Created:  p.created,
+		Modified: p.modified,
+	}
+}
+
+// Save will save the name and the contact info
+func (p *PoolParticipant) Save(ctx context.Context) error {
+	return p.save(ctx, p.model.DB)
+}
+
+// SaveTx will save the name and the contact info within the transaction
+func (p *PoolParticipant) SaveTx(ctx context.Context, tx *sql.Tx) error {
+	return p.save(ctx, tx)
+}
+
+func (p *PoolParticipant) save(ctx context.Context, q Queryable) error {
+	const query = `
+		UPDATE pool_participants
+		SET name = $1, contact = $2, modified = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $3
+		RETURNING modified`
+	if err := q.QueryRowContext(ctx, query, p.name, p.contact, p.id).Scan(&p.modified); err != nil {
+		return err
+	}
+
+	p.modified = p.modified.In(locationNewYork)
+	return nil
+}
+
+// ResetToken will give the participant a new token to link their account with. The old token stops working.
+func (p *PoolParticipant) ResetToken(ctx context.Context) error {
+	return p.resetToken(ctx, p.model.DB)
+}
+
+// ResetTokenTx will give the participant a new token within the transaction
+func (p *PoolParticipant) ResetTokenTx(ctx context.Context, tx *sql.Tx) error {
+	return p.resetToken(ctx, tx)
+}
+
+func (p *PoolParticipant) resetToken(ctx context.Context, q Queryable) error {
+	token, err := tokengen.Generate(ParticipantTokenLength)
+	if err != nil {
+		return err
+	}
+
+	const query = `
+		UPDATE pool_participants
+		SET token = $1, modified = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $2
+		RETURNING modified`
+	if err := q.QueryRowContext(ctx, query, token, p.id).Scan(&p.modified); err != nil {
+		return err
+	}
+
+	p.token = token
+	p.modified = p.modified.In(locationNewYork)
+	return nil
+}
+
+// NewParticipant will add a participant to the pool
+func (p *Pool) NewParticipant(ctx context.Context, name string) (*PoolParticipant, error) {
+	return p.newParticipant(ctx, p.model.DB, name)
+}
+
+// NewParticipantTx will add a participant to the pool within the transaction
+func (p *Pool) NewParticipantTx(ctx context.Context, tx *sql.Tx, name string) (*PoolParticipant, error) {
+	return p.newParticipant(ctx, tx, name)
+}
+
+func (p *Pool) newParticipant(ctx context.Context, q Queryable, name string) (*PoolParticipant, error) {
+	id, err := p.model.insertParticipant(ctx, q, p.id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+		SELECT ` + poolParticipantColumns + `
+		FROM pool_participants
+		WHERE id = $1`
+	return p.model.poolParticipantByRow(q.QueryRowContext(ctx, query, id).Scan)
+}
+
+// insertParticipant will add a participant that is not linked to an account
+func (m *Model) insertParticipant(ctx context.Context, q Queryable, poolID int64, name string) (int64, error) {
+	if utf8.RuneCountInString(name) > ClaimantMaxLength {
+		name = string([]rune(name)[0:ClaimantMaxLength])
+	}
+
+	token, err := tokengen.Generate(ParticipantTokenLength)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	const query = "INSERT INTO pool_participants (pool_id, name, token) VALUES ($1, $2, $3) RETURNING id"
+	err = q.QueryRowContext(ctx, query, poolID, name, token).Scan(&id)
+	return id, err
+}
+
+// participantIDFor returns the participant that a square claimed by the user, or claimed under the name if there is
+// no user, belongs to. The participant is added if there is none.
+func (m *Model) participantIDFor(ctx context.Context, q Queryable, poolID int64, userID int64, claimant string) (int64, error) {
+	var id int64
+	if userID > 0 {
+		const query = `
+			INSERT INTO pool_participants (pool_id, name, user_id)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (pool_id, user_id) WHERE user_id IS NOT NULL DO UPDATE SET modified = pool_participants.modified
+			RETURNING id`
+		err := q.QueryRowContext(ctx, query, poolID, claimant, userID).Scan(&id)
+		return id, err
+	}
+
+	const query = `
+		SELECT id
+		FROM pool_participants
+		WHERE pool_id = $1 AND user_id IS NULL AND name = $2
+		ORDER BY id
+		LIMIT 1`
+	err := q.QueryRowContext(ctx, query, poolID, claimant).Scan(&id)
+	if err == sql.ErrNoRows {
+		return m.insertParticipant(ctx, q, poolID, claimant)
+	}
+
+	return id, err
+}
+
+// Participants will return the participants of the pool, ordered by name
+func (p *Pool) Participants(ctx context.Context, offset int64, limit int) ([]*PoolParticipant, error) {
+	const query = `
+		SELECT ` + poolParticipantColumns + `
+		FROM pool_participants
+		WHERE pool_id = $1
+		ORDER BY LOWER(name), id
+		OFFSET $2
+		LIMIT $3`
+	rows, err := p.model.DB.QueryContext(ctx, query, p.id, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	participants := make([]*PoolParticipant, 0)
+	for rows.Next() {
+		participant, err := p.model.poolParticipantByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		participants = append(participants, participant)
+	}
+
+	return participants, rows.Err()
+}
+
+// ParticipantsCount will return the number of participants in the pool
+func (p *Pool) ParticipantsCount(ctx context.Context) (int64, error) {
+	var count int64
+	err := p.model.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM pool_participants WHERE pool_id = $1", p.id).Scan(&count)
+	return count, err
+}
+
+// ParticipantSquareCounts returns how many squares each participant of the pool has in each state. Participants
+// without any squares are left out.
+func (p *Pool) ParticipantSquareCounts(ctx context.Context) (map[int64]map[PoolSquareState]int, error) {
+	const query = `
+		SELECT participant_id, state, COUNT(*)
+		FROM pool_squares
+		WHERE pool_id = $1 AND participant_id IS NOT NULL
+		GROUP BY participant_id, state`
+	rows, err := p.model.DB.QueryContext(ctx, query, p.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]map[PoolSquareState]int)
+	for rows.Next() {
+		var participantID int64
+		var state PoolSquareState
+		var count int
+		if err := rows.Scan(&participantID, &state, &count); err != nil {
+			return nil, err
+		}
+
+		if counts[participantID] == nil {
+			counts[participantID] = make(map[PoolSquareState]int)
+		}
+		counts[participantID][state] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// ParticipantByID will return the participant. If the participant does not belong to the pool, sql.ErrNoRows will be
+// returned.
+func (p *Pool) ParticipantByID(ctx context.Context, id int64) (*PoolParticipant, error) {
+	const query = `
+		SELECT ` + poolParticipantColumns + `
+		FROM pool_participants
+		WHERE pool_id = $1 AND id = $2`
+	return p.model.poolParticipantByRow(p.model.DB.QueryRowContext(ctx, query, p.id, id).Scan)
+}
+
+// ParticipantByToken will return the participant with the token
+func (m *Model) ParticipantByToken(ctx context.Context, token string) (*PoolParticipant, error) {
+	const query = `
+		SELECT ` + poolParticipantColumns + `
+		FROM pool_participants
+		WHERE token = $1`
+	return m.poolParticipantByRow(m.DB.QueryRowContext(ctx, query, token).Scan)
+}
+
+// Delete will remove the participant. Their squares stay claimed under their name.
+func (p *PoolParticipant) Delete(ctx context.Context) error {
+	return p.delete(ctx, p.model.DB)
+}
+
+// DeleteTx will remove the participant within the transaction
+func (p *PoolParticipant) DeleteTx(ctx context.Context, tx *sql.Tx) error {
+	return p.delete(ctx, tx)
+}
+
+func (p *PoolParticipant) delete(ctx context.Context, q Queryable) error {
+	_, err := q.ExecContext(ctx, "DELETE FROM pool_participants WHERE id = $1", p.id)
+	return err
+}
+
+// ClaimOnBehalf will claim unclaimed squares for someone who does not sign in. If participant is not nil, the squares
+// are claimed under the participant's name and will belong to them. Otherwise, they belong to the participant with the
+// claimant's name. Any squares after the first are claimed as its secondary squares. If a square has already been
+// claimed, ErrSquareAlreadyClaimed will be returned and none of the squares are claimed.
+func (p *Pool) ClaimOnBehalf(ctx context.Context, claimant string, participant *PoolParticipant, remoteAddr string, squares ...*PoolSquare) error {
+	return p.model.inTx(ctx, func(tx *sql.Tx) error {
+		return p.claimOnBehalf(ctx, tx, claimant, participant, remoteAddr, squares)
+	})
+}
+
+// ClaimOnBehalfTx will claim the squares as ClaimOnBehalf does within the transaction
+func (p *Pool) ClaimOnBehalfTx(ctx context.Context, tx *sql.Tx, claimant string, participant *PoolParticipant, remoteAddr string, squares ...*PoolSquare) error {
+	return p.claimOnBehalf(ctx, tx, claimant, participant, remoteAddr, squares)
+}
+
+// ClaimForNewParticipant will add a participant to the pool and claim the squares for them, as ClaimOnBehalf does
+func (p *Pool) ClaimForNewParticipant(ctx context.Context, name string, remoteAddr string, squares ...*PoolSquare) (*PoolParticipant, error) {
+	var participant *PoolParticipant
+	err := p.model.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		participant, err = p.ClaimForNewParticipantTx(ctx, tx, name, remoteAddr, squares...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return participant, nil
+}
+
+// ClaimForNewParticipantTx will add the participant and claim the squares for them within the transaction
+func (p *Pool) ClaimForNewParticipantTx(ctx context.Context, tx *sql.Tx, name string, remoteAddr string, squares ...*PoolSquare) (*PoolParticipant, error) {
+	participant, err := p.newParticipant(ctx, tx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.claimOnBehalf(ctx, tx, name, participant, remoteAddr, squares); err != nil {
+		return nil, err
+	}
+
+	return participant, nil
+}
+
+func (p *Pool) claimOnBehalf(ctx context.Context, tx *sql.Tx, claimant string, participant *PoolParticipant, remoteAddr string, squares []*PoolSquare) error {
+	var participantID int64
+	var userID int64
+	note := fmt.Sprintf("admin: claimed for `%s`", claimant)
+	if participant != nil {
+		participantID = participant.id
+		userID = participant.userID
+		claimant = participant.name
+		note = fmt.Sprintf("admin: claimed for participant `%s`", claimant)
+	}
+
+	for i, square := range squares {
+		// admins are allowed to change any square, so make sure that nobody has claimed this one
+		var state PoolSquareState
+		if err := tx.QueryRowContext(ctx, "SELECT state FROM pool_squares WHERE id = $1 FOR UPDATE", square.ID).Scan(&state); err != nil {
+			return err
+		}
+
+		if state != PoolSquareStateUnclaimed {
+			return ErrSquareAlreadyClaimed
+		}
+
+		squareNote := note
+		if i > 0 {
+			if err := square.SetParentSquare(ctx, tx, squares[0]); err != nil {
+				return err
+			}
+
+			square.ParentID = squares[0].ID
+			square.ParentSquareID = squares[0].SquareID
+			squareNote += " (secondary)"
+		}
+
+		square.SetClaimant(claimant)
+		square.SetUserID(userID)
+		square.State = PoolSquareStateClaimed
+		square.participantID = participantID
+
+		if err := square.Save(ctx, tx, true, PoolSquareLog{
+			RemoteAddr: remoteAddr,
+			Note:       squareNote,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Link will link the participant to the user's account. The user joins the pool if they have not already, and every
+// square that belongs to the participant becomes theirs. If the user already has a participant in the pool, the two
+// are merged. If the participant was linked to another account, ErrParticipantLinked will be returned. The squares that
+// now belong to the user are returned.
+func (p *PoolParticipant) Link(ctx context.Context, user *User, remoteAddr string) ([]*PoolSquare, error) {
+	pool, err := p.model.PoolByID(p.poolID)
+	if err != nil {
+		return nil, err
+	}
+
+	note := fmt.Sprintf("participant: `%s` linked their account", p.name)
+	linked := p
+	err = p.model.inTx(ctx, func(tx *sql.Tx) error {
+		var userID int64
+		if err := tx.QueryRowContext(ctx, "SELECT COALESCE(user_id, 0) FROM pool_participants WHERE id = $1 FOR UPDATE", p.id).Scan(&userID); err != nil {
+			return err
+		}
+
+		if userID > 0 && userID != user.ID {
+			return ErrParticipantLinked
+		}
+
+		// the pool is only joined once the participant is known to be free to link
+		if err := user.joinPool(ctx, tx, pool); err != nil {
+			return err
+		}
+
+		if userID == user.ID {
+			return nil
+		}
+
+		const query = `
+			SELECT ` + poolParticipantColumns + `
+			FROM pool_participants
+			WHERE pool_id = $1 AND user_id = $2
+			FOR UPDATE`
+		existing, err := p.model.poolParticipantByRow(tx.QueryRowContext(ctx, query, p.poolID, user.ID).Scan)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		// the user already has squares of their own, so this participant's squares are added to them
+		if existing != nil {
+			linked = existing
+			return existing.mergeParticipant(ctx, tx, p, note, remoteAddr)
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE pool_participants SET user_id = $1, token = NULL, modified = (NOW() AT TIME ZONE 'utc') WHERE id = $2", user.ID, p.id); err != nil {
+			return err
+		}
+
+		return p.model.moveParticipantSquares(ctx, tx, p.id, p.id, user.ID, note, remoteAddr)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if linked == p {
+		p.userID = user.ID
+		p.token = ""
+	}
+
+	return linked.Squares(ctx)
+}
+
+// Merge will move the squares and payments of the other participants to this one and remove the others. This is used
+// when the same person ended up with more than one participant, such as when squares were claimed under slightly
+// different names. Participants linked to different accounts cannot be merged.
+func (p *PoolParticipant) Merge(ctx context.Context, remoteAddr string, others ...*PoolParticipant) error {
+	return p.model.inTx(ctx, func(tx *sql.Tx) error {
+		return p.MergeTx(ctx, tx, remoteAddr, others...)
+	})
+}
+
+// MergeTx will move the squares and payments of the other participants to this one within the transaction
+func (p *PoolParticipant) MergeTx(ctx context.Context, tx *sql.Tx, remoteAddr string, others ...*PoolParticipant) error {
+	for _, other := range others {
+		if other.id == p.id {
+			return ErrParticipantMergeSelf
+		}
+
+		if other.poolID != p.poolID {
+			return sql.ErrNoRows
+		}
+	}
+
+	userID, token := p.userID, p.token
+	for _, other := range others {
+		note := fmt.Sprintf("admin: merged participant `%s` into `%s`", other.name, p.name)
+		if err := p.mergeParticipant(ctx, tx, other, note, remoteAddr); err != nil {
+			// the link did not move after all
+			p.userID, p.token = userID, token
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeParticipant will move the squares and payments of the other participant to this one and remove the other
+// participant. If only the other participant is linked to an account, this participant takes over the link.
+func (p *PoolParticipant) mergeParticipant(ctx context.Context, tx *sql.Tx, other *PoolParticipant, note string, remoteAddr string) error {
+	if p.userID > 0 && other.userID > 0 && p.userID != other.userID {
+		return ErrParticipantsLinked
+	}
+
+	userID := p.userID
+	if userID == 0 {
+		userID = other.userID
+	}
+
+	if err := p.model.moveParticipantSquares(ctx, tx, other.id, p.id, userID, note, remoteAddr); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE pool_payments SET participant_id = $1 WHERE participant_id = $2", p.id, other.id); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM pool_participants WHERE id = $1", other.id); err != nil {
+		return err
+	}
+
+	// the other participant has to be removed before the link can move, since an account has one participant per pool
+	if userID != p.userID {
+		if _, err := tx.ExecContext(ctx, "UPDATE pool_participants SET user_id = $1, token = NULL, modified = (NOW() AT TIME ZONE 'utc') WHERE id = $2", userID, p.id); err != nil {
+			return err
+		}
+
+		p.userID = userID
+		p.token = ""
+	}
+
+	// what the other participant paid now goes towards these squares as well
+	_, err := p.model.applyPayments(ctx, tx, p.poolID, p.id, remoteAddr)
+	return err
+}
+
+// moveParticipantSquares will give the squares of one participant to another. Squares that are not claimed by an
+// account are given to userID, if it is not 0. The change is logged against every square. Neither the state nor the
+// claimant changes, so the log entries are made here rather than with update_pool_square.
+func (m *Model) moveParticipantSquares(ctx context.Context, tx *sql.Tx, fromID, toID, userID int64, note string, remoteAddr string) error {
+	var ip *string
+	if remoteAddr != "" {
+		addr := ipFromRemoteAddr(remoteAddr)
+		ip = &addr
+	}
+
+	const query = `
+		WITH moved AS (
+			UPDATE pool_squares
+			SET participant_id = $1,
+			    user_id = COALESCE(user_id, NULLIF($2, 0)),
+			    modified = (NOW() AT TIME ZONE 'utc')
+			WHERE participant_id = $3
+			RETURNING id, user_id, state, claimant
+		)
+		INSERT INTO pool_squares_logs (pool_square_id, user_id, state, claimant, note, remote_addr)
+		SELECT id, user_id, state, claimant, $4, $5
+		FROM moved`
+	_, err := tx.ExecContext(ctx, query, toID, userID, fromID, note, ip)
+	return err
+}
+
+// Squares returns the squares that belong to the participant
+func (p *PoolParticipant) Squares(ctx context.Context) ([]*PoolSquare, error) {
+	const query = `
+		SELECT ` + poolSquareColumns + `
+		FROM
+			pool_squares ps
+		LEFT JOIN
+			pool_squares ps2 ON ps.parent_id = ps2.id
+		WHERE
+			ps.participant_id = $1
+		ORDER BY
+			ps.grid_id NULLS FIRST, ps.square_id`
+	rows, err := p.model.DB.QueryContext(ctx, query, p.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// this is a little hacky. it's done to be able to call squareByRow()
+	pool := &Pool{model: p.model, id: p.poolID}
+
+	squares := make([]*PoolSquare, 0)
+	for rows.Next() {
+		square, err := pool.squareByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		squares = append(squares, square)
+	}
+
+	return squares, rows.Err()
+}
+
+const poolParticipantColumns = `
+	id,
+	pool_id,
+	name,
+	contact,
+	COALESCE(token, ''),
+	COALESCE(user_id, 0),
+	created,
+	modified`
+
+func (m *Model) poolParticipantByRow(scan scanFunc) (*PoolParticipant, error) {
+	p := PoolParticipant{model: m}
+	if err := scan(&p.id, &p.poolID, &p.name, &p.contact, &p.token, &p.userID, &p.created, &p.modified); err != nil {
+		return nil, err
+	}
+
+	p.created = p.created.In(locationNewYork)
+	p.modified = p.modified.In(locationNewYork)
+
+	return &p, nil
+}
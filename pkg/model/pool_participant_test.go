@@ -0,0 +1,195 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestPoolParticipant(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, IssuerAuth0, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	squares, err := pool.Squares()
+	g.Expect(err).Should(gomega.Succeed())
+
+	// a name without a participant gets one with the same name
+	g.Expect(pool.ClaimOnBehalf(ctx, "Grandma", nil, "127.0.0.1", squares[1])).Should(gomega.Succeed())
+	square, err := pool.SquareBySquareID(1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.Claimant()).Should(gomega.Equal("Grandma"))
+	g.Expect(square.UserID()).Should(gomega.Equal(int64(0)))
+	g.Expect(square.ParticipantID()).ShouldNot(gomega.Equal(int64(0)))
+	grandmaID := square.ParticipantID()
+
+	// squares that were already claimed are left alone
+	g.Expect(pool.ClaimOnBehalf(ctx, "Grandpa", nil, "127.0.0.1", squares[1])).Should(gomega.Equal(ErrSquareAlreadyClaimed))
+
+	g.Expect(pool.ClaimOnBehalf(ctx, "Grandma", nil, "127.0.0.1", squares[6])).Should(gomega.Succeed())
+	square, err = pool.SquareBySquareID(6)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.ParticipantID()).Should(gomega.Equal(grandmaID))
+
+	participant, err := pool.ClaimForNewParticipant(ctx, "Uncle Bob", "127.0.0.1", squares[2])
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(participant.Name()).Should(gomega.Equal("Uncle Bob"))
+	g.Expect(len(participant.Token())).Should(gomega.Equal(ParticipantTokenLength))
+	g.Expect(pool.ClaimOnBehalf(ctx, "", participant, "127.0.0.1", squares[3])).Should(gomega.Succeed())
+
+	square, err = pool.SquareBySquareID(3)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.Claimant()).Should(gomega.Equal("Uncle Bob"))
+	g.Expect(square.ParticipantID()).Should(gomega.Equal(participant.ID()))
+
+	count, err := pool.ParticipantsCount(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(2)))
+
+	// linking the account hands the squares over and lists the pool under the user's pools
+	user, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	token := participant.Token()
+	participant, err = m.ParticipantByToken(ctx, token)
+	g.Expect(err).Should(gomega.Succeed())
+
+	linked, err := participant.Link(ctx, user, "127.0.0.1")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(linked)).Should(gomega.Equal(2))
+	g.Expect(linked[0].UserID()).Should(gomega.Equal(user.ID))
+	g.Expect(participant.UserID()).Should(gomega.Equal(user.ID))
+
+	// the token cannot be used again once the account is linked
+	g.Expect(participant.Token()).Should(gomega.BeEmpty())
+	_, err = m.ParticipantByToken(ctx, token)
+	g.Expect(err).Should(gomega.Equal(sql.ErrNoRows))
+
+	pools, err := m.PoolsJoinedByUserID(ctx, user.ID, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(pools)).Should(gomega.Equal(1))
+	g.Expect(pools[0].ID()).Should(gomega.Equal(pool.ID()))
+
+	other, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	_, err = participant.Link(ctx, other, "127.0.0.1")
+	g.Expect(err).Should(gomega.Equal(ErrParticipantLinked))
+
+	// a participant that is linked to another account does not let the account join the pool
+	pools, err = m.PoolsJoinedByUserID(ctx, other.ID, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(pools)).Should(gomega.Equal(0))
+
+	// unclaiming a square takes it away from the participant
+	square.State = PoolSquareStateUnclaimed
+	g.Expect(square.Save(ctx, m.DB, true, PoolSquareLog{})).Should(gomega.Succeed())
+	square, err = pool.SquareBySquareID(3)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.ParticipantID()).Should(gomega.Equal(int64(0)))
+
+	// squares claimed by a member belong to the participant linked to their account
+	square, err = pool.SquareBySquareID(5)
+	g.Expect(err).Should(gomega.Succeed())
+	square.SetClaimant("Bob")
+	square.SetUserID(user.ID)
+	square.State = PoolSquareStateClaimed
+	g.Expect(square.Save(ctx, m.DB, false, PoolSquareLog{})).Should(gomega.Succeed())
+	g.Expect(square.ParticipantID()).Should(gomega.Equal(participant.ID()))
+}
+
+func TestPoolParticipantMerge(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, IssuerAuth0, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	squares, err := pool.Squares()
+	g.Expect(err).Should(gomega.Succeed())
+
+	g.Expect(pool.ClaimOnBehalf(ctx, "Bob", nil, "127.0.0.1", squares[1])).Should(gomega.Succeed())
+	g.Expect(pool.ClaimOnBehalf(ctx, "bob ", nil, "127.0.0.1", squares[2], squares[3])).Should(gomega.Succeed())
+
+	participants, err := pool.Participants(ctx, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(participants)).Should(gomega.Equal(2))
+
+	bob, other := participants[0], participants[1]
+	if bob.Name() != "Bob" {
+		bob, other = other, bob
+	}
+
+	g.Expect(bob.Merge(ctx, "127.0.0.1", bob)).Should(gomega.Equal(ErrParticipantMergeSelf))
+	g.Expect(bob.Merge(ctx, "127.0.0.1", other)).Should(gomega.Succeed())
+
+	count, err := pool.ParticipantsCount(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(1)))
+
+	// the claimant is left as it was for display
+	bobSquares, err := bob.Squares(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(bobSquares)).Should(gomega.Equal(3))
+	g.Expect(bobSquares[1].Claimant()).Should(gomega.Equal("bob "))
+
+	square, err := pool.SquareBySquareID(2)
+	g.Expect(err).Should(gomega.Succeed())
+	square.State = PoolSquareStatePaidFull
+	g.Expect(square.Save(ctx, m.DB, true, PoolSquareLog{})).Should(gomega.Succeed())
+	g.Expect(square.ParticipantID()).Should(gomega.Equal(bob.ID()))
+
+	counts, err := pool.ParticipantSquareCounts(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(counts[bob.ID()][PoolSquareStateClaimed]).Should(gomega.Equal(2))
+	g.Expect(counts[bob.ID()][PoolSquareStatePaidFull]).Should(gomega.Equal(1))
+
+	// a participant linked to an account takes over the squares of another participant linked to the same account
+	user, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	g.Expect(bob.ResetToken(ctx)).Should(gomega.Succeed())
+	_, err = bob.Link(ctx, user, "127.0.0.1")
+	g.Expect(err).Should(gomega.Succeed())
+
+	sam, err := pool.ClaimForNewParticipant(ctx, "Sam", "127.0.0.1", squares[4])
+	g.Expect(err).Should(gomega.Succeed())
+
+	linked, err := sam.Link(ctx, user, "127.0.0.1")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(linked)).Should(gomega.Equal(4))
+
+	_, err = pool.ParticipantByID(ctx, sam.ID())
+	g.Expect(err).Should(gomega.Equal(sql.ErrNoRows))
+}
@@ -0,0 +1,664 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PaymentMethod is how a payment was made
+type PaymentMethod string
+
+// Allowed payment methods
+const (
+	PaymentMethodCash   PaymentMethod = "cash"
+	PaymentMethodCheck  PaymentMethod = "check"
+	PaymentMethodVenmo  PaymentMethod = "venmo"
+	PaymentMethodPayPal PaymentMethod = "paypal"
+	PaymentMethodZelle  PaymentMethod = "zelle"
+	PaymentMethodOther  PaymentMethod = "other"
+)
+
+// PaymentMethods are the valid payment methods
+var PaymentMethods = []PaymentMethod{
+	PaymentMethodCash,
+	PaymentMethodCheck,
+	PaymentMethodVenmo,
+	PaymentMethodPayPal,
+	PaymentMethodZelle,
+	PaymentMethodOther,
+}
+
+// IsValid will ensure that it's a valid method
+func (m PaymentMethod) IsValid() bool {
+	for _, method := range PaymentMethods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrPaymentVoided happens when voiding a payment that has already been voided
+var ErrPaymentVoided = errors.New("this payment has already been voided")
+
+// ErrPaymentSquareUnclaimed happens when recording a payment for a square that nobody has claimed
+var ErrPaymentSquareUnclaimed = errors.New("payments can only be recorded for claimed squares")
+
+// PoolPayment is an entry in the payment ledger of a pool. A payment is made by a participant and may be for one of
+// their squares. Payments are never removed, but are voided instead.
+type PoolPayment struct {
+	model         *Model
+	id            int64
+	poolID        int64
+	participantID int64
+	poolSquareID  int64
+	gridID        int64
+	squareID      int
+	amount        int64
+	method        PaymentMethod
+	note          string
+	recordedBy    int64
+	voided        *time.Time
+	voidedBy      int64
+	created       time.Time
+}
+
+// PoolPaymentJSON is the JSON representation of a PoolPayment
+type PoolPaymentJSON struct {
+	ID            int64         `json:"id"`
+	ParticipantID int64         `json:"participantId,omitempty"`
+	GridID        int64         `json:"gridId,omitempty"`
+	SquareID      int           `json:"squareId,omitempty"`
+	Amount        int64         `json:"amount"`
+	Method        PaymentMethod `json:"method"`
+	Note          string        `json:"note"`
+	RecordedBy    int64         `json:"recordedBy"`
+	Voided        *time.Time    `json:"voided,omitempty"`
+	VoidedBy      int64         `json:"voidedBy,omitempty"`
+	Created       time.Time     `json:"created"`
+}
+
+// ID returns the ID
+func (p *PoolPayment) ID() int64 {
+	return p.id
+}
+
+// ParticipantID returns the participant who made the payment. It is 0 if the participant has been removed.
+func (p *PoolPayment) ParticipantID() int64 {
+	return p.participantID
+}
+
+// Amount returns the amount in cents
+func (p *PoolPayment) Amount() int64 {
+	return p.amount
+}
+
+// Method returns how the payment was made
+func (p *PoolPayment) Method() PaymentMethod {
+	return p.method
+}
+
+// Voided returns when the payment was voided, if it was
+func (p *PoolPayment) Voided() *time.Time {
+	return p.voided
+}
+
+// JSON will return the JSON representation
+func (p *PoolPayment) JSON() *PoolPaymentJSON {
+	return &PoolPaymentJSON{
+		ID:            p.id,
+		ParticipantID: p.participantID,
+		GridID:        p.gridID,
+		SquareID:      p.squareID,
+		Amount:        p.amount,
+		Method:        p.method,
+		Note:          p.note,
+		RecordedBy:    p.recordedBy,
+		Voided:        p.voided,
+		VoidedBy:      p.voidedBy,
+		Created:       p.created,
+	}
+}
+
+// ParticipantBalance is how much a participant owes for their squares and how much they have paid. All amounts are in
+// cents.
+type ParticipantBalance struct {
+	Owed    int64 `json:"owed"`
+	Paid    int64 `json:"paid"`
+	Balance int64 `json:"balance"`
+}
+
+// PaymentTotals is how much is owed for every square of a pool and how much has been paid. All amounts are in cents.
+type PaymentTotals struct {
+	Owed    int64                   `json:"owed"`
+	Paid    int64                   `json:"paid"`
+	Balance int64                   `json:"balance"`
+	Methods map[PaymentMethod]int64 `json:"methods"`
+}
+
+// RecordPayment will add a payment to the ledger of the pool. If square is not nil, the payment is for that square
+// and is made by the participant it belongs to. Otherwise, it is made by the participant and is applied to their
+// squares in order. The states of the participant's squares are updated to match what they have paid, and the squares
+// that changed are returned.
+func (p *Pool) RecordPayment(ctx context.Context, participant *PoolParticipant, square *PoolSquare, amount int64, method PaymentMethod, note string, recordedBy int64, remoteAddr string) (*PoolPayment, []*PoolSquare, error) {
+	var payment *PoolPayment
+	var changed []*PoolSquare
+	err := p.model.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		payment, changed, err = p.RecordPaymentTx(ctx, tx, participant, square, amount, method, note, recordedBy, remoteAddr)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return payment, changed, nil
+}
+
+// RecordPaymentTx will add a payment to the ledger of the pool within the transaction
+func (p *Pool) RecordPaymentTx(ctx context.Context, tx *sql.Tx, participant *PoolParticipant, square *PoolSquare, amount int64, method PaymentMethod, note string, recordedBy int64, remoteAddr string) (*PoolPayment, []*PoolSquare, error) {
+	var participantID int64
+	var poolSquareID *int64
+	if square != nil {
+		var state PoolSquareState
+		const query = "SELECT state, COALESCE(participant_id, 0) FROM pool_squares WHERE id = $1 AND pool_id = $2"
+		if err := tx.QueryRowContext(ctx, query, square.ID, p.id).Scan(&state, &participantID); err != nil {
+			return nil, nil, err
+		}
+
+		if state == PoolSquareStateUnclaimed || state == PoolSquareStateHeld || participantID == 0 {
+			return nil, nil, ErrPaymentSquareUnclaimed
+		}
+
+		poolSquareID = &square.ID
+	} else {
+		if participant == nil || participant.poolID != p.id {
+			return nil, nil, sql.ErrNoRows
+		}
+
+		participantID = participant.id
+	}
+
+	var id int64
+	const query = `
+		INSERT INTO pool_payments (pool_id, participant_id, pool_square_id, amount, method, note, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+	if err := tx.QueryRowContext(ctx, query, p.id, participantID, poolSquareID, amount, method, note, recordedBy).Scan(&id); err != nil {
+		return nil, nil, err
+	}
+
+	payment, err := p.model.paymentByID(ctx, tx, p.id, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changed, err := p.model.applyPayments(ctx, tx, p.id, participantID, remoteAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return payment, changed, nil
+}
+
+// Void will void the payment. The states of the participant's squares are updated to match what they have paid, and
+// the squares that changed are returned. If the payment was already voided, ErrPaymentVoided will be returned.
+func (p *PoolPayment) Void(ctx context.Context, voidedBy int64, remoteAddr string) ([]*PoolSquare, error) {
+	var changed []*PoolSquare
+	err := p.model.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		changed, err = p.VoidTx(ctx, tx, voidedBy, remoteAddr)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+// VoidTx will void the payment within the transaction
+func (p *PoolPayment) VoidTx(ctx context.Context, tx *sql.Tx, voidedBy int64, remoteAddr string) ([]*PoolSquare, error) {
+	var voided time.Time
+	const query = `
+		UPDATE pool_payments
+		SET voided = (NOW() AT TIME ZONE 'utc'), voided_by = $1
+		WHERE id = $2 AND voided IS NULL
+		RETURNING voided, COALESCE(participant_id, 0)`
+	if err := tx.QueryRowContext(ctx, query, voidedBy, p.id).Scan(&voided, &p.participantID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPaymentVoided
+		}
+
+		return nil, err
+	}
+
+	voided = voided.In(locationNewYork)
+	p.voided = &voided
+	p.voidedBy = voidedBy
+
+	if p.participantID == 0 {
+		return nil, nil
+	}
+
+	return p.model.applyPayments(ctx, tx, p.poolID, p.participantID, remoteAddr)
+}
+
+// applyPayments will update the states of the participant's squares to match what they have paid. Payments for a
+// square go to that square first, and everything else is applied to the squares in order. Squares without a price are
+// left alone, as are the squares of participants who have never had a payment recorded, so that pools which do not use
+// the ledger can keep marking squares as paid by hand. The squares that changed are returned.
+func (m *Model) applyPayments(ctx context.Context, tx *sql.Tx, poolID, participantID int64, remoteAddr string) ([]*PoolSquare, error) {
+	// payments for the same participant are applied one at a time
+	var hasPayments bool
+	const lockQuery = `
+		SELECT EXISTS(SELECT 1 FROM pool_payments WHERE participant_id = pp.id)
+		FROM pool_participants pp
+		WHERE pp.id = $1
+		FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, lockQuery, participantID).Scan(&hasPayments); err != nil {
+		return nil, err
+	}
+
+	if !hasPayments {
+		return nil, nil
+	}
+
+	shared, prices, err := m.squarePrices(ctx, tx, poolID)
+	if err != nil {
+		return nil, err
+	}
+
+	bySquare := make(map[int64]int64)
+	const paymentsQuery = `
+		SELECT COALESCE(pool_square_id, 0), SUM(amount)
+		FROM pool_payments
+		WHERE participant_id = $1 AND voided IS NULL
+		GROUP BY 1`
+	rows, err := tx.QueryContext(ctx, paymentsQuery, participantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var poolSquareID, amount int64
+		if err := rows.Scan(&poolSquareID, &amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		bySquare[poolSquareID] = amount
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const squaresQuery = `
+		SELECT ` + poolSquareColumns + `
+		FROM
+			pool_squares ps
+		LEFT JOIN
+			pool_squares ps2 ON ps.parent_id = ps2.id
+		WHERE
+			ps.participant_id = $1 AND ps.state IN ('claimed', 'paid-partial', 'paid-full')
+		ORDER BY
+			ps.grid_id NULLS FIRST, ps.square_id
+		FOR UPDATE OF ps`
+	rows, err = tx.QueryContext(ctx, squaresQuery, participantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// this is a little hacky. it's done to be able to call squareByRow()
+	pool := &Pool{model: m, id: poolID}
+
+	squares := make([]*PoolSquare, 0)
+	for rows.Next() {
+		square, err := pool.squareByRow(rows.Scan)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		squares = append(squares, square)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// a payment for a square goes to that square. whatever is left over, or was paid for a square the participant no
+	// longer has, is credit for the rest of their squares.
+	paid := make(map[int64]int64)
+	for _, square := range squares {
+		price := squarePrice(square, shared, prices)
+		amount := bySquare[square.ID]
+		delete(bySquare, square.ID)
+
+		if amount > price {
+			bySquare[0] += amount - price
+			amount = price
+		}
+
+		paid[square.ID] = amount
+	}
+
+	var credit int64
+	for _, amount := range bySquare {
+		credit += amount
+	}
+
+	changed := make([]*PoolSquare, 0)
+	for _, square := range squares {
+		price := squarePrice(square, shared, prices)
+		if price == 0 {
+			continue
+		}
+
+		if need := price - paid[square.ID]; need > 0 && credit > 0 {
+			if need > credit {
+				need = credit
+			}
+
+			paid[square.ID] += need
+			credit -= need
+		}
+
+		state := PoolSquareStateClaimed
+		if paid[square.ID] >= price {
+			state = PoolSquareStatePaidFull
+		} else if paid[square.ID] > 0 {
+			state = PoolSquareStatePaidPartial
+		}
+
+		if state == square.State {
+			continue
+		}
+
+		square.State = state
+		if err := square.Save(ctx, tx, true, PoolSquareLog{
+			RemoteAddr: remoteAddr,
+			Note:       fmt.Sprintf("payment: %d of %d cents paid", paid[square.ID], price),
+		}); err != nil {
+			return nil, err
+		}
+
+		changed = append(changed, square)
+	}
+
+	return changed, nil
+}
+
+// squarePrices returns what a square of the shared sheet costs and what a square of each grid's own sheet costs. A
+// square of the shared sheet is played in every grid, so it costs the price of every grid together.
+func (m *Model) squarePrices(ctx context.Context, q Queryable, poolID int64) (int64, map[int64]int64, error) {
+	const query = `
+		SELECT g.id, gs.square_price
+		FROM grids g
+		INNER JOIN grid_settings gs ON gs.grid_id = g.id
+		WHERE g.pool_id = $1 AND g.state = 'active'`
+	rows, err := q.QueryContext(ctx, query, poolID)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	var shared int64
+	prices := make(map[int64]int64)
+	for rows.Next() {
+		var gridID, price int64
+		if err := rows.Scan(&gridID, &price); err != nil {
+			return 0, nil, err
+		}
+
+		shared += price
+		prices[gridID] = price
+	}
+
+	return shared, prices, rows.Err()
+}
+
+func squarePrice(square *PoolSquare, shared int64, prices map[int64]int64) int64 {
+	if square.GridID > 0 {
+		return prices[square.GridID]
+	}
+
+	return shared
+}
+
+// ParticipantBalances returns how much each participant of the pool owes and has paid. Participants without any
+// squares or payments are left out.
+func (p *Pool) ParticipantBalances(ctx context.Context) (map[int64]*ParticipantBalance, error) {
+	balances, _, err := p.balances(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// squares and payments of participants who have been removed are only part of the totals
+	delete(balances, 0)
+	return balances, nil
+}
+
+// Balance returns how much the participant owes and has paid
+func (p *PoolParticipant) Balance(ctx context.Context) (*ParticipantBalance, error) {
+	pool := &Pool{model: p.model, id: p.poolID}
+	balances, _, err := pool.balances(ctx, p.id)
+	if err != nil {
+		return nil, err
+	}
+
+	if balance, ok := balances[p.id]; ok {
+		return balance, nil
+	}
+
+	return &ParticipantBalance{}, nil
+}
+
+// PaymentTotals returns how much is owed for every square of the pool and how much has been paid
+func (p *Pool) PaymentTotals(ctx context.Context) (*PaymentTotals, error) {
+	balances, methods, err := p.balances(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := &PaymentTotals{Methods: methods}
+	for _, balance := range balances {
+		totals.Owed += balance.Owed
+		totals.Paid += balance.Paid
+	}
+	totals.Balance = totals.Owed - totals.Paid
+
+	return totals, nil
+}
+
+// balances returns the balance of each participant, or of a single participant if participantID is not 0, along with
+// how much has been paid with each method. The balance of squares and payments without a participant is under 0.
+func (p *Pool) balances(ctx context.Context, participantID int64) (map[int64]*ParticipantBalance, map[PaymentMethod]int64, error) {
+	shared, prices, err := p.model.squarePrices(ctx, p.model.DB, p.id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balances := make(map[int64]*ParticipantBalance)
+	balanceOf := func(id int64) *ParticipantBalance {
+		if balances[id] == nil {
+			balances[id] = &ParticipantBalance{}
+		}
+
+		return balances[id]
+	}
+
+	const squaresQuery = `
+		SELECT COALESCE(participant_id, 0), COALESCE(grid_id, 0), COUNT(*)
+		FROM pool_squares
+		WHERE pool_id = $1
+		  AND state IN ('claimed', 'paid-partial', 'paid-full')
+		  AND ($2 = 0 OR participant_id = $2)
+		GROUP BY 1, 2`
+	rows, err := p.model.DB.QueryContext(ctx, squaresQuery, p.id, participantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for rows.Next() {
+		var id, gridID, count int64
+		if err := rows.Scan(&id, &gridID, &count); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+
+		price := shared
+		if gridID > 0 {
+			price = prices[gridID]
+		}
+
+		balanceOf(id).Owed += price * count
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	const paymentsQuery = `
+		SELECT COALESCE(participant_id, 0), method, SUM(amount)
+		FROM pool_payments
+		WHERE pool_id = $1
+		  AND voided IS NULL
+		  AND ($2 = 0 OR participant_id = $2)
+		GROUP BY 1, 2`
+	rows, err = p.model.DB.QueryContext(ctx, paymentsQuery, p.id, participantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	methods := make(map[PaymentMethod]int64)
+	for rows.Next() {
+		var id, amount int64
+		var method PaymentMethod
+		if err := rows.Scan(&id, &method, &amount); err != nil {
+			return nil, nil, err
+		}
+
+		balanceOf(id).Paid += amount
+		methods[method] += amount
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	for _, balance := range balances {
+		balance.Balance = balance.Owed - balance.Paid
+	}
+
+	return balances, methods, nil
+}
+
+// Payments will return the payment ledger of the pool, newest first. If participantID is not 0, only the payments of
+// that participant are returned.
+func (p *Pool) Payments(ctx context.Context, participantID int64, offset int64, limit int) ([]*PoolPayment, error) {
+	const query = `
+		SELECT ` + poolPaymentColumns + `
+		FROM pool_payments pay
+		LEFT JOIN pool_squares ps ON ps.id = pay.pool_square_id
+		WHERE pay.pool_id = $1 AND ($2 = 0 OR pay.participant_id = $2)
+		ORDER BY pay.id DESC
+		OFFSET $3
+		LIMIT $4`
+	rows, err := p.model.DB.QueryContext(ctx, query, p.id, participantID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make([]*PoolPayment, 0)
+	for rows.Next() {
+		payment, err := p.model.poolPaymentByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// PaymentsCount will return the number of payments in the ledger of the pool. If participantID is not 0, only the
+// payments of that participant are counted.
+func (p *Pool) PaymentsCount(ctx context.Context, participantID int64) (int64, error) {
+	const query = "SELECT COUNT(*) FROM pool_payments WHERE pool_id = $1 AND ($2 = 0 OR participant_id = $2)"
+
+	var count int64
+	err := p.model.DB.QueryRowContext(ctx, query, p.id, participantID).Scan(&count)
+	return count, err
+}
+
+// PaymentByID will return the payment. If the payment does not belong to the pool, sql.ErrNoRows will be returned.
+func (p *Pool) PaymentByID(ctx context.Context, id int64) (*PoolPayment, error) {
+	return p.model.paymentByID(ctx, p.model.DB, p.id, id)
+}
+
+func (m *Model) paymentByID(ctx context.Context, q Queryable, poolID, id int64) (*PoolPayment, error) {
+	const query = `
+		SELECT ` + poolPaymentColumns + `
+		FROM pool_payments pay
+		LEFT JOIN pool_squares ps ON ps.id = pay.pool_square_id
+		WHERE pay.pool_id = $1 AND pay.id = $2`
+	return m.poolPaymentByRow(q.QueryRowContext(ctx, query, poolID, id).Scan)
+}
+
+const poolPaymentColumns = `
+	pay.id,
+	pay.pool_id,
+	COALESCE(pay.participant_id, 0),
+	COALESCE(pay.pool_square_id, 0),
+	COALESCE(ps.grid_id, 0),
+	COALESCE(ps.square_id, 0),
+	pay.amount,
+	pay.method,
+	pay.note,
+	pay.recorded_by,
+	pay.voided,
+	COALESCE(pay.voided_by, 0),
+	pay.created`
+
+func (m *Model) poolPaymentByRow(scan scanFunc) (*PoolPayment, error) {
+	p := PoolPayment{model: m}
+	if err := scan(&p.id, &p.poolID, &p.participantID, &p.poolSquareID, &p.gridID, &p.squareID, &p.amount, &p.method, &p.note, &p.recordedBy, &p.voided, &p.voidedBy, &p.created); err != nil {
+		return nil, err
+	}
+
+	if p.voided != nil {
+		voided := p.voided.In(locationNewYork)
+		p.voided = &voided
+	}
+	p.created = p.created.In(locationNewYork)
+
+	return &p, nil
+}
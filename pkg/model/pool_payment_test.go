@@ -0,0 +1,118 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestPaymentMethod(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	for _, method := range PaymentMethods {
+		g.Expect(method.IsValid()).Should(gomega.BeTrue())
+	}
+
+	g.Expect(PaymentMethod("bitcoin").IsValid()).Should(gomega.BeFalse())
+}
+
+func TestPoolPayment(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, IssuerAuth0, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	grids, err := pool.Grids(ctx, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(grids)).Should(gomega.Equal(1))
+
+	grid := grids[0]
+	g.Expect(grid.LoadSettings(ctx)).Should(gomega.Succeed())
+	grid.Settings().SetSquarePrice(1000)
+	g.Expect(grid.Save(ctx)).Should(gomega.Succeed())
+
+	squares, err := pool.Squares()
+	g.Expect(err).Should(gomega.Succeed())
+
+	participant, err := pool.ClaimForNewParticipant(ctx, "Bob", "127.0.0.1", squares[1])
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(pool.ClaimOnBehalf(ctx, "", participant, "127.0.0.1", squares[2])).Should(gomega.Succeed())
+
+	// a payment for the participant goes towards their squares in order
+	payment, changed, err := pool.RecordPayment(ctx, participant, nil, 1500, PaymentMethodVenmo, "", owner.ID, "127.0.0.1")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(payment.Amount()).Should(gomega.Equal(int64(1500)))
+	g.Expect(len(changed)).Should(gomega.Equal(2))
+	g.Expect(changed[0].State).Should(gomega.Equal(PoolSquareStatePaidFull))
+	g.Expect(changed[1].State).Should(gomega.Equal(PoolSquareStatePaidPartial))
+
+	balance, err := participant.Balance(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(*balance).Should(gomega.Equal(ParticipantBalance{Owed: 2000, Paid: 1500, Balance: 500}))
+
+	// a payment for a square goes to that square
+	square, err := pool.SquareBySquareID(2)
+	g.Expect(err).Should(gomega.Succeed())
+	_, changed, err = pool.RecordPayment(ctx, nil, square, 500, PaymentMethodCash, "the rest", owner.ID, "127.0.0.1")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(changed)).Should(gomega.Equal(1))
+	g.Expect(changed[0].SquareID).Should(gomega.Equal(2))
+	g.Expect(changed[0].State).Should(gomega.Equal(PoolSquareStatePaidFull))
+
+	square, err = pool.SquareBySquareID(3)
+	g.Expect(err).Should(gomega.Succeed())
+	_, _, err = pool.RecordPayment(ctx, nil, square, 500, PaymentMethodCash, "", owner.ID, "127.0.0.1")
+	g.Expect(err).Should(gomega.Equal(ErrPaymentSquareUnclaimed))
+
+	totals, err := pool.PaymentTotals(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(totals.Owed).Should(gomega.Equal(int64(2000)))
+	g.Expect(totals.Paid).Should(gomega.Equal(int64(2000)))
+	g.Expect(totals.Balance).Should(gomega.Equal(int64(0)))
+	g.Expect(totals.Methods[PaymentMethodVenmo]).Should(gomega.Equal(int64(1500)))
+
+	// voiding a payment takes it back off the squares
+	changed, err = payment.Void(ctx, owner.ID, "127.0.0.1")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(payment.Voided()).ShouldNot(gomega.BeNil())
+	g.Expect(len(changed)).Should(gomega.Equal(2))
+	g.Expect(changed[0].SquareID).Should(gomega.Equal(1))
+	g.Expect(changed[0].State).Should(gomega.Equal(PoolSquareStateClaimed))
+	g.Expect(changed[1].State).Should(gomega.Equal(PoolSquareStatePaidPartial))
+
+	_, err = payment.Void(ctx, owner.ID, "127.0.0.1")
+	g.Expect(err).Should(gomega.Equal(ErrPaymentVoided))
+
+	payments, err := pool.Payments(ctx, participant.ID(), 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(payments)).Should(gomega.Equal(2))
+	g.Expect(payments[0].JSON().SquareID).Should(gomega.Equal(2))
+
+	count, err := pool.PaymentsCount(ctx, 0)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(2)))
+}
@@ -23,11 +23,16 @@ import (
 	"errors"
 	"time"
 	"unicode/utf8"
+
+	"github.com/lib/pq"
 )
 
 // ClaimantMaxLength is the maximum number of characters allowed in a claimant name
 const ClaimantMaxLength = 30
 
+// PoolSquareHoldDuration is how long a square is held for a member before it is released
+const PoolSquareHoldDuration = time.Minute * 15
+
 // PoolSquareState represents the state of an individual square within a given pool
 type PoolSquareState string
 
@@ -37,6 +42,7 @@ const (
 	PoolSquareStateClaimed     PoolSquareState = "claimed"
 	PoolSquareStatePaidPartial PoolSquareState = "paid-partial"
 	PoolSquareStatePaidFull    PoolSquareState = "paid-full"
+	PoolSquareStateHeld        PoolSquareState = "held"
 )
 
 // PoolSquareStates are the valid states of a PoolSquare
@@ -45,11 +51,21 @@ var PoolSquareStates = []PoolSquareState{
 	PoolSquareStatePaidPartial,
 	PoolSquareStatePaidFull,
 	PoolSquareStateUnclaimed,
+	PoolSquareStateHeld,
 }
 
 // ErrSquareAlreadyClaimed is an error when a user tries to claim a square that has already been claimed.
 var ErrSquareAlreadyClaimed = errors.New("square has already been claimed")
 
+// ErrSquareLimit is an error when a user tries to claim more squares than the pool allows each user to have.
+var ErrSquareLimit = errors.New("you have claimed the maximum number of squares allowed in this pool")
+
+// errCodeSquareLimit is the SQLSTATE that update_pool_square raises when the square limit has been reached
+const errCodeSquareLimit pq.ErrorCode = "SQ001"
+
+// errCodeVersionMismatch is the SQLSTATE that update_pool_square raises when the square is not at the expected version
+const errCodeVersionMismatch pq.ErrorCode = "SQ002"
+
 // ValidPoolSquareStates contains a map of valid states.
 var ValidPoolSquareStates = map[PoolSquareState]bool{}
 
@@ -65,20 +81,35 @@ func (g PoolSquareState) IsValid() bool {
 	return ok
 }
 
+// IsSold returns whether a square in the state has been sold, i.e. claimed or paid for. A held square is only set
+// aside while its user checks out, so it is not sold.
+func (g PoolSquareState) IsSold() bool {
+	switch g {
+	case PoolSquareStateClaimed, PoolSquareStatePaidPartial, PoolSquareStatePaidFull:
+		return true
+	}
+
+	return false
+}
+
 // PoolSquare is an individual square within a pool
 type PoolSquare struct {
 	*Model
 	ID             int64 `json:"-"`
 	ParentID       int64 `json:"-"`
 	PoolID         int64 `json:"-"`
+	GridID         int64 `json:"-"`
 	userID         int64
 	SquareID       int             `json:"-"`
 	ParentSquareID int             `json:"-"`
 	ChildSquareIDs []int8          `json:"-"`
 	State          PoolSquareState `json:"-"`
 	claimant       string
+	participantID  int64
+	holdExpires    *time.Time
 	Modified       time.Time        `json:"-"`
 	Logs           []*PoolSquareLog `json:"-"`
+	version        int64
 }
 
 // FIXME - remove the above json tags once we validate it's no longer necessary
@@ -97,6 +128,11 @@ func (p *PoolSquare) SetClaimant(claimant string) {
 	p.claimant = claimant
 }
 
+// Version returns the version of the square, which goes up every time the square is changed
+func (p *PoolSquare) Version() int64 {
+	return p.version
+}
+
 // UserID is a getter
 func (p *PoolSquare) UserID() int64 {
 	return p.userID
@@ -107,14 +143,28 @@ func (p *PoolSquare) SetUserID(userID int64) {
 	p.userID = userID
 }
 
+// ParticipantID is the participant that the square was claimed for. It is 0 if the square was not claimed on
+// someone's behalf.
+func (p *PoolSquare) ParticipantID() int64 {
+	return p.participantID
+}
+
+// HoldExpires is when the hold on the square will be released. It is nil unless the square is held.
+func (p *PoolSquare) HoldExpires() *time.Time {
+	return p.holdExpires
+}
+
 // PoolSquareJSON represents JSON that can be sent to the front-end
 type PoolSquareJSON struct {
+	GridID         int64            `json:"gridId,omitempty"`
 	UserID         int64            `json:"userId"`
 	SquareID       int              `json:"squareId"`
 	ParentSquareID int              `json:"parentSquareId"`
 	ChildSquareIDs []int8           `json:"childSquareIds"`
 	State          PoolSquareState  `json:"state"`
 	Claimant       string           `json:"claimant"`
+	ParticipantID  int64            `json:"participantId,omitempty"`
+	HoldExpires    *time.Time       `json:"holdExpires,omitempty"`
 	Modified       time.Time        `json:"modified"`
 	Logs           []*PoolSquareLog `json:"logs,omitempty"`
 }
@@ -122,12 +172,15 @@ type PoolSquareJSON struct {
 // JSON will custom JSON encode a PoolSquare
 func (p *PoolSquare) JSON() *PoolSquareJSON {
 	return &PoolSquareJSON{
+		GridID:         p.GridID,
 		UserID:         p.userID,
 		SquareID:       p.SquareID,
 		ParentSquareID: p.ParentSquareID,
 		ChildSquareIDs: p.ChildSquareIDs,
 		State:          p.State,
 		Claimant:       p.Claimant(),
+		ParticipantID:  p.participantID,
+		HoldExpires:    p.holdExpires,
 		Modified:       p.Modified,
 		Logs:           p.Logs,
 	}
@@ -137,12 +190,14 @@ func (p *PoolSquare) JSON() *PoolSquareJSON {
 type PoolSquareLog struct {
 	id           int64
 	poolSquareID int64
+	gridID       int64
 	squareID     int
 	userID       int64
 	state        PoolSquareState
 	claimant     string
 	RemoteAddr   string
 	Note         string
+	tradeID      int64
 	created      time.Time
 }
 
@@ -151,27 +206,41 @@ func (p *PoolSquareLog) SquareID() int {
 	return p.squareID
 }
 
+// GridID is the grid the square belongs to. It is 0 if the square is shared by every grid of the pool.
+func (p *PoolSquareLog) GridID() int64 {
+	return p.gridID
+}
+
 // Claimant is a getter for the claimant
 func (p *PoolSquareLog) Claimant() string {
 	return p.claimant
 }
 
+// TradeID is the trade that changed the owner of the square. It is 0 if the entry was not made by a trade.
+func (p *PoolSquareLog) TradeID() int64 {
+	return p.tradeID
+}
+
 // PoolSquareLogJSON returns data safe for a user to see
 type PoolSquareLogJSON struct {
+	GridID   int64           `json:"gridId,omitempty"`
 	SquareID int             `json:"squareID"`
 	State    PoolSquareState `json:"state"`
 	Claimant string          `json:"claimant"`
 	Note     string          `json:"note"`
+	TradeID  int64           `json:"tradeId,omitempty"`
 	Created  time.Time       `json:"created"`
 }
 
 // JSON will return data safe for the front-end
 func (p *PoolSquareLog) JSON() *PoolSquareLogJSON {
 	return &PoolSquareLogJSON{
+		GridID:   p.GridID(),
 		SquareID: p.SquareID(),
 		State:    p.State(),
 		Claimant: p.Claimant(),
 		Note:     p.Note,
+		TradeID:  p.TradeID(),
 		Created:  p.Created(),
 	}
 }
@@ -209,6 +278,13 @@ func (p *PoolSquare) SetParentSquare(ctx context.Context, tx *sql.Tx, square *Po
 
 // Save will save the pool square and the associated log data to the database
 func (p *PoolSquare) Save(ctx context.Context, dbFn Queryable, isAdmin bool, poolSquareLog PoolSquareLog) error {
+	return p.SaveVersion(ctx, dbFn, isAdmin, 0, poolSquareLog)
+}
+
+// SaveVersion will save the pool square and the associated log data only if the square is still at the version, e.g.
+// the one that a client last loaded. If it has been changed since, ErrVersionMismatch is returned. The version is not
+// checked if it is 0.
+func (p *PoolSquare) SaveVersion(ctx context.Context, dbFn Queryable, isAdmin bool, version int64, poolSquareLog PoolSquareLog) error {
 	var claimant *string
 	if p.claimant != "" {
 		claimant = &p.claimant
@@ -226,11 +302,22 @@ func (p *PoolSquare) Save(ctx context.Context, dbFn Queryable, isAdmin bool, poo
 		remoteAddr = &ip
 	}
 
-	const query = "SELECT * FROM update_pool_square($1, $2, $3, $4, $5, $6, $7)"
-	row := dbFn.QueryRowContext(ctx, query, p.ID, p.State, claimant, userID, remoteAddr, poolSquareLog.Note, isAdmin)
+	holdSeconds := int(PoolSquareHoldDuration / time.Second)
+
+	const query = "SELECT * FROM update_pool_square($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	row := dbFn.QueryRowContext(ctx, query, p.ID, p.State, claimant, userID, remoteAddr, poolSquareLog.Note, isAdmin, holdSeconds, version)
 
 	var ok bool
 	if err := row.Scan(&ok); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case errCodeSquareLimit:
+				return ErrSquareLimit
+			case errCodeVersionMismatch:
+				return ErrVersionMismatch
+			}
+		}
+
 		return err
 	}
 
@@ -238,6 +325,52 @@ func (p *PoolSquare) Save(ctx context.Context, dbFn Queryable, isAdmin bool, poo
 		return ErrSquareAlreadyClaimed
 	}
 
+	// unclaiming a square takes it away from its participant
+	if p.State == PoolSquareStateUnclaimed {
+		p.participantID = 0
+	} else if err := p.saveParticipant(ctx, dbFn); err != nil {
+		return err
+	}
+
+	if p.State != PoolSquareStateHeld {
+		p.holdExpires = nil
+		return nil
+	}
+
+	// the expiry is set by the database, since an existing hold keeps its expiry
+	var holdExpires time.Time
+	if err := dbFn.QueryRowContext(ctx, "SELECT hold_expires FROM pool_squares WHERE id = $1", p.ID).Scan(&holdExpires); err != nil {
+		return err
+	}
+
+	holdExpires = holdExpires.In(locationNewYork)
+	p.holdExpires = &holdExpires
+
+	return nil
+}
+
+// saveParticipant will make sure that the square belongs to a participant. A square claimed by an account belongs to
+// the account's participant. Otherwise, it stays with the participant it was given and falls back on the participant
+// with the claimant's name.
+func (p *PoolSquare) saveParticipant(ctx context.Context, dbFn Queryable) error {
+	participantID := p.participantID
+	if p.userID > 0 || participantID == 0 {
+		if p.userID == 0 && p.claimant == "" {
+			return nil
+		}
+
+		var err error
+		if participantID, err = p.Model.participantIDFor(ctx, dbFn, p.PoolID, p.userID, p.claimant); err != nil {
+			return err
+		}
+	}
+
+	const query = "UPDATE pool_squares SET participant_id = $1 WHERE id = $2 AND participant_id IS DISTINCT FROM $1"
+	if _, err := dbFn.ExecContext(ctx, query, participantID, p.ID); err != nil {
+		return err
+	}
+
+	p.participantID = participantID
 	return nil
 }
 
@@ -246,15 +379,25 @@ func poolSquareLogByRow(scan scanFunc) (*PoolSquareLog, error) {
 	var remoteAddr *string
 	var userID *int64
 	var claimant *string
+	var gridID *int64
+	var tradeID *int64
 
-	if err := scan(&l.id, &l.poolSquareID, &l.squareID, &userID, &l.state, &claimant, &remoteAddr, &l.Note, &l.created); err != nil {
+	if err := scan(&l.id, &l.poolSquareID, &gridID, &l.squareID, &userID, &l.state, &claimant, &remoteAddr, &l.Note, &tradeID, &l.created); err != nil {
 		return nil, err
 	}
 
+	if tradeID != nil {
+		l.tradeID = *tradeID
+	}
+
 	if userID != nil {
 		l.userID = *userID
 	}
 
+	if gridID != nil {
+		l.gridID = *gridID
+	}
+
 	if remoteAddr != nil {
 		l.RemoteAddr = *remoteAddr
 	}
@@ -271,15 +414,7 @@ func poolSquareLogByRow(scan scanFunc) (*PoolSquareLog, error) {
 // LoadLogs will load the logs for the given square
 func (p *PoolSquare) LoadLogs(ctx context.Context) error {
 	const query = `
-		SELECT
-		       pool_squares_logs.id,
-		       pool_square_id,
-		       square_id,
-		       pool_squares_logs.user_id,
-		       pool_squares_logs.state,
-		       pool_squares_logs.claimant,
-		       remote_addr, note,
-		       pool_squares_logs.created
+		SELECT ` + poolSquareLogColumns + `
 		FROM
 		     pool_squares_logs
 		INNER JOIN
@@ -311,16 +446,7 @@ func (p *PoolSquare) LoadLogs(ctx context.Context) error {
 // ChildSquares returns the children of the current square
 func (p *PoolSquare) ChildSquares(ctx context.Context, q Queryable) ([]*PoolSquare, error) {
 	const query = `
-		SELECT
-		       ps.id,
-		       ps.square_id,
-		       ps.parent_id,
-		       ps.user_id,
-		       ps.state,
-		       ps.claimant,
-		       ps.modified,
-		       ps2.square_id AS parent_square_id,
-		       (SELECT array_agg(square_id) FROM pool_squares ps3 WHERE ps3.parent_id = ps.id) AS child_square_ids
+		SELECT ` + poolSquareColumns + `
 		FROM
 			pool_squares ps
 		LEFT JOIN
@@ -349,3 +475,33 @@ func (p *PoolSquare) ChildSquares(ctx context.Context, q Queryable) ([]*PoolSqua
 
 	return squares, nil
 }
+
+const poolSquareColumns = `
+	ps.id,
+	ps.grid_id,
+	ps.square_id,
+	ps.parent_id,
+	ps.user_id,
+	ps.state,
+	ps.claimant,
+	COALESCE(ps.participant_id, 0),
+	ps.hold_expires,
+	ps.modified,
+	ps.version,
+	ps2.square_id AS parent_square_id,
+	(SELECT array_agg(square_id) FROM pool_squares ps3 WHERE ps3.parent_id = ps.id) AS child_square_ids
+`
+
+const poolSquareLogColumns = `
+	pool_squares_logs.id,
+	pool_square_id,
+	pool_squares.grid_id,
+	pool_squares.square_id,
+	pool_squares_logs.user_id,
+	pool_squares_logs.state,
+	pool_squares_logs.claimant,
+	remote_addr,
+	note,
+	pool_squares_logs.trade_id,
+	pool_squares_logs.created
+`
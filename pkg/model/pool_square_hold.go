@@ -0,0 +1,119 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PoolIDsWithExpiredHolds returns the IDs of the pools that have squares whose holds have expired
+func (m *Model) PoolIDsWithExpiredHolds(ctx context.Context) ([]int64, error) {
+	const query = `
+		SELECT DISTINCT pool_id
+		FROM pool_squares
+		WHERE state = 'held' AND hold_expires <= (NOW() AT TIME ZONE 'utc')`
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// ReleaseExpiredHolds will put every square in the pool whose hold has expired back up for grabs. The release is
+// logged against each square. The squares that were released are returned.
+func (p *Pool) ReleaseExpiredHolds(ctx context.Context) ([]*PoolSquare, error) {
+	var released []*PoolSquare
+	err := p.model.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		released, err = p.ReleaseExpiredHoldsTx(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return released, nil
+}
+
+// ReleaseExpiredHoldsTx will release the expired holds as ReleaseExpiredHolds does within the transaction
+func (p *Pool) ReleaseExpiredHoldsTx(ctx context.Context, tx *sql.Tx) ([]*PoolSquare, error) {
+	// a hold that is being claimed right now is skipped. if the claim fails, the next sweep will release it.
+	const query = `
+		SELECT ` + poolSquareColumns + `
+		FROM
+		     pool_squares ps
+		LEFT JOIN
+		         pool_squares ps2 ON ps.parent_id = ps2.id
+		WHERE
+		      ps.pool_id = $1 AND
+		      ps.state = 'held' AND
+		      ps.hold_expires <= (NOW() AT TIME ZONE 'utc')
+		ORDER BY
+		         ps.id
+		FOR UPDATE OF ps SKIP LOCKED`
+	rows, err := tx.QueryContext(ctx, query, p.id)
+	if err != nil {
+		return nil, err
+	}
+
+	squares := make([]*PoolSquare, 0)
+	for rows.Next() {
+		square, err := p.squareByRow(rows.Scan)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		squares = append(squares, square)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, square := range squares {
+		note := fmt.Sprintf("automatic: hold by `%s` expired", square.Claimant())
+
+		square.State = PoolSquareStateUnclaimed
+		square.SetClaimant("")
+		square.SetUserID(0)
+		square.ParentID = 0
+		square.ParentSquareID = 0
+		square.ChildSquareIDs = nil
+
+		if err := square.Save(ctx, tx, true, PoolSquareLog{Note: note}); err != nil {
+			return nil, err
+		}
+	}
+
+	return squares, nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestSquareHolds(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	u1, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	u2, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, u1.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	squares, err := pool.Squares()
+	g.Expect(err).Should(gomega.Succeed())
+
+	save := func(square *PoolSquare, user *User, state PoolSquareState) error {
+		square.SetClaimant("Test User")
+		square.State = state
+		square.SetUserID(user.ID)
+		return square.Save(ctx, m.DB, false, PoolSquareLog{})
+	}
+
+	g.Expect(save(squares[1], u1, PoolSquareStateHeld)).Should(gomega.Succeed())
+	g.Expect(squares[1].HoldExpires()).ShouldNot(gomega.BeNil())
+	g.Expect(squares[1].HoldExpires().After(time.Now())).Should(gomega.BeTrue())
+
+	// other members can see the hold, but cannot take the square
+	square, err := pool.SquareBySquareID(1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.State).Should(gomega.Equal(PoolSquareStateHeld))
+	g.Expect(square.JSON().HoldExpires).ShouldNot(gomega.BeNil())
+	g.Expect(save(square, u2, PoolSquareStateClaimed)).Should(gomega.Equal(ErrSquareAlreadyClaimed))
+
+	// the holder can turn the hold into a claim
+	g.Expect(save(squares[1], u1, PoolSquareStateClaimed)).Should(gomega.Succeed())
+	g.Expect(squares[1].HoldExpires()).Should(gomega.BeNil())
+
+	// an expired hold is released by the sweeper
+	g.Expect(save(squares[2], u1, PoolSquareStateHeld)).Should(gomega.Succeed())
+	_, err = m.DB.ExecContext(ctx, "UPDATE pool_squares SET hold_expires = (NOW() AT TIME ZONE 'utc') - INTERVAL '1 minute' WHERE id = $1", squares[2].ID)
+	g.Expect(err).Should(gomega.Succeed())
+
+	poolIDs, err := m.PoolIDsWithExpiredHolds(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(poolIDs).Should(gomega.ContainElement(pool.ID()))
+
+	released, err := pool.ReleaseExpiredHolds(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(released)).Should(gomega.Equal(1))
+	g.Expect(released[0].SquareID).Should(gomega.Equal(2))
+	g.Expect(released[0].State).Should(gomega.Equal(PoolSquareStateUnclaimed))
+
+	square, err = pool.SquareBySquareID(2)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.State).Should(gomega.Equal(PoolSquareStateUnclaimed))
+	g.Expect(square.HoldExpires()).Should(gomega.BeNil())
+
+	g.Expect(square.LoadLogs(ctx)).Should(gomega.Succeed())
+	g.Expect(square.Logs[0].Note).Should(gomega.Equal("automatic: hold by `Test User` expired"))
+
+	released, err = pool.ReleaseExpiredHolds(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(released)).Should(gomega.Equal(0))
+}
+
+func TestSquareHoldExpiredRoll100(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	u1, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	u2, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, u1.ID, "test", GridTypeRoll100, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	save := func(q Queryable, square *PoolSquare, user *User, state PoolSquareState) error {
+		square.SetClaimant("Test User")
+		square.State = state
+		square.SetUserID(user.ID)
+		return square.Save(ctx, q, false, PoolSquareLog{})
+	}
+
+	square := func(squareID int) *PoolSquare {
+		square, err := pool.SquareBySquareID(squareID)
+		g.Expect(err).Should(gomega.Succeed())
+		return square
+	}
+
+	// claim will save the square along with its secondary square the way that the endpoints do
+	claim := func(user *User, state PoolSquareState, squareID, secondarySquareID int) error {
+		parent, child := square(squareID), square(secondarySquareID)
+
+		tx, err := m.DB.BeginTx(ctx, nil)
+		g.Expect(err).Should(gomega.Succeed())
+		defer tx.Rollback()
+
+		if err := save(tx, parent, user, state); err != nil {
+			return err
+		}
+		if err := child.SetParentSquare(ctx, tx, parent); err != nil {
+			return err
+		}
+		if err := save(tx, child, user, state); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	expire := func(squareIDs ...int) {
+		for _, squareID := range squareIDs {
+			_, err := m.DB.ExecContext(ctx, "UPDATE pool_squares SET hold_expires = (NOW() AT TIME ZONE 'utc') - INTERVAL '1 minute' WHERE id = $1", square(squareID).ID)
+			g.Expect(err).Should(gomega.Succeed())
+		}
+	}
+
+	g.Expect(claim(u1, PoolSquareStateHeld, 1, 11)).Should(gomega.Succeed())
+	g.Expect(claim(u1, PoolSquareStateHeld, 2, 12)).Should(gomega.Succeed())
+	g.Expect(claim(u1, PoolSquareStateHeld, 3, 13)).Should(gomega.Succeed())
+	expire(1, 11, 2, 12, 3, 13)
+
+	// claiming the square of an expired hold does not take the secondary square that was held with it
+	g.Expect(save(m.DB, square(1), u2, PoolSquareStateClaimed)).Should(gomega.Succeed())
+	g.Expect(square(1).ChildSquareIDs).Should(gomega.BeEmpty())
+	g.Expect(square(11).ParentSquareID).Should(gomega.Equal(0))
+	g.Expect(square(11).UserID()).Should(gomega.Equal(u1.ID))
+
+	// the secondary square of an expired hold is claimed on its own, so it counts towards the limit
+	pool.SetMaxSquaresPerUser(1)
+	g.Expect(pool.Save(ctx)).Should(gomega.Succeed())
+	g.Expect(save(m.DB, square(12), u2, PoolSquareStateClaimed)).Should(gomega.Equal(ErrSquareLimit))
+
+	pool.SetMaxSquaresPerUser(0)
+	g.Expect(pool.Save(ctx)).Should(gomega.Succeed())
+	g.Expect(save(m.DB, square(12), u2, PoolSquareStateClaimed)).Should(gomega.Succeed())
+	g.Expect(square(12).ParentSquareID).Should(gomega.Equal(0))
+	g.Expect(square(2).ChildSquareIDs).Should(gomega.BeEmpty())
+
+	// a square and its secondary square can be claimed together from an expired hold
+	g.Expect(claim(u2, PoolSquareStateClaimed, 3, 13)).Should(gomega.Succeed())
+	g.Expect(square(13).ParentSquareID).Should(gomega.Equal(3))
+	g.Expect(square(13).UserID()).Should(gomega.Equal(u2.ID))
+	g.Expect(square(3).ChildSquareIDs).Should(gomega.Equal([]int8{13}))
+}
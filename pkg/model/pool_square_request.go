@@ -0,0 +1,416 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// ErrRandomAssignment happens when a member tries to pick a square in a pool where squares are assigned at random
+var ErrRandomAssignment = errors.New("squares are assigned at random in this pool")
+
+// ErrNotRandomAssignment happens when a member requests squares in a pool where squares are not assigned at random
+var ErrNotRandomAssignment = errors.New("squares are not assigned at random in this pool")
+
+// assignmentNote is the note that is logged for every square that is assigned at random
+const assignmentNote = "automatic: assigned at random"
+
+// PoolSquareRequest is the number of squares that a member wants to be assigned at random
+type PoolSquareRequest struct {
+	id       int64
+	poolID   int64
+	gridID   int64
+	userID   int64
+	claimant string
+	squares  int
+	assigned int
+	created  time.Time
+	modified time.Time
+}
+
+// PoolSquareRequestJSON is the JSON representation of a PoolSquareRequest
+type PoolSquareRequestJSON struct {
+	UserID   int64     `json:"userId"`
+	GridID   int64     `json:"gridId,omitempty"`
+	Claimant string    `json:"claimant"`
+	Squares  int       `json:"squares"`
+	Assigned int       `json:"assigned"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+}
+
+// UserID returns the ID of the member who made the request
+func (r *PoolSquareRequest) UserID() int64 {
+	return r.userID
+}
+
+// Claimant returns the name that the squares will be claimed with
+func (r *PoolSquareRequest) Claimant() string {
+	return r.claimant
+}
+
+// Squares returns the number of squares that were requested
+func (r *PoolSquareRequest) Squares() int {
+	return r.squares
+}
+
+// Assigned returns the number of squares that have been assigned
+func (r *PoolSquareRequest) Assigned() int {
+	return r.assigned
+}
+
+// JSON will return the JSON representation
+func (r *PoolSquareRequest) JSON() *PoolSquareRequestJSON {
+	return &PoolSquareRequestJSON{
+		UserID:   r.userID,
+		GridID:   r.gridID,
+		Claimant: r.claimant,
+		Squares:  r.squares,
+		Assigned: r.assigned,
+		Created:  r.created,
+		Modified: r.modified,
+	}
+}
+
+// RequestSquares will ask for a number of squares to be assigned to the user at random. A nil grid requests squares
+// that are shared by every grid. If the user already made a request, it is replaced.
+func (p *Pool) RequestSquares(ctx context.Context, grid *Grid, userID int64, claimant string, squares int) (*PoolSquareRequest, error) {
+	if !p.randomAssignment {
+		return nil, ErrNotRandomAssignment
+	}
+
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+		INSERT INTO pool_square_requests (pool_id, grid_id, user_id, claimant, squares)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5)
+		ON CONFLICT (pool_id, COALESCE(grid_id, 0), user_id) DO UPDATE SET
+			claimant = EXCLUDED.claimant,
+			squares = EXCLUDED.squares,
+			modified = (NOW() AT TIME ZONE 'utc')
+		RETURNING ` + poolSquareRequestColumns
+	return poolSquareRequestByRow(p.model.DB.QueryRowContext(ctx, query, p.id, sheetID, userID, claimant, squares).Scan)
+}
+
+// SquareRequests will return every request for squares, oldest first. A nil grid returns the requests for the squares
+// that are shared by every grid.
+func (p *Pool) SquareRequests(ctx context.Context, grid *Grid) ([]*PoolSquareRequest, error) {
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.squareRequests(ctx, p.model.DB, sheetID)
+}
+
+func (p *Pool) squareRequests(ctx context.Context, q Queryable, sheetID int64) ([]*PoolSquareRequest, error) {
+	const query = `
+		SELECT ` + poolSquareRequestColumns + `
+		FROM pool_square_requests
+		WHERE pool_id = $1 AND COALESCE(grid_id, 0) = $2
+		ORDER BY created, id`
+	rows, err := q.QueryContext(ctx, query, p.id, sheetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := make([]*PoolSquareRequest, 0)
+	for rows.Next() {
+		request, err := poolSquareRequestByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// SquareRequestByUserID will return the request that the user made. If the user has not made one, sql.ErrNoRows will
+// be returned.
+func (p *Pool) SquareRequestByUserID(ctx context.Context, grid *Grid, userID int64) (*PoolSquareRequest, error) {
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+		SELECT ` + poolSquareRequestColumns + `
+		FROM pool_square_requests
+		WHERE pool_id = $1 AND COALESCE(grid_id, 0) = $2 AND user_id = $3`
+	return poolSquareRequestByRow(p.model.DB.QueryRowContext(ctx, query, p.id, sheetID, userID).Scan)
+}
+
+// CancelSquareRequest will withdraw the request that the user made. Squares that were already assigned are kept. If
+// the user has not made a request, sql.ErrNoRows will be returned.
+func (p *Pool) CancelSquareRequest(ctx context.Context, grid *Grid, userID int64) error {
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return err
+	}
+
+	const query = "DELETE FROM pool_square_requests WHERE pool_id = $1 AND COALESCE(grid_id, 0) = $2 AND user_id = $3"
+	res, err := p.model.DB.ExecContext(ctx, query, p.id, sheetID, userID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AssignSquares will assign unclaimed squares at random to the members who requested them. Members take turns in
+// the order they made their requests, one square at a time, until every request is filled or no squares are left. In
+// a roll100 pool, every square is assigned along with a random secondary square. Each square is claimed and logged as
+// if the member had claimed it. The squares that were assigned are returned.
+func (p *Pool) AssignSquares(ctx context.Context, remoteAddr string) ([]*PoolSquare, error) {
+	var assigned []*PoolSquare
+	err := p.model.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		assigned, err = p.AssignSquaresTx(ctx, tx, remoteAddr)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assigned, nil
+}
+
+// AssignSquaresTx will assign unclaimed squares at random to the members who requested them within the transaction
+func (p *Pool) AssignSquaresTx(ctx context.Context, tx *sql.Tx, remoteAddr string) ([]*PoolSquare, error) {
+	sheets := []*Grid{nil}
+	if p.squaresPerGrid {
+		grids, err := p.Grids(ctx, 0, MaxGridsPerPool)
+		if err != nil {
+			return nil, err
+		}
+
+		sheets = grids
+	}
+
+	// claims are serialized against the pool, so this keeps anyone from claiming a square mid-assignment
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM pools WHERE id = $1 FOR NO KEY UPDATE", p.id); err != nil {
+		return nil, err
+	}
+
+	var assigned []*PoolSquare
+	for _, grid := range sheets {
+		squares, err := p.assignSheet(ctx, tx, grid, remoteAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		assigned = append(assigned, squares...)
+	}
+
+	return assigned, nil
+}
+
+func (p *Pool) assignSheet(ctx context.Context, tx *sql.Tx, grid *Grid, remoteAddr string) ([]*PoolSquare, error) {
+	sheetID, err := p.sheetID(grid)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := p.squareRequests(ctx, tx, sheetID)
+	if err != nil {
+		return nil, err
+	}
+
+	outstanding := make([]int, len(requests))
+	total := 0
+	for i, request := range requests {
+		if n := request.squares - request.assigned; n > 0 {
+			outstanding[i] = n
+			total += n
+		}
+	}
+
+	if total == 0 {
+		return nil, nil
+	}
+
+	const query = `
+		SELECT ` + poolSquareColumns + `
+		FROM
+		     pool_squares ps
+		LEFT JOIN
+		         pool_squares ps2 ON ps.parent_id = ps2.id
+		WHERE
+		      ps.pool_id = $1 AND
+		      COALESCE(ps.grid_id, 0) = $2 AND
+		      ps.state = 'unclaimed'
+		ORDER BY
+		         ps.square_id
+		FOR UPDATE OF ps`
+	rows, err := tx.QueryContext(ctx, query, p.id, sheetID)
+	if err != nil {
+		return nil, err
+	}
+
+	free := make([]*PoolSquare, 0)
+	for rows.Next() {
+		square, err := p.squareByRow(rows.Scan)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		free = append(free, square)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := shuffleSquares(free); err != nil {
+		return nil, err
+	}
+
+	perAssignment := 1
+	if p.gridType == GridTypeRoll100 {
+		perAssignment = 2
+	}
+
+	assigned := make([]*PoolSquare, 0)
+	counts := make([]int, len(requests))
+	for next := 0; next+perAssignment <= len(free); {
+		progress := false
+		for i, request := range requests {
+			if outstanding[i] == 0 || next+perAssignment > len(free) {
+				continue
+			}
+
+			square := free[next]
+			if err := p.assignSquare(ctx, tx, square, nil, request, remoteAddr); err != nil {
+				return nil, err
+			}
+			assigned = append(assigned, square)
+
+			if perAssignment == 2 {
+				secondary := free[next+1]
+				if err := p.assignSquare(ctx, tx, secondary, square, request, remoteAddr); err != nil {
+					return nil, err
+				}
+				assigned = append(assigned, secondary)
+			}
+
+			next += perAssignment
+			outstanding[i]--
+			counts[i]++
+			progress = true
+		}
+
+		if !progress {
+			break
+		}
+	}
+
+	for i, request := range requests {
+		if counts[i] == 0 {
+			continue
+		}
+
+		const query = "UPDATE pool_square_requests SET assigned = assigned + $1, modified = (NOW() AT TIME ZONE 'utc') WHERE id = $2"
+		if _, err := tx.ExecContext(ctx, query, counts[i], request.id); err != nil {
+			return nil, err
+		}
+	}
+
+	return assigned, nil
+}
+
+// assignSquare will claim the square for the member who requested it. If parent is not nil, the square is the
+// secondary square of the parent.
+func (p *Pool) assignSquare(ctx context.Context, tx *sql.Tx, square, parent *PoolSquare, request *PoolSquareRequest, remoteAddr string) error {
+	note := assignmentNote
+	if parent != nil {
+		if err := square.SetParentSquare(ctx, tx, parent); err != nil {
+			return err
+		}
+
+		square.ParentID = parent.ID
+		square.ParentSquareID = parent.SquareID
+		parent.ChildSquareIDs = append(parent.ChildSquareIDs, int8(square.SquareID))
+		note += " (secondary)"
+	}
+
+	square.SetClaimant(request.claimant)
+	square.State = PoolSquareStateClaimed
+	square.SetUserID(request.userID)
+
+	// the request was checked against the limits of the pool when it was made
+	return square.Save(ctx, tx, true, PoolSquareLog{
+		RemoteAddr: remoteAddr,
+		Note:       note,
+	})
+}
+
+// shuffleSquares will put the squares in a random order
+func shuffleSquares(squares []*PoolSquare) error {
+	for i := len(squares) - 1; i > 0; i-- {
+		jBig, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return err
+		}
+		j := int(jBig.Int64())
+
+		squares[i], squares[j] = squares[j], squares[i]
+	}
+
+	return nil
+}
+
+const poolSquareRequestColumns = `
+	id,
+	pool_id,
+	COALESCE(grid_id, 0),
+	user_id,
+	claimant,
+	squares,
+	assigned,
+	created,
+	modified`
+
+func poolSquareRequestByRow(scan scanFunc) (*PoolSquareRequest, error) {
+	var r PoolSquareRequest
+	if err := scan(&r.id, &r.poolID, &r.gridID, &r.userID, &r.claimant, &r.squares, &r.assigned, &r.created, &r.modified); err != nil {
+		return nil, err
+	}
+
+	r.created = r.created.In(locationNewYork)
+	r.modified = r.modified.In(locationNewYork)
+
+	return &r, nil
+}
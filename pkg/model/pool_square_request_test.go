@@ -0,0 +1,99 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestRandomAssignment(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, IssuerAuth0, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	u1, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	u2, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	_, err = pool.RequestSquares(ctx, nil, u1.ID, "User One", 5)
+	g.Expect(err).Should(gomega.Equal(ErrNotRandomAssignment))
+
+	pool.SetRandomAssignment(true)
+	g.Expect(pool.Save(ctx)).Should(gomega.Succeed())
+
+	pool, err = m.PoolByID(pool.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(pool.RandomAssignment()).Should(gomega.BeTrue())
+	g.Expect(pool.JSON().RandomAssignment).Should(gomega.BeTrue())
+
+	_, err = pool.RequestSquares(ctx, nil, u1.ID, "User One", 5)
+	g.Expect(err).Should(gomega.Succeed())
+
+	// a second request replaces the first
+	request, err := pool.RequestSquares(ctx, nil, u1.ID, "User One", 20)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(request.Squares()).Should(gomega.Equal(20))
+
+	_, err = pool.RequestSquares(ctx, nil, u2.ID, "User Two", 10)
+	g.Expect(err).Should(gomega.Succeed())
+
+	requests, err := pool.SquareRequests(ctx, nil)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(requests)).Should(gomega.Equal(2))
+	g.Expect(requests[0].UserID()).Should(gomega.Equal(u1.ID))
+
+	_, err = pool.SquareRequestByUserID(ctx, nil, owner.ID)
+	g.Expect(err).Should(gomega.Equal(sql.ErrNoRows))
+
+	// 30 squares were requested but there are only 25, so members take turns until they run out
+	assigned, err := pool.AssignSquares(ctx, "127.0.0.1")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(assigned)).Should(gomega.Equal(25))
+
+	claimed, err := pool.SquaresClaimedBy(ctx, u1.ID)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(claimed).Should(gomega.Equal(15))
+
+	claimed, err = pool.SquaresClaimedBy(ctx, u2.ID)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(claimed).Should(gomega.Equal(10))
+
+	request, err = pool.SquareRequestByUserID(ctx, nil, u2.ID)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(request.Assigned()).Should(gomega.Equal(10))
+
+	// nothing is left to assign
+	assigned, err = pool.AssignSquares(ctx, "127.0.0.1")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(assigned)).Should(gomega.Equal(0))
+
+	g.Expect(pool.CancelSquareRequest(ctx, nil, u2.ID)).Should(gomega.Succeed())
+	g.Expect(pool.CancelSquareRequest(ctx, nil, u2.ID)).Should(gomega.Equal(sql.ErrNoRows))
+}
@@ -0,0 +1,515 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PoolSquareTradeState is the state of a trade
+type PoolSquareTradeState string
+
+// constants for PoolSquareTradeState
+const (
+	PoolSquareTradeStateProposed         PoolSquareTradeState = "proposed"
+	PoolSquareTradeStateAwaitingApproval PoolSquareTradeState = "awaitingApproval"
+	PoolSquareTradeStateAccepted         PoolSquareTradeState = "accepted"
+	PoolSquareTradeStateDeclined         PoolSquareTradeState = "declined"
+	PoolSquareTradeStateCancelled        PoolSquareTradeState = "cancelled"
+)
+
+// IsOpen returns true if the trade can still be accepted, declined or cancelled
+func (s PoolSquareTradeState) IsOpen() bool {
+	return s == PoolSquareTradeStateProposed || s == PoolSquareTradeStateAwaitingApproval
+}
+
+// ErrTradeNotOwner happens when a member tries to trade away a square that they have not claimed
+var ErrTradeNotOwner = errors.New("you can only trade a square that you have claimed")
+
+// ErrTradeSameOwner happens when a member tries to trade for a square that they have already claimed
+var ErrTradeSameOwner = errors.New("you have already claimed that square")
+
+// ErrTradeNotClaimed happens when either square of a trade is not claimed
+var ErrTradeNotClaimed = errors.New("only claimed squares can be traded")
+
+// ErrTradeSecondarySquare happens when a member tries to trade a secondary square on its own
+var ErrTradeSecondarySquare = errors.New("a secondary square can only be traded along with its parent")
+
+// ErrTradeExists happens when a member proposes a trade that they have already proposed
+var ErrTradeExists = errors.New("you have already proposed this trade")
+
+// ErrTradeNotOpen happens when a trade is no longer waiting on anyone
+var ErrTradeNotOpen = errors.New("the trade is no longer open")
+
+// ErrTradeSquaresChanged happens when either square of a trade has changed hands since the trade was proposed
+var ErrTradeSquaresChanged = errors.New("one of the squares has changed hands since the trade was proposed")
+
+// PoolSquareTrade is a proposal by a member to swap one of their squares for a square of another member
+type PoolSquareTrade struct {
+	model         *Model
+	id            int64
+	poolID        int64
+	gridID        int64
+	fromUserID    int64
+	fromSquareID  int64
+	fromSquareNum int
+	toUserID      int64
+	toSquareID    int64
+	toSquareNum   int
+	state         PoolSquareTradeState
+	approvedBy    int64
+	created       time.Time
+	modified      time.Time
+}
+
+// PoolSquareTradeJSON is the JSON representation of a PoolSquareTrade. The square IDs are the numbers of the squares
+// within the grid.
+type PoolSquareTradeJSON struct {
+	ID           int64                `json:"id"`
+	GridID       int64                `json:"gridId,omitempty"`
+	FromUserID   int64                `json:"fromUserId"`
+	FromSquareID int                  `json:"fromSquareId"`
+	ToUserID     int64                `json:"toUserId"`
+	ToSquareID   int                  `json:"toSquareId"`
+	State        PoolSquareTradeState `json:"state"`
+	ApprovedBy   int64                `json:"approvedBy,omitempty"`
+	Created      time.Time            `json:"created"`
+	Modified     time.Time            `json:"modified"`
+}
+
+// ID returns the ID
+func (t *PoolSquareTrade) ID() int64 {
+	return t.id
+}
+
+// FromUserID returns the ID of the member who proposed the trade
+func (t *PoolSquareTrade) FromUserID() int64 {
+	return t.fromUserID
+}
+
+// ToUserID returns the ID of the member who was asked to trade
+func (t *PoolSquareTrade) ToUserID() int64 {
+	return t.toUserID
+}
+
+// State returns the state
+func (t *PoolSquareTrade) State() PoolSquareTradeState {
+	return t.state
+}
+
+// JSON will return the JSON representation
+func (t *PoolSquareTrade) JSON() *PoolSquareTradeJSON {
+	return &PoolSquareTradeJSON{
+		ID:           t.id,
+		GridID:       t.gridID,
+		FromUserID:   t.fromUserID,
+		FromSquareID: t.fromSquareNum,
+		ToUserID:     t.toUserID,
+		ToSquareID:   t.toSquareNum,
+		State:        t.state,
+		ApprovedBy:   t.approvedBy,
+		Created:      t.created,
+		Modified:     t.modified,
+	}
+}
+
+// isTradeable returns true if the square has been claimed by someone. A held square has not been claimed yet.
+func (p *PoolSquare) isTradeable() bool {
+	return p.userID > 0 && p.State != PoolSquareStateUnclaimed && p.State != PoolSquareStateHeld
+}
+
+// ProposeTrade will ask the owner of forSquare to swap it for square, which must belong to the user. Nothing changes
+// until the other member accepts.
+func (p *Pool) ProposeTrade(ctx context.Context, user *User, square, forSquare *PoolSquare) (*PoolSquareTrade, error) {
+	if square.UserID() != user.ID {
+		return nil, ErrTradeNotOwner
+	}
+
+	if forSquare.UserID() == user.ID {
+		return nil, ErrTradeSameOwner
+	}
+
+	if !square.isTradeable() || !forSquare.isTradeable() {
+		return nil, ErrTradeNotClaimed
+	}
+
+	if square.ParentID > 0 || forSquare.ParentID > 0 {
+		return nil, ErrTradeSecondarySquare
+	}
+
+	const query = `
+		INSERT INTO pool_square_trades (pool_id, from_user_id, from_square_id, to_user_id, to_square_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (from_square_id, to_square_id) WHERE state IN ('proposed', 'awaitingApproval') DO NOTHING
+		RETURNING id`
+	var id int64
+	if err := p.model.DB.QueryRowContext(ctx, query, p.id, user.ID, square.ID, forSquare.UserID(), forSquare.ID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTradeExists
+		}
+
+		return nil, err
+	}
+
+	return p.TradeByID(ctx, id)
+}
+
+// TradeByID will return the trade. If the trade does not belong to the pool, sql.ErrNoRows will be returned.
+func (p *Pool) TradeByID(ctx context.Context, id int64) (*PoolSquareTrade, error) {
+	const query = `
+		SELECT ` + poolSquareTradeColumns + `
+		FROM ` + poolSquareTradeTables + `
+		WHERE t.pool_id = $1 AND t.id = $2`
+	return p.model.poolSquareTradeByRow(p.model.DB.QueryRowContext(ctx, query, p.id, id).Scan)
+}
+
+// Trades will return the trades of the pool, newest first. If userID is not 0, only the trades that the user is a part
+// of are returned.
+func (p *Pool) Trades(ctx context.Context, userID int64, offset int64, limit int) ([]*PoolSquareTrade, error) {
+	return p.trades(ctx, userID, Cursor{}, offset, limit)
+}
+
+// TradesAfter will return up to limit trades that come after the cursor, along with the cursor of the next page. Like
+// Trades, a userID of 0 returns the trades of every user.
+func (p *Pool) TradesAfter(ctx context.Context, userID int64, cursor Cursor, limit int) ([]*PoolSquareTrade, Cursor, error) {
+	trades, err := p.trades(ctx, userID, cursor, 0, limit+1)
+	if err != nil || len(trades) <= limit {
+		return trades, Cursor{}, err
+	}
+
+	trades = trades[:limit]
+	return trades, Cursor{id: trades[limit-1].id}, nil
+}
+
+func (p *Pool) trades(ctx context.Context, userID int64, after Cursor, offset int64, limit int) ([]*PoolSquareTrade, error) {
+	const query = `
+		SELECT ` + poolSquareTradeColumns + `
+		FROM ` + poolSquareTradeTables + `
+		WHERE t.pool_id = $1 AND ($2 = 0 OR t.from_user_id = $2 OR t.to_user_id = $2) AND ($3 = 0 OR t.id < $3)
+		ORDER BY t.id DESC
+		OFFSET $4
+		LIMIT $5`
+	rows, err := p.model.DB.QueryContext(ctx, query, p.id, userID, after.id, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trades := make([]*PoolSquareTrade, 0)
+	for rows.Next() {
+		trade, err := p.model.poolSquareTradeByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		trades = append(trades, trade)
+	}
+
+	return trades, rows.Err()
+}
+
+// TradesCount will return the number of trades that Trades can return
+func (p *Pool) TradesCount(ctx context.Context, userID int64) (int64, error) {
+	const query = `
+		SELECT COUNT(*)
+		FROM pool_square_trades
+		WHERE pool_id = $1 AND ($2 = 0 OR from_user_id = $2 OR to_user_id = $2)`
+	var count int64
+	err := p.model.DB.QueryRowContext(ctx, query, p.id, userID).Scan(&count)
+	return count, err
+}
+
+// Accept will swap the squares. If needsApproval is true, the trade will wait for an admin to approve it instead. The
+// squares that changed owners are returned.
+func (t *PoolSquareTrade) Accept(ctx context.Context, remoteAddr string, needsApproval bool) ([]*PoolSquare, error) {
+	if !needsApproval {
+		return t.execute(ctx, PoolSquareTradeStateProposed, 0, remoteAddr)
+	}
+
+	return nil, t.setState(ctx, PoolSquareTradeStateAwaitingApproval, PoolSquareTradeStateProposed)
+}
+
+// AcceptTx will accept the trade as Accept does within the transaction. The trade is updated even if the transaction
+// is rolled back.
+func (t *PoolSquareTrade) AcceptTx(ctx context.Context, tx *sql.Tx, remoteAddr string, needsApproval bool) ([]*PoolSquare, error) {
+	if !needsApproval {
+		return t.executeTx(ctx, tx, PoolSquareTradeStateProposed, 0, remoteAddr)
+	}
+
+	modified, err := t.setStateTx(ctx, tx, PoolSquareTradeStateAwaitingApproval, 0, PoolSquareTradeStateProposed)
+	if err != nil {
+		return nil, err
+	}
+
+	t.setStateFields(PoolSquareTradeStateAwaitingApproval, 0, modified)
+	return nil, nil
+}
+
+// Approve will swap the squares of a trade that was waiting on an admin. The squares that changed owners are returned.
+func (t *PoolSquareTrade) Approve(ctx context.Context, admin *User, remoteAddr string) ([]*PoolSquare, error) {
+	return t.execute(ctx, PoolSquareTradeStateAwaitingApproval, admin.ID, remoteAddr)
+}
+
+// ApproveTx will approve the trade as Approve does within the transaction. The trade is updated even if the
+// transaction is rolled back.
+func (t *PoolSquareTrade) ApproveTx(ctx context.Context, tx *sql.Tx, admin *User, remoteAddr string) ([]*PoolSquare, error) {
+	return t.executeTx(ctx, tx, PoolSquareTradeStateAwaitingApproval, admin.ID, remoteAddr)
+}
+
+// Decline will turn down a trade that is open
+func (t *PoolSquareTrade) Decline(ctx context.Context) error {
+	return t.setState(ctx, PoolSquareTradeStateDeclined, PoolSquareTradeStateProposed, PoolSquareTradeStateAwaitingApproval)
+}
+
+// Cancel will withdraw a trade that is open
+func (t *PoolSquareTrade) Cancel(ctx context.Context) error {
+	return t.setState(ctx, PoolSquareTradeStateCancelled, PoolSquareTradeStateProposed, PoolSquareTradeStateAwaitingApproval)
+}
+
+// setState will move the trade to the state if it is in one of the from states. If it is not, ErrTradeNotOpen is
+// returned.
+func (t *PoolSquareTrade) setState(ctx context.Context, state PoolSquareTradeState, from ...PoolSquareTradeState) error {
+	var modified time.Time
+	err := t.model.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		modified, err = t.setStateTx(ctx, tx, state, 0, from...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	t.setStateFields(state, 0, modified)
+	return nil
+}
+
+// setStateTx will update the trade within the transaction and return when it was modified. The trade itself is left
+// alone until the transaction is committed.
+func (t *PoolSquareTrade) setStateTx(ctx context.Context, tx *sql.Tx, state PoolSquareTradeState, approvedBy int64, from ...PoolSquareTradeState) (time.Time, error) {
+	var current PoolSquareTradeState
+	if err := tx.QueryRowContext(ctx, "SELECT state FROM pool_square_trades WHERE id = $1 FOR UPDATE", t.id).Scan(&current); err != nil {
+		return time.Time{}, err
+	}
+
+	allowed := false
+	for _, f := range from {
+		if current == f {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		return time.Time{}, ErrTradeNotOpen
+	}
+
+	const query = `
+		UPDATE pool_square_trades
+		SET state = $1, approved_by = NULLIF($2, 0), modified = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $3
+		RETURNING modified`
+	var modified time.Time
+	err := tx.QueryRowContext(ctx, query, state, approvedBy, t.id).Scan(&modified)
+	return modified, err
+}
+
+func (t *PoolSquareTrade) setStateFields(state PoolSquareTradeState, approvedBy int64, modified time.Time) {
+	t.state = state
+	t.approvedBy = approvedBy
+	t.modified = modified.In(locationNewYork)
+}
+
+type tradedSquare struct {
+	id            int64
+	userID        int64
+	claimant      string
+	state         PoolSquareState
+	participantID *int64
+}
+
+func (s tradedSquare) isClaimedBy(userID int64) bool {
+	return s.userID == userID && s.state != PoolSquareStateUnclaimed && s.state != PoolSquareStateHeld
+}
+
+// execute will swap the owners of the squares, along with any secondary squares, and log the change against every
+// square with a link to the trade
+func (t *PoolSquareTrade) execute(ctx context.Context, from PoolSquareTradeState, approvedBy int64, remoteAddr string) ([]*PoolSquare, error) {
+	var squares []*PoolSquare
+	err := t.model.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		squares, err = t.executeTx(ctx, tx, from, approvedBy, remoteAddr)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return squares, nil
+}
+
+// executeTx will swap the owners of the squares within the transaction and return the squares that were traded
+func (t *PoolSquareTrade) executeTx(ctx context.Context, tx *sql.Tx, from PoolSquareTradeState, approvedBy int64, remoteAddr string) ([]*PoolSquare, error) {
+	var ip *string
+	if remoteAddr != "" {
+		addr := ipFromRemoteAddr(remoteAddr)
+		ip = &addr
+	}
+
+	modified, err := t.setStateTx(ctx, tx, PoolSquareTradeStateAccepted, approvedBy, from)
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+		SELECT id, COALESCE(user_id, 0), COALESCE(claimant, ''), state, participant_id
+		FROM pool_squares
+		WHERE id = $1
+		FOR UPDATE`
+	var fromSquare, toSquare tradedSquare
+	if err := tx.QueryRowContext(ctx, query, t.fromSquareID).Scan(&fromSquare.id, &fromSquare.userID, &fromSquare.claimant, &fromSquare.state, &fromSquare.participantID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.QueryRowContext(ctx, query, t.toSquareID).Scan(&toSquare.id, &toSquare.userID, &toSquare.claimant, &toSquare.state, &toSquare.participantID); err != nil {
+		return nil, err
+	}
+
+	if !fromSquare.isClaimedBy(t.fromUserID) || !toSquare.isClaimedBy(t.toUserID) {
+		return nil, ErrTradeSquaresChanged
+	}
+
+	swaps := []struct {
+		square    tradedSquare
+		owner     tradedSquare
+		forSquare int
+	}{
+		{square: fromSquare, owner: toSquare, forSquare: t.toSquareNum},
+		{square: toSquare, owner: fromSquare, forSquare: t.fromSquareNum},
+	}
+
+	for _, swap := range swaps {
+		// what the member paid for goes with them, so the square takes on the state of the square they gave up
+		const updateQuery = `
+			UPDATE pool_squares
+			SET user_id = $1, claimant = $2, state = $3, participant_id = $4, modified = (NOW() AT TIME ZONE 'utc')
+			WHERE id = $5 OR parent_id = $5
+			RETURNING id`
+		rows, err := tx.QueryContext(ctx, updateQuery, swap.owner.userID, swap.owner.claimant, swap.owner.state, swap.owner.participantID, swap.square.id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := make([]int64, 0)
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			ids = append(ids, id)
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		note := fmt.Sprintf("trade: `%s` traded to `%s` for square %d", swap.square.claimant, swap.owner.claimant, swap.forSquare)
+		for _, id := range ids {
+			const logQuery = `
+				INSERT INTO pool_squares_logs (pool_square_id, user_id, state, claimant, note, remote_addr, trade_id)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`
+			if _, err := tx.ExecContext(ctx, logQuery, id, swap.owner.userID, swap.owner.state, swap.owner.claimant, note, ip, t.id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	t.setStateFields(PoolSquareTradeStateAccepted, approvedBy, modified)
+	return t.squares(ctx, tx)
+}
+
+// squares returns the squares of the trade along with their secondary squares
+func (t *PoolSquareTrade) squares(ctx context.Context, q Queryable) ([]*PoolSquare, error) {
+	const query = `
+		SELECT ` + poolSquareColumns + `
+		FROM
+			pool_squares ps
+		LEFT JOIN
+			pool_squares ps2 ON ps.parent_id = ps2.id
+		WHERE
+			ps.id IN ($1, $2) OR ps.parent_id IN ($1, $2)
+		ORDER BY
+			ps.square_id`
+	rows, err := q.QueryContext(ctx, query, t.fromSquareID, t.toSquareID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// this is a little hacky. it's done to be able to call squareByRow()
+	pool := &Pool{model: t.model, id: t.poolID}
+
+	squares := make([]*PoolSquare, 0)
+	for rows.Next() {
+		square, err := pool.squareByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		squares = append(squares, square)
+	}
+
+	return squares, rows.Err()
+}
+
+const poolSquareTradeColumns = `
+	t.id,
+	t.pool_id,
+	COALESCE(fs.grid_id, 0),
+	t.from_user_id,
+	t.from_square_id,
+	fs.square_id,
+	t.to_user_id,
+	t.to_square_id,
+	ts.square_id,
+	t.state,
+	COALESCE(t.approved_by, 0),
+	t.created,
+	t.modified`
+
+const poolSquareTradeTables = `
+	pool_square_trades t
+	INNER JOIN pool_squares fs ON fs.id = t.from_square_id
+	INNER JOIN pool_squares ts ON ts.id = t.to_square_id`
+
+func (m *Model) poolSquareTradeByRow(scan scanFunc) (*PoolSquareTrade, error) {
+	t := PoolSquareTrade{model: m}
+	if err := scan(&t.id, &t.poolID, &t.gridID, &t.fromUserID, &t.fromSquareID, &t.fromSquareNum, &t.toUserID, &t.toSquareID, &t.toSquareNum, &t.state, &t.approvedBy, &t.created, &t.modified); err != nil {
+		return nil, err
+	}
+
+	t.created = t.created.In(locationNewYork)
+	t.modified = t.modified.In(locationNewYork)
+
+	return &t, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestSquareTrade(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	u1, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	u2, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, u1.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	squares, err := pool.Squares()
+	g.Expect(err).Should(gomega.Succeed())
+
+	claim := func(square *PoolSquare, user *User, claimant string, state PoolSquareState) {
+		square.SetClaimant(claimant)
+		square.State = state
+		square.SetUserID(user.ID)
+		g.Expect(square.Save(ctx, m.DB, true, PoolSquareLog{})).Should(gomega.Succeed())
+	}
+
+	claim(squares[1], u1, "User One", PoolSquareStatePaidFull)
+	claim(squares[2], u2, "User Two", PoolSquareStateClaimed)
+
+	_, err = pool.ProposeTrade(ctx, u1, squares[2], squares[1])
+	g.Expect(err).Should(gomega.Equal(ErrTradeNotOwner))
+	_, err = pool.ProposeTrade(ctx, u1, squares[1], squares[3])
+	g.Expect(err).Should(gomega.Equal(ErrTradeNotClaimed))
+
+	trade, err := pool.ProposeTrade(ctx, u1, squares[1], squares[2])
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(trade.State()).Should(gomega.Equal(PoolSquareTradeStateProposed))
+	g.Expect(trade.JSON().FromSquareID).Should(gomega.Equal(1))
+	g.Expect(trade.JSON().ToSquareID).Should(gomega.Equal(2))
+
+	_, err = pool.ProposeTrade(ctx, u1, squares[1], squares[2])
+	g.Expect(err).Should(gomega.Equal(ErrTradeExists))
+
+	trades, err := pool.Trades(ctx, u2.ID, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(trades)).Should(gomega.Equal(1))
+
+	page, cursor, err := pool.TradesAfter(ctx, u2.ID, Cursor{}, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(page)).Should(gomega.Equal(1))
+	g.Expect(page[0].ID()).Should(gomega.Equal(trade.ID()))
+	g.Expect(cursor.IsZero()).Should(gomega.BeTrue())
+
+	// a trade that needs approval does not change anything until an admin approves it
+	traded, err := trade.Accept(ctx, "127.0.0.1", true)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(traded)).Should(gomega.Equal(0))
+	g.Expect(trade.State()).Should(gomega.Equal(PoolSquareTradeStateAwaitingApproval))
+
+	_, err = trade.Accept(ctx, "127.0.0.1", false)
+	g.Expect(err).Should(gomega.Equal(ErrTradeNotOpen))
+
+	traded, err = trade.Approve(ctx, u1, "127.0.0.1")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(traded)).Should(gomega.Equal(2))
+	g.Expect(trade.State()).Should(gomega.Equal(PoolSquareTradeStateAccepted))
+	g.Expect(trade.JSON().ApprovedBy).Should(gomega.Equal(u1.ID))
+
+	square, err := pool.SquareBySquareID(1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.UserID()).Should(gomega.Equal(u2.ID))
+	g.Expect(square.Claimant()).Should(gomega.Equal("User Two"))
+	g.Expect(square.State).Should(gomega.Equal(PoolSquareStateClaimed))
+
+	g.Expect(square.LoadLogs(ctx)).Should(gomega.Succeed())
+	g.Expect(square.Logs[0].TradeID()).Should(gomega.Equal(trade.ID()))
+	g.Expect(square.Logs[0].Note).Should(gomega.Equal("trade: `User One` traded to `User Two` for square 2"))
+
+	square, err = pool.SquareBySquareID(2)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.UserID()).Should(gomega.Equal(u1.ID))
+	g.Expect(square.State).Should(gomega.Equal(PoolSquareStatePaidFull))
+
+	// a trade for squares that have since changed hands cannot go through
+	forSquare, err := pool.SquareBySquareID(1)
+	g.Expect(err).Should(gomega.Succeed())
+	trade, err = pool.ProposeTrade(ctx, u1, square, forSquare)
+	g.Expect(err).Should(gomega.Succeed())
+	claim(squares[1], u1, "User One", PoolSquareStateClaimed)
+	_, err = trade.Accept(ctx, "127.0.0.1", false)
+	g.Expect(err).Should(gomega.Equal(ErrTradeSquaresChanged))
+
+	g.Expect(trade.Cancel(ctx)).Should(gomega.Succeed())
+	g.Expect(trade.State()).Should(gomega.Equal(PoolSquareTradeStateCancelled))
+	g.Expect(trade.Decline(ctx)).Should(gomega.Equal(ErrTradeNotOpen))
+}
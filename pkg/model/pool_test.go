@@ -97,6 +97,7 @@ func TestPool(t *testing.T) {
 	g.Expect(pool.passwordHash).ShouldNot(gomega.Equal("my-other-unique-password"))
 	g.Expect(argon2id.Compare(pool.passwordHash, "my-other-unique-password")).Should(gomega.Succeed())
 	g.Expect(pool.OpenAccessOnLock()).Should(gomega.BeFalse())
+	g.Expect(pool.TimeZone()).Should(gomega.Equal("America/New_York"))
 
 	originalPasswordHash := pool.passwordHash
 	g.Expect(pool.SetPassword("my-other-unique-password")).Should(gomega.Succeed())
@@ -108,6 +109,7 @@ func TestPool(t *testing.T) {
 	pool.IncrementCheckID()
 	pool.SetArchived(true)
 	pool.SetOpenAccessOnLock(true)
+	g.Expect(pool.SetTimeZone("America/Chicago")).Should(gomega.Succeed())
 
 	err = pool.Save(context.Background())
 	g.Expect(err).Should(gomega.Succeed())
@@ -121,6 +123,7 @@ func TestPool(t *testing.T) {
 	g.Expect(pool2.CheckID()).Should(gomega.Equal(1))
 	g.Expect(pool2.Archived()).Should(gomega.BeTrue())
 	g.Expect(pool2.OpenAccessOnLock()).Should(gomega.BeTrue())
+	g.Expect(pool2.TimeZone()).Should(gomega.Equal("America/Chicago"))
 
 	pool3, err := m.PoolByToken(context.Background(), pool2.token)
 	g.Expect(err).Should(gomega.Succeed())
@@ -175,6 +178,26 @@ func TestGridCollections(t *testing.T) {
 	collection, err = m.PoolsOwnedByUserID(context.Background(), user2.ID, false, 0, 10)
 	g.Expect(err).Should(gomega.Succeed())
 	g.Expect(len(collection)).Should(gomega.Equal(0))
+
+	pool2, err := m.NewPool(context.Background(), user.ID, "Another for Collection", GridTypeStd100, "my-other-unique-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	collection, cursor, err := m.PoolsOwnedByUserIDAfter(context.Background(), user.ID, false, Cursor{}, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(collection)).Should(gomega.Equal(1))
+	g.Expect(collection[0].ID()).Should(gomega.Equal(pool2.ID()))
+	g.Expect(cursor.IsZero()).Should(gomega.BeFalse())
+
+	collection, cursor, err = m.PoolsOwnedByUserIDAfter(context.Background(), user.ID, false, cursor, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(collection)).Should(gomega.Equal(1))
+	g.Expect(collection[0].ID()).Should(gomega.Equal(pool.ID()))
+	g.Expect(cursor.IsZero()).Should(gomega.BeTrue())
+
+	collection, cursor, err = m.PoolsJoinedByUserIDAfter(context.Background(), user2.ID, Cursor{}, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(collection)).Should(gomega.Equal(1))
+	g.Expect(cursor.IsZero()).Should(gomega.BeTrue())
 }
 
 func TestGridCollectionPagination(t *testing.T) {
@@ -312,14 +335,47 @@ func TestGridSquares(t *testing.T) {
 	g.Expect(square.Logs[1].userID).Should(gomega.Equal(user.ID))
 	g.Expect(square.Logs[1].Claimant()).Should(gomega.Equal("Test User"))
 
-	logs, err := pool.Logs(context.Background(), 0, 1000)
+	logs, err := pool.Logs(context.Background(), LogFilter{}, 0, 1000)
 	g.Expect(err).Should(gomega.Succeed())
 	g.Expect(len(logs)).Should(gomega.BeNumerically(">", 0))
 
-	count, err := pool.LogsCount(context.Background())
+	count, err := pool.LogsCount(context.Background(), LogFilter{})
 	g.Expect(err).Should(gomega.Succeed())
 	g.Expect(count).Should(gomega.Equal(int64(len(logs))))
 
+	filter := LogFilter{SquareID: 15, UserID: user.ID, Claimant: "test us", Note: "NOTE", State: PoolSquareStateClaimed}
+	logs, cursor, err := pool.LogsAfter(context.Background(), filter, Cursor{}, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(logs)).Should(gomega.Equal(1))
+	g.Expect(logs[0].Note).Should(gomega.Equal("A new note"))
+	g.Expect(cursor.IsZero()).Should(gomega.BeFalse())
+
+	logs, cursor, err = pool.LogsAfter(context.Background(), filter, cursor, 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(logs)).Should(gomega.Equal(1))
+	g.Expect(logs[0].Note).Should(gomega.Equal("Test Note"))
+	g.Expect(cursor.IsZero()).Should(gomega.BeTrue())
+
+	count, err = pool.LogsCount(context.Background(), filter)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(2)))
+
+	count, err = pool.LogsCount(context.Background(), LogFilter{Note: "%"})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(0)))
+
+	count, err = pool.LogsCount(context.Background(), LogFilter{SquareID: 16})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(0)))
+
+	count, err = pool.LogsCount(context.Background(), LogFilter{From: time.Now().Add(time.Hour)})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(0)))
+
+	notes, err := pool.LastNotes(context.Background())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(notes).Should(gomega.Equal(map[int]string{15: "A new note"}))
+
 	square.claimant = "New User"
 	err = square.Save(context.Background(), m.DB, false, PoolSquareLog{
 		Note: "",
@@ -327,6 +383,128 @@ func TestGridSquares(t *testing.T) {
 	g.Expect(err).Should(gomega.Equal(ErrSquareAlreadyClaimed))
 }
 
+func TestSquaresPerGrid(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	pool := getPool(m)
+	first, err := pool.DefaultGrid(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+
+	g.Expect(pool.SetSquaresPerGrid(ctx, true)).Should(gomega.Succeed())
+	g.Expect(pool.SquaresPerGrid()).Should(gomega.BeTrue())
+
+	_, err = pool.Squares()
+	g.Expect(err).Should(gomega.Equal(ErrSquaresPerGrid))
+
+	// grids created afterwards get their own squares as well
+	second := pool.NewGrid()
+	g.Expect(second.Save(ctx)).Should(gomega.Succeed())
+
+	firstSquares, err := pool.GridSquares(ctx, first)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(firstSquares)).Should(gomega.Equal(25))
+
+	secondSquares, err := pool.GridSquares(ctx, second)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(secondSquares)).Should(gomega.Equal(25))
+	g.Expect(secondSquares[5].GridID).Should(gomega.Equal(second.ID()))
+	g.Expect(secondSquares[5].ID).ShouldNot(gomega.Equal(firstSquares[5].ID))
+
+	square := secondSquares[5]
+	square.SetClaimant("Second Grid")
+	square.State = PoolSquareStateClaimed
+	g.Expect(square.Save(ctx, m.DB, false, PoolSquareLog{Note: "claimed on the second grid"})).Should(gomega.Succeed())
+
+	square, err = pool.GridSquareBySquareID(ctx, first, 5)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.State).Should(gomega.Equal(PoolSquareStateUnclaimed))
+
+	square, err = pool.GridSquareBySquareID(ctx, second, 5)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(square.Claimant()).Should(gomega.Equal("Second Grid"))
+
+	logs, err := pool.GridLogs(ctx, second, LogFilter{}, 0, 100)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(logs)).Should(gomega.Equal(1))
+	g.Expect(logs[0].GridID()).Should(gomega.Equal(second.ID()))
+
+	count, err := pool.GridLogsCount(ctx, first, LogFilter{})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(0)))
+
+	notes, err := pool.GridLastNotes(ctx, second)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(notes).Should(gomega.Equal(map[int]string{5: "claimed on the second grid"}))
+
+	g.Expect(pool.SetSquaresPerGrid(ctx, false)).Should(gomega.Equal(ErrSquaresClaimed))
+}
+
+func TestMaxSquaresPerUser(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	user, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, user.ID, "Test Pool", GridTypeRoll100, "a password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool.SetMaxSquaresPerUser(2)
+	g.Expect(pool.Save(ctx)).Should(gomega.Succeed())
+
+	pool, err = m.PoolByID(pool.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(pool.MaxSquaresPerUser()).Should(gomega.Equal(2))
+	g.Expect(pool.JSON().MaxSquaresPerUser).Should(gomega.Equal(2))
+
+	squares, err := pool.Squares()
+	g.Expect(err).Should(gomega.Succeed())
+
+	claim := func(square *PoolSquare, isAdmin bool) error {
+		square.SetClaimant("Test User")
+		square.State = PoolSquareStateClaimed
+		square.SetUserID(user.ID)
+		return square.Save(ctx, m.DB, isAdmin, PoolSquareLog{})
+	}
+
+	g.Expect(claim(squares[1], false)).Should(gomega.Succeed())
+
+	// a secondary square counts as part of its parent
+	tx, err := m.DB.BeginTx(ctx, nil)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(squares[2].SetParentSquare(ctx, tx, squares[1])).Should(gomega.Succeed())
+	g.Expect(tx.Commit()).Should(gomega.Succeed())
+	g.Expect(claim(squares[2], false)).Should(gomega.Succeed())
+
+	claimed, err := pool.SquaresClaimedBy(ctx, user.ID)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(claimed).Should(gomega.Equal(1))
+
+	g.Expect(claim(squares[3], false)).Should(gomega.Succeed())
+	g.Expect(claim(squares[4], false)).Should(gomega.Equal(ErrSquareLimit))
+
+	// admins are not limited
+	g.Expect(claim(squares[4], true)).Should(gomega.Succeed())
+
+	claimed, err = pool.SquaresClaimedBy(ctx, user.ID)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(claimed).Should(gomega.Equal(3))
+
+	// unclaiming a square frees up the quota
+	pool.SetMaxSquaresPerUser(3)
+	g.Expect(pool.Save(ctx)).Should(gomega.Succeed())
+	squares[3].State = PoolSquareStateUnclaimed
+	g.Expect(squares[3].Save(ctx, m.DB, false, PoolSquareLog{})).Should(gomega.Succeed())
+	g.Expect(claim(squares[5], false)).Should(gomega.Succeed())
+}
+
 func TestLocks(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 
@@ -403,3 +581,19 @@ func TestNumberOfSquares(t *testing.T) {
 	p.gridType = GridTypeRoll100
 	g.Expect(p.NumberOfSquares()).Should(gomega.Equal(100))
 }
+
+func TestPoolTimeZone(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	p := Pool{}
+	g.Expect(p.Location()).Should(gomega.Equal(locationNewYork))
+
+	g.Expect(p.SetTimeZone("America/Los_Angeles")).Should(gomega.Succeed())
+	g.Expect(p.TimeZone()).Should(gomega.Equal("America/Los_Angeles"))
+	g.Expect(p.Location().String()).Should(gomega.Equal("America/Los_Angeles"))
+
+	for _, name := range []string{"", "Local", "America/Nowhere", "../etc/passwd"} {
+		g.Expect(p.SetTimeZone(name)).Should(gomega.Equal(ErrInvalidTimeZone))
+	}
+	g.Expect(p.TimeZone()).Should(gomega.Equal("America/Los_Angeles"))
+}
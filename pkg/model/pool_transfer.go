@@ -0,0 +1,227 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PoolTransferTTL is how long the new owner has to accept a transfer of the pool
+const PoolTransferTTL = time.Hour * 24 * 7
+
+// ErrTransferToOwner happens when trying to transfer a pool to the user who already owns it
+var ErrTransferToOwner = errors.New("the pool is already owned by this user")
+
+// ErrTransferNotMember happens when trying to transfer a pool to a user who has not joined it
+var ErrTransferNotMember = errors.New("the pool can only be transferred to a member")
+
+// ErrTransferNotPermitted happens when the new owner of a pool would not be allowed to create one
+var ErrTransferNotPermitted = errors.New("the pool can only be transferred to a user who is allowed to create pools")
+
+// PoolTransfer is a pending transfer of a pool to a new owner
+type PoolTransfer struct {
+	poolID     int64
+	fromUserID int64
+	toUserID   int64
+	created    time.Time
+	expires    time.Time
+}
+
+// PoolTransferJSON is the JSON representation of a PoolTransfer
+type PoolTransferJSON struct {
+	FromUserID int64     `json:"fromUserId"`
+	ToUserID   int64     `json:"toUserId"`
+	Created    time.Time `json:"created"`
+	Expires    time.Time `json:"expires"`
+}
+
+// FromUserID returns the ID of the owner who started the transfer
+func (t *PoolTransfer) FromUserID() int64 {
+	return t.fromUserID
+}
+
+// ToUserID returns the ID of the user who will become the owner
+func (t *PoolTransfer) ToUserID() int64 {
+	return t.toUserID
+}
+
+// Expires returns when the transfer can no longer be accepted
+func (t *PoolTransfer) Expires() time.Time {
+	return t.expires
+}
+
+// JSON will return the JSON representation
+func (t *PoolTransfer) JSON() *PoolTransferJSON {
+	return &PoolTransferJSON{
+		FromUserID: t.fromUserID,
+		ToUserID:   t.toUserID,
+		Created:    t.created,
+		Expires:    t.expires,
+	}
+}
+
+type transferState struct {
+	UserID int64 `json:"userId"`
+}
+
+// StartTransfer will offer the ownership of the pool to another member. Nothing changes until they accept it. Any
+// transfer that was already pending is replaced. The offer is recorded in the audit log of the pool.
+func (p *Pool) StartTransfer(ctx context.Context, actor *User, remoteAddr string, to *User) (*PoolTransfer, error) {
+	if to.ID == p.userID {
+		return nil, ErrTransferToOwner
+	}
+
+	if !to.HasPermission(PermissionCreatePool) {
+		return nil, ErrTransferNotPermitted
+	}
+
+	if isMember, err := to.IsMemberOf(ctx, p); err != nil {
+		return nil, err
+	} else if !isMember {
+		return nil, ErrTransferNotMember
+	}
+
+	var transfer *PoolTransfer
+	err := p.model.inTx(ctx, func(tx *sql.Tx) error {
+		const query = `
+			INSERT INTO pool_transfers (pool_id, from_user_id, to_user_id, expires)
+			VALUES ($1, $2, $3, (NOW() AT TIME ZONE 'utc') + INTERVAL '1 microsecond' * $4)
+			ON CONFLICT (pool_id) DO UPDATE SET
+				from_user_id = EXCLUDED.from_user_id,
+				to_user_id = EXCLUDED.to_user_id,
+				created = (NOW() AT TIME ZONE 'utc'),
+				expires = EXCLUDED.expires
+			RETURNING ` + poolTransferColumns
+		row := tx.QueryRowContext(ctx, query, p.id, p.userID, to.ID, PoolTransferTTL/time.Microsecond)
+
+		var err error
+		if transfer, err = poolTransferByRow(row.Scan); err != nil {
+			return err
+		}
+
+		return p.recordAuditEvent(ctx, tx, actor, remoteAddr, AuditEventOwnershipTransferStarted, to.ID, nil, transferState{UserID: to.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// Transfer will return the pending transfer of the pool. If there is none, or it has expired, sql.ErrNoRows will be
+// returned.
+func (p *Pool) Transfer(ctx context.Context) (*PoolTransfer, error) {
+	const query = `
+		SELECT ` + poolTransferColumns + `
+		FROM pool_transfers
+		WHERE pool_id = $1 AND expires > (NOW() AT TIME ZONE 'utc')`
+	return poolTransferByRow(p.model.DB.QueryRowContext(ctx, query, p.id).Scan)
+}
+
+// CancelTransfer will cancel the pending transfer of the pool. This may be done by the owner or by the user who was
+// offered the pool. If there is no transfer, sql.ErrNoRows will be returned.
+func (p *Pool) CancelTransfer(ctx context.Context, actor *User, remoteAddr string) error {
+	return p.model.inTx(ctx, func(tx *sql.Tx) error {
+		var toUserID int64
+		row := tx.QueryRowContext(ctx, "DELETE FROM pool_transfers WHERE pool_id = $1 RETURNING to_user_id", p.id)
+		if err := row.Scan(&toUserID); err != nil {
+			return err
+		}
+
+		return p.recordAuditEvent(ctx, tx, actor, remoteAddr, AuditEventOwnershipTransferCancelled, toUserID, transferState{UserID: toUserID}, nil)
+	})
+}
+
+// AcceptTransfer will make the user the owner of the pool. The user must be the one that the pool was offered to, and
+// the transfer must have been started by the current owner. The previous owner stays on as an admin. If there is no
+// such transfer, sql.ErrNoRows will be returned. If the user is no longer a member, ErrTransferNotMember will be
+// returned.
+func (p *Pool) AcceptTransfer(ctx context.Context, user *User, remoteAddr string) error {
+	if !user.HasPermission(PermissionCreatePool) {
+		return ErrTransferNotPermitted
+	}
+
+	var previousOwnerID int64
+	err := p.model.inTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, "SELECT user_id FROM pools WHERE id = $1 FOR UPDATE", p.id).Scan(&previousOwnerID); err != nil {
+			return err
+		}
+
+		const query = `
+			DELETE FROM pool_transfers
+			WHERE pool_id = $1 AND from_user_id = $2 AND to_user_id = $3 AND expires > (NOW() AT TIME ZONE 'utc')
+			RETURNING pool_id`
+		var poolID int64
+		if err := tx.QueryRowContext(ctx, query, p.id, previousOwnerID, user.ID).Scan(&poolID); err != nil {
+			return err
+		}
+
+		// the new owner must still be a member. They are removed since the owner is not stored in pools_users.
+		res, err := tx.ExecContext(ctx, "DELETE FROM pools_users WHERE pool_id = $1 AND user_id = $2", p.id, user.ID)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrTransferNotMember
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE pools SET user_id = $1, modified = (NOW() AT TIME ZONE 'utc') WHERE id = $2", user.ID, p.id); err != nil {
+			return err
+		}
+
+		const adminQuery = `
+			INSERT INTO pools_users (pool_id, user_id, is_admin)
+			VALUES ($1, $2, true)
+			ON CONFLICT (user_id, pool_id) DO UPDATE SET is_admin = true, modified = (NOW() AT TIME ZONE 'utc')`
+		if _, err := tx.ExecContext(ctx, adminQuery, p.id, previousOwnerID); err != nil {
+			return err
+		}
+
+		return p.recordAuditEvent(ctx, tx, user, remoteAddr, AuditEventOwnershipTransferred, user.ID, transferState{UserID: previousOwnerID}, transferState{UserID: user.ID})
+	})
+	if err != nil {
+		return err
+	}
+
+	p.userID = user.ID
+	return nil
+}
+
+const poolTransferColumns = `
+	pool_id,
+	from_user_id,
+	to_user_id,
+	created,
+	expires`
+
+func poolTransferByRow(scan scanFunc) (*PoolTransfer, error) {
+	var t PoolTransfer
+	if err := scan(&t.poolID, &t.fromUserID, &t.toUserID, &t.created, &t.expires); err != nil {
+		return nil, err
+	}
+
+	t.created = t.created.In(locationNewYork)
+	t.expires = t.expires.In(locationNewYork)
+
+	return &t, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestPoolTransfer(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	owner, err := m.GetUser(ctx, IssuerAuth0, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	pool, err := m.NewPool(ctx, owner.ID, "test", GridTypeStd25, "join-password")
+	g.Expect(err).Should(gomega.Succeed())
+
+	guest, err := m.GetUser(ctx, IssuerSqMGR, randString())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(guest.JoinPool(ctx, pool)).Should(gomega.Succeed())
+
+	u, err := m.GetUser(ctx, IssuerAuth0, randString())
+	g.Expect(err).Should(gomega.Succeed())
+
+	_, err = pool.StartTransfer(ctx, owner, "127.0.0.1", owner)
+	g.Expect(err).Should(gomega.Equal(ErrTransferToOwner))
+	_, err = pool.StartTransfer(ctx, owner, "127.0.0.1", guest)
+	g.Expect(err).Should(gomega.Equal(ErrTransferNotPermitted))
+	_, err = pool.StartTransfer(ctx, owner, "127.0.0.1", u)
+	g.Expect(err).Should(gomega.Equal(ErrTransferNotMember))
+
+	_, err = pool.Transfer(ctx)
+	g.Expect(err).Should(gomega.Equal(sql.ErrNoRows))
+
+	g.Expect(u.JoinPool(ctx, pool)).Should(gomega.Succeed())
+	transfer, err := pool.StartTransfer(ctx, owner, "127.0.0.1", u)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(transfer.FromUserID()).Should(gomega.Equal(owner.ID))
+	g.Expect(transfer.ToUserID()).Should(gomega.Equal(u.ID))
+
+	g.Expect(pool.AcceptTransfer(ctx, guest, "127.0.0.1")).Should(gomega.Equal(ErrTransferNotPermitted))
+	g.Expect(pool.AcceptTransfer(ctx, owner, "127.0.0.1")).Should(gomega.Equal(sql.ErrNoRows))
+
+	g.Expect(pool.CancelTransfer(ctx, u, "127.0.0.1")).Should(gomega.Succeed())
+	g.Expect(pool.AcceptTransfer(ctx, u, "127.0.0.1")).Should(gomega.Equal(sql.ErrNoRows))
+
+	_, err = pool.StartTransfer(ctx, owner, "127.0.0.1", u)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(pool.AcceptTransfer(ctx, u, "127.0.0.1")).Should(gomega.Succeed())
+	g.Expect(pool.UserID()).Should(gomega.Equal(u.ID))
+
+	pool, err = m.PoolByID(pool.ID())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(pool.UserID()).Should(gomega.Equal(u.ID))
+
+	owned, err := m.PoolsOwnedByUserID(ctx, u.ID, false, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(owned)).Should(gomega.Equal(1))
+
+	count, err := m.PoolsOwnedByUserIDCount(ctx, owner.ID, false)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(count).Should(gomega.Equal(int64(0)))
+
+	// the previous owner stays on as an admin
+	isAdmin, err := owner.IsAdminOf(ctx, pool)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(isAdmin).Should(gomega.BeTrue())
+
+	_, err = pool.Transfer(ctx)
+	g.Expect(err).Should(gomega.Equal(sql.ErrNoRows))
+
+	events, err := pool.AuditEvents(ctx, AuditFilter{}, 0, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(events)).Should(gomega.Equal(4))
+	g.Expect(events[0].Event()).Should(gomega.Equal(AuditEventOwnershipTransferred))
+	g.Expect(string(events[0].JSON().Before)).Should(gomega.MatchJSON(`{"userId":` + strconv.FormatInt(owner.ID, 10) + `}`))
+	g.Expect(events[2].Event()).Should(gomega.Equal(AuditEventOwnershipTransferCancelled))
+	g.Expect(events[2].UserID()).Should(gomega.Equal(u.ID))
+}
@@ -0,0 +1,344 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MaxWebhooksPerPool is the maximum number of webhooks that can be registered on a pool
+const MaxWebhooksPerPool = 10
+
+// ErrWebhookLimit happens when a user tries to register more webhooks on a pool than allowed
+var ErrWebhookLimit = fmt.Errorf("you cannot register more than %d webhooks per pool", MaxWebhooksPerPool)
+
+// webhookSecretBytes is the number of random bytes in a webhook secret
+const webhookSecretBytes = 32
+
+// WebhookDeliveryState is the state of a single webhook delivery
+type WebhookDeliveryState string
+
+// Webhook delivery states
+const (
+	WebhookDeliveryStatePending   WebhookDeliveryState = "pending"
+	WebhookDeliveryStateDelivered WebhookDeliveryState = "delivered"
+	WebhookDeliveryStateFailed    WebhookDeliveryState = "failed"
+)
+
+// PoolWebhook is a URL that will receive events from a pool
+type PoolWebhook struct {
+	model    *Model
+	id       int64
+	poolID   int64
+	url      string
+	secret   string
+	events   []string
+	created  time.Time
+	modified time.Time
+}
+
+// PoolWebhookJSON is the JSON representation of a PoolWebhook. The secret is only included when the webhook is
+// first created.
+type PoolWebhookJSON struct {
+	ID       int64     `json:"id"`
+	URL      string    `json:"url"`
+	Secret   string    `json:"secret,omitempty"`
+	Events   []string  `json:"events"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+}
+
+// ID is a getter
+func (p *PoolWebhook) ID() int64 {
+	return p.id
+}
+
+// URL is a getter
+func (p *PoolWebhook) URL() string {
+	return p.url
+}
+
+// Secret is the key used to sign the deliveries
+func (p *PoolWebhook) Secret() string {
+	return p.secret
+}
+
+// Events are the event types that the webhook will receive
+func (p *PoolWebhook) Events() []string {
+	return p.events
+}
+
+// JSON returns the JSON representation of the webhook without the secret
+func (p *PoolWebhook) JSON() *PoolWebhookJSON {
+	return &PoolWebhookJSON{
+		ID:       p.id,
+		URL:      p.url,
+		Events:   p.events,
+		Created:  p.created,
+		Modified: p.modified,
+	}
+}
+
+const poolWebhookColumns = `id, pool_id, url, secret, events, created, modified`
+
+// NewWebhook will register a new webhook on the pool with a randomly generated secret
+func (p *Pool) NewWebhook(ctx context.Context, url string, events []string) (*PoolWebhook, error) {
+	secretBytes := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, err
+	}
+
+	const query = `
+INSERT INTO pool_webhooks (pool_id, url, secret, events)
+SELECT $1, $2, $3, $4
+WHERE (SELECT COUNT(*) FROM pool_webhooks WHERE pool_id = $1) < $5
+RETURNING ` + poolWebhookColumns
+
+	row := p.model.DB.QueryRowContext(ctx, query, p.id, url, hex.EncodeToString(secretBytes), pq.Array(events), MaxWebhooksPerPool)
+	webhook, err := p.model.poolWebhookByRow(row.Scan)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookLimit
+	}
+
+	return webhook, err
+}
+
+// Webhooks returns all of the webhooks registered on the pool
+func (p *Pool) Webhooks(ctx context.Context) ([]*PoolWebhook, error) {
+	rows, err := p.model.DB.QueryContext(ctx, "SELECT "+poolWebhookColumns+" FROM pool_webhooks WHERE pool_id = $1 ORDER BY id", p.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]*PoolWebhook, 0)
+	for rows.Next() {
+		webhook, err := p.model.poolWebhookByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+// WebhookByID returns a single webhook registered on the pool
+func (p *Pool) WebhookByID(ctx context.Context, id int64) (*PoolWebhook, error) {
+	row := p.model.DB.QueryRowContext(ctx, "SELECT "+poolWebhookColumns+" FROM pool_webhooks WHERE pool_id = $1 AND id = $2", p.id, id)
+	return p.model.poolWebhookByRow(row.Scan)
+}
+
+// Delete will remove the webhook along with its deliveries
+func (p *PoolWebhook) Delete(ctx context.Context) error {
+	_, err := p.model.DB.ExecContext(ctx, "DELETE FROM pool_webhooks WHERE id = $1", p.id)
+	return err
+}
+
+// EnqueueWebhookDeliveries will queue the payload for delivery to every webhook of the pool that wants the event.
+// It returns the number of deliveries that were queued.
+func (p *Pool) EnqueueWebhookDeliveries(ctx context.Context, event string, payload []byte) (int64, error) {
+	return p.enqueueWebhookDeliveries(ctx, p.model.DB, event, payload)
+}
+
+// EnqueueWebhookDeliveriesTx will queue the deliveries within the transaction of the change that caused the event, so
+// that they are only queued if the change is saved
+func (p *Pool) EnqueueWebhookDeliveriesTx(ctx context.Context, tx *sql.Tx, event string, payload []byte) (int64, error) {
+	return p.enqueueWebhookDeliveries(ctx, tx, event, payload)
+}
+
+func (p *Pool) enqueueWebhookDeliveries(ctx context.Context, q Queryable, event string, payload []byte) (int64, error) {
+	const query = `
+INSERT INTO pool_webhook_deliveries (webhook_id, event, payload)
+SELECT id, $2, $3
+FROM pool_webhooks
+WHERE pool_id = $1 AND $2 = ANY(events)
+`
+
+	res, err := q.ExecContext(ctx, query, p.id, event, string(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (m *Model) poolWebhookByRow(scan scanFunc) (*PoolWebhook, error) {
+	p := PoolWebhook{model: m}
+	if err := scan(&p.id, &p.poolID, &p.url, &p.secret, pq.Array(&p.events), &p.created, &p.modified); err != nil {
+		return nil, err
+	}
+
+	p.created = p.created.In(locationNewYork)
+	p.modified = p.modified.In(locationNewYork)
+	return &p, nil
+}
+
+// WebhookDelivery is a single event that is sent to a webhook
+type WebhookDelivery struct {
+	model          *Model
+	ID             int64                `json:"id"`
+	WebhookID      int64                `json:"webhookId"`
+	Event          string               `json:"event"`
+	Payload        string               `json:"-"`
+	State          WebhookDeliveryState `json:"state"`
+	Attempts       int                  `json:"attempts"`
+	NextAttempt    time.Time            `json:"nextAttempt"`
+	LastStatusCode *int                 `json:"lastStatusCode"`
+	LastError      *string              `json:"lastError"`
+	Created        time.Time            `json:"created"`
+	Modified       time.Time            `json:"modified"`
+
+	// URL and Secret are only loaded when leasing a delivery
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+const webhookDeliveryColumns = `d.id, d.webhook_id, d.event, d.payload, d.state, d.attempts, d.next_attempt, d.last_status_code, d.last_error, d.created, d.modified`
+
+// Deliveries returns the most recent deliveries of the webhook
+func (p *PoolWebhook) Deliveries(ctx context.Context, limit int) ([]*WebhookDelivery, error) {
+	const query = `
+SELECT ` + webhookDeliveryColumns + `
+FROM pool_webhook_deliveries d
+WHERE d.webhook_id = $1
+ORDER BY d.id DESC
+LIMIT $2
+`
+
+	rows, err := p.model.DB.QueryContext(ctx, query, p.id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := p.model.webhookDeliveryByRow(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// LeaseWebhookDeliveries will return up to limit deliveries that are due. Each one is pushed back by the lease so that
+// no other process will try to deliver it at the same time. The caller must record the outcome of each delivery with
+// Delivered() or Failed().
+func (m *Model) LeaseWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	const query = `
+UPDATE pool_webhook_deliveries d
+SET next_attempt = (NOW() AT TIME ZONE 'utc') + $2 * INTERVAL '1 millisecond'
+FROM pool_webhooks w
+WHERE d.webhook_id = w.id AND d.id IN (
+	SELECT id
+	FROM pool_webhook_deliveries
+	WHERE state = 'pending' AND next_attempt <= (NOW() AT TIME ZONE 'utc')
+	ORDER BY next_attempt
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + webhookDeliveryColumns + `, w.url, w.secret`
+
+	rows, err := m.DB.QueryContext(ctx, query, limit, int64(lease/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*WebhookDelivery, 0)
+	for rows.Next() {
+		var url, secret string
+		delivery, err := m.webhookDeliveryByRow(func(dest ...interface{}) error {
+			return rows.Scan(append(dest, &url, &secret)...)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.URL = url
+		delivery.Secret = secret
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// Delivered will record a successful attempt
+func (d *WebhookDelivery) Delivered(ctx context.Context, statusCode int) error {
+	return d.recordAttempt(ctx, WebhookDeliveryStateDelivered, &statusCode, nil, time.Now())
+}
+
+// Failed will record a failed attempt. If retryAt is nil, the delivery will not be tried again.
+func (d *WebhookDelivery) Failed(ctx context.Context, statusCode *int, errMsg string, retryAt *time.Time) error {
+	state := WebhookDeliveryStatePending
+	nextAttempt := time.Now()
+	if retryAt == nil {
+		state = WebhookDeliveryStateFailed
+	} else {
+		nextAttempt = *retryAt
+	}
+
+	return d.recordAttempt(ctx, state, statusCode, &errMsg, nextAttempt)
+}
+
+func (d *WebhookDelivery) recordAttempt(ctx context.Context, state WebhookDeliveryState, statusCode *int, errMsg *string, nextAttempt time.Time) error {
+	const query = `
+UPDATE pool_webhook_deliveries
+SET state = $1,
+	attempts = attempts + 1,
+	last_status_code = $2,
+	last_error = $3,
+	next_attempt = $4,
+	modified = (NOW() AT TIME ZONE 'utc')
+WHERE id = $5
+RETURNING attempts`
+
+	if err := d.model.DB.QueryRowContext(ctx, query, state, statusCode, errMsg, nextAttempt.UTC(), d.ID).Scan(&d.Attempts); err != nil {
+		return err
+	}
+
+	d.State = state
+	d.LastStatusCode = statusCode
+	d.LastError = errMsg
+	d.NextAttempt = nextAttempt.In(locationNewYork)
+
+	return nil
+}
+
+func (m *Model) webhookDeliveryByRow(scan scanFunc) (*WebhookDelivery, error) {
+	d := WebhookDelivery{model: m}
+	if err := scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.State, &d.Attempts, &d.NextAttempt, &d.LastStatusCode, &d.LastError, &d.Created, &d.Modified); err != nil {
+		return nil, err
+	}
+
+	d.NextAttempt = d.NextAttempt.In(locationNewYork)
+	d.Created = d.Created.In(locationNewYork)
+	d.Modified = d.Modified.In(locationNewYork)
+	return &d, nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestPoolWebhooks(t *testing.T) {
+	ensureIntegration(t)
+
+	g := gomega.NewWithT(t)
+	m := New(getDB())
+	ctx := context.Background()
+
+	pool := getPool(m)
+	webhook, err := pool.NewWebhook(ctx, "https://example.com/hook", []string{"squareClaimed", "poolLocked"})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(webhook.ID()).Should(gomega.BeNumerically(">", 0))
+	g.Expect(webhook.Secret()).Should(gomega.HaveLen(webhookSecretBytes * 2))
+	g.Expect(webhook.Events()).Should(gomega.Equal([]string{"squareClaimed", "poolLocked"}))
+	g.Expect(webhook.JSON().Secret).Should(gomega.Equal(""))
+
+	webhooks, err := pool.Webhooks(ctx)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(webhooks)).Should(gomega.Equal(1))
+	g.Expect(webhooks[0].URL()).Should(gomega.Equal("https://example.com/hook"))
+
+	n, err := pool.EnqueueWebhookDeliveries(ctx, "squareUnclaimed", []byte(`{}`))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(n).Should(gomega.Equal(int64(0)))
+
+	n, err = pool.EnqueueWebhookDeliveries(ctx, "squareClaimed", []byte(`{"event":"squareClaimed"}`))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(n).Should(gomega.Equal(int64(1)))
+
+	leased := func() []*WebhookDelivery {
+		deliveries, err := m.LeaseWebhookDeliveries(ctx, 100, time.Minute)
+		g.Expect(err).Should(gomega.Succeed())
+
+		mine := make([]*WebhookDelivery, 0)
+		for _, d := range deliveries {
+			if d.WebhookID == webhook.ID() {
+				mine = append(mine, d)
+			}
+		}
+
+		return mine
+	}
+
+	deliveries := leased()
+	g.Expect(len(deliveries)).Should(gomega.Equal(1))
+	delivery := deliveries[0]
+	g.Expect(delivery.URL).Should(gomega.Equal(webhook.URL()))
+	g.Expect(delivery.Secret).Should(gomega.Equal(webhook.Secret()))
+	g.Expect(delivery.Payload).Should(gomega.Equal(`{"event":"squareClaimed"}`))
+
+	// the lease should keep it from being picked up again
+	g.Expect(leased()).Should(gomega.BeEmpty())
+
+	statusCode := 500
+	retryAt := time.Now().Add(-time.Second)
+	g.Expect(delivery.Failed(ctx, &statusCode, "webhook: received status 500", &retryAt)).Should(gomega.Succeed())
+	g.Expect(delivery.Attempts).Should(gomega.Equal(1))
+	g.Expect(delivery.State).Should(gomega.Equal(WebhookDeliveryStatePending))
+
+	deliveries = leased()
+	g.Expect(len(deliveries)).Should(gomega.Equal(1))
+	g.Expect(deliveries[0].Delivered(ctx, 200)).Should(gomega.Succeed())
+	g.Expect(deliveries[0].Attempts).Should(gomega.Equal(2))
+	g.Expect(leased()).Should(gomega.BeEmpty())
+
+	_, err = pool.EnqueueWebhookDeliveries(ctx, "poolLocked", []byte(`{"event":"poolLocked"}`))
+	g.Expect(err).Should(gomega.Succeed())
+	deliveries = leased()
+	g.Expect(len(deliveries)).Should(gomega.Equal(1))
+	g.Expect(deliveries[0].Failed(ctx, nil, "dial tcp: connection refused", nil)).Should(gomega.Succeed())
+	g.Expect(deliveries[0].State).Should(gomega.Equal(WebhookDeliveryStateFailed))
+
+	history, err := webhook.Deliveries(ctx, 10)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(len(history)).Should(gomega.Equal(2))
+	g.Expect(history[0].Event).Should(gomega.Equal("poolLocked"))
+	g.Expect(history[0].State).Should(gomega.Equal(WebhookDeliveryStateFailed))
+	g.Expect(*history[0].LastError).Should(gomega.Equal("dial tcp: connection refused"))
+	g.Expect(history[1].State).Should(gomega.Equal(WebhookDeliveryStateDelivered))
+	g.Expect(*history[1].LastStatusCode).Should(gomega.Equal(200))
+
+	for i := 1; i < MaxWebhooksPerPool; i++ {
+		_, err := pool.NewWebhook(ctx, "https://example.com/hook", []string{"poolLocked"})
+		g.Expect(err).Should(gomega.Succeed())
+	}
+
+	_, err = pool.NewWebhook(ctx, "https://example.com/hook", []string{"poolLocked"})
+	g.Expect(err).Should(gomega.Equal(ErrWebhookLimit))
+
+	g.Expect(webhook.Delete(ctx)).Should(gomega.Succeed())
+	_, err = pool.WebhookByID(ctx, webhook.ID())
+	g.Expect(err).Should(gomega.HaveOccurred())
+}
@@ -29,3 +29,18 @@ type Queryable interface {
 }
 
 type scanFunc func(dest ...interface{}) error
+
+// inTx will run fn in a transaction. The transaction is committed if fn succeeds and rolled back if it does not.
+func (m *Model) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
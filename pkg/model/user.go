@@ -108,8 +108,13 @@ func (m *Model) GetUser(ctx context.Context, issuer, storeID string) (*User, err
 	return m.userByRow(row)
 }
 
-// JoinPool will link a user to a pool.
+// JoinPool will link a user to a pool. If the user has been banned from the pool, ErrUserBanned will be returned.
 func (u *User) JoinPool(ctx context.Context, p *Pool) error {
+	return u.joinPool(ctx, u.DB, p)
+}
+
+// joinPool will link a user to a pool with the given connection, so that the pool can be joined within a transaction
+func (u *User) joinPool(ctx context.Context, q Queryable, p *Pool) error {
 	// no-op
 	if isAdmin, err := u.IsAdminOf(ctx, p); err != nil {
 		return err
@@ -117,7 +122,13 @@ func (u *User) JoinPool(ctx context.Context, p *Pool) error {
 		return nil
 	}
 
-	_, err := u.DB.ExecContext(ctx, "INSERT INTO pools_users (pool_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", p.id, u.ID)
+	if isBanned, err := u.IsBannedFrom(ctx, p); err != nil {
+		return err
+	} else if isBanned {
+		return ErrUserBanned
+	}
+
+	_, err := q.ExecContext(ctx, "INSERT INTO pools_users (pool_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", p.id, u.ID)
 	return err
 }
 
@@ -157,7 +168,11 @@ func (u *User) IsMemberOf(ctx context.Context, p *Pool) (bool, error) {
 // SetAdminOf will set the user as an admin in the pool. Note: this user must
 // already be a member
 func (u *User) SetAdminOf(ctx context.Context, p *Pool, isAdmin bool) error {
-	_, err := u.DB.ExecContext(ctx, `
+	return u.setAdminOf(ctx, u.DB, p, isAdmin)
+}
+
+func (u *User) setAdminOf(ctx context.Context, q Queryable, p *Pool, isAdmin bool) error {
+	_, err := q.ExecContext(ctx, `
 UPDATE
     pools_users
 SET
@@ -192,6 +207,18 @@ func (u *User) IsAdminOf(ctx context.Context, p *Pool) (bool, error) {
 	return ok, nil
 }
 
+// IsBannedFrom will return true if the user has been banned from the pool
+func (u *User) IsBannedFrom(ctx context.Context, p *Pool) (bool, error) {
+	row := u.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pool_bans WHERE pool_id = $1 AND user_id = $2)", p.id, u.ID)
+
+	var ok bool
+	if err := row.Scan(&ok); err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
 // PoolsCreatedWithin will return the number of pools a user has created within a given duration period
 func (u *User) PoolsCreatedWithin(ctx context.Context, within time.Duration) (int, error) {
 	const query = "SELECT COUNT(*) FROM pools WHERE user_id = $1 AND created > NOW() - INTERVAL '1 microsecond' * $2"
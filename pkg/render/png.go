@@ -0,0 +1,119 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"unicode/utf8"
+)
+
+// PNGScale is the number of pixels in the PNG for each unit of the layout
+const PNGScale = 2
+
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+// PNG will write the board as a PNG image
+func PNG(w io.Writer, b *Board) error {
+	l := newLayout(b.Size)
+	c := &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, l.width*PNGScale, l.height*PNGScale))}
+	draw(b, c)
+
+	return png.Encode(w, c.img)
+}
+
+// fill sets a rectangle of pixels. Unlike rect, the coordinates are in pixels.
+func (c *pngCanvas) fill(x, y, w, h int, fill color.RGBA) {
+	r := image.Rect(x, y, x+w, y+h).Intersect(c.img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			c.img.SetRGBA(px, py, fill)
+		}
+	}
+}
+
+func (c *pngCanvas) rect(x, y, w, h int, fill color.RGBA) {
+	c.fill(x*PNGScale, y*PNGScale, w*PNGScale, h*PNGScale, fill)
+}
+
+func (c *pngCanvas) text(x, y int, s string, scale int, fill color.RGBA, a align) {
+	width := utf8.RuneCountInString(s)*glyphWidth*scale - scale
+	switch a {
+	case alignCenter:
+		x -= width / 2
+	case alignRight:
+		x -= width
+	}
+
+	c.drawText(s, scale, fill, func(tx, ty int) (int, int) {
+		return x*PNGScale + tx, y*PNGScale + ty
+	})
+}
+
+func (c *pngCanvas) verticalText(cx, cy int, s string, scale int, fill color.RGBA) {
+	width := utf8.RuneCountInString(s)*glyphWidth*scale*PNGScale - scale*PNGScale
+	height := glyphHeight * scale * PNGScale
+
+	// rotate a quarter turn counterclockwise around the center
+	c.drawText(s, scale, fill, func(tx, ty int) (int, int) {
+		tx -= width / 2
+		ty -= height / 2
+		return cx*PNGScale + ty, cy*PNGScale - tx - scale*PNGScale
+	})
+}
+
+// drawText draws each pixel of the text through transform, which maps the pixel offset within the text to the
+// position in the image
+func (c *pngCanvas) drawText(s string, scale int, fill color.RGBA, transform func(tx, ty int) (int, int)) {
+	px := scale * PNGScale
+	i := 0
+	for _, r := range s {
+		g := glyph(r)
+		for col, bits := range g {
+			for row := 0; row < glyphHeight; row++ {
+				if bits&(1<<uint(row)) == 0 {
+					continue
+				}
+
+				x, y := transform((i*glyphWidth+col)*px, row*px)
+				c.fill(x, y, px, px, fill)
+			}
+		}
+
+		i++
+	}
+}
+
+func (c *pngCanvas) icon(x, y int, name string, scale int, fill color.RGBA) {
+	bitmap := iconBitmap(name)
+	px := scale * PNGScale
+	for row, bits := range bitmap {
+		for col := 0; col < 8; col++ {
+			if bits&(0x80>>uint(col)) != 0 {
+				c.fill(x*PNGScale+col*px, y*PNGScale+row*px, px, px, fill)
+			}
+		}
+	}
+}
+
+func (c *pngCanvas) title(x, y, w, h int, s string) {
+	// PNGs don't have anywhere to put it
+}
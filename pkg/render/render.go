@@ -0,0 +1,315 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package render draws a squares grid as an SVG or PNG image. It has no dependencies outside of the standard library
+// so that the output is the same everywhere it runs.
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Board is everything that is drawn
+type Board struct {
+	// Size is the number of rows and columns, either 5 or 10
+	Size        int
+	Title       string
+	HomeTeam    Team
+	AwayTeam    Team
+	HomeNumbers []int
+	AwayNumbers []int
+	// Squares are keyed by square ID, which start at 1 in the top-left corner and are counted left-to-right,
+	// top-to-bottom
+	Squares map[int]Square
+}
+
+// Team is the name and colors of a team. Colors are hex colors like #ff0000 or #f00.
+type Team struct {
+	Name   string
+	Color1 string
+	Color2 string
+}
+
+// Square is a single square on the board
+type Square struct {
+	Claimant   string
+	Annotation string
+	// Icon is the name of an annotation icon, e.g. "trophy". See Icons.
+	Icon string
+}
+
+const (
+	margin        = 10
+	titleHeight   = 28
+	teamBarSize   = 24
+	numberBarSize = 24
+	boardSize     = 600
+	glyphWidth    = 6
+	glyphHeight   = 8
+)
+
+var (
+	colorWhite       = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	colorBlack       = color.RGBA{A: 0xff}
+	colorGridLine    = color.RGBA{R: 0x99, G: 0x99, B: 0x99, A: 0xff}
+	colorSquareID    = color.RGBA{R: 0x88, G: 0x88, B: 0x88, A: 0xff}
+	colorClaimed     = color.RGBA{R: 0xf4, G: 0xf8, B: 0xff, A: 0xff}
+	colorIcon        = color.RGBA{R: 0xd4, G: 0x8a, B: 0x00, A: 0xff}
+	colorDefaultTeam = color.RGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xff}
+)
+
+type align int
+
+const (
+	alignLeft align = iota
+	alignCenter
+	alignRight
+)
+
+// canvas is implemented by each output format so that the board is only laid out once
+type canvas interface {
+	rect(x, y, w, h int, fill color.RGBA)
+	// text draws a single line of text. y is the top of the line, which is glyphHeight * scale tall.
+	text(x, y int, s string, scale int, fill color.RGBA, a align)
+	// verticalText draws a single line of text centered on cx, cy that reads from the bottom to the top
+	verticalText(cx, cy int, s string, scale int, fill color.RGBA)
+	icon(x, y int, name string, scale int, fill color.RGBA)
+	// title adds a description to the square, if supported by the format
+	title(x, y, w, h int, s string)
+}
+
+type layout struct {
+	size   int
+	cell   int
+	gridX  int
+	gridY  int
+	width  int
+	height int
+}
+
+func newLayout(size int) layout {
+	if size != 5 {
+		size = 10
+	}
+
+	l := layout{
+		size:  size,
+		cell:  boardSize / size,
+		gridX: margin + teamBarSize + numberBarSize,
+		gridY: margin + titleHeight + teamBarSize + numberBarSize,
+	}
+
+	l.width = l.gridX + boardSize + margin
+	l.height = l.gridY + boardSize + margin
+	return l
+}
+
+// textScale is the scale used for the text within a square
+func (l layout) textScale() int {
+	if l.size == 5 {
+		return 2
+	}
+
+	return 1
+}
+
+func draw(b *Board, c canvas) layout {
+	l := newLayout(b.Size)
+
+	c.rect(0, 0, l.width, l.height, colorWhite)
+	c.text(l.width/2, margin+(titleHeight-2*glyphHeight)/2, truncate(b.Title, (l.width-2*margin)/(2*glyphWidth)), 2, colorBlack, alignCenter)
+
+	// home team across the top
+	homeColor1, homeColor2 := parseColor(b.HomeTeam.Color1), parseColor(b.HomeTeam.Color2)
+	y := margin + titleHeight
+	c.rect(l.gridX, y, boardSize, teamBarSize, homeColor1)
+	c.text(l.gridX+boardSize/2, y+(teamBarSize-2*glyphHeight)/2, truncate(b.HomeTeam.Name, boardSize/(2*glyphWidth)), 2, contrast(homeColor1), alignCenter)
+
+	y += teamBarSize
+	c.rect(l.gridX, y, boardSize, numberBarSize, homeColor2)
+	for col := 0; col < l.size; col++ {
+		c.text(l.gridX+col*l.cell+l.cell/2, y+(numberBarSize-2*glyphHeight)/2, numbersLabel(b.HomeNumbers, col, l.size), 2, contrast(homeColor2), alignCenter)
+	}
+
+	// away team down the side
+	awayColor1, awayColor2 := parseColor(b.AwayTeam.Color1), parseColor(b.AwayTeam.Color2)
+	c.rect(margin, l.gridY, teamBarSize, boardSize, awayColor1)
+	c.verticalText(margin+teamBarSize/2, l.gridY+boardSize/2, truncate(b.AwayTeam.Name, boardSize/(2*glyphWidth)), 2, contrast(awayColor1))
+
+	x := margin + teamBarSize
+	c.rect(x, l.gridY, numberBarSize, boardSize, awayColor2)
+	for row := 0; row < l.size; row++ {
+		c.text(x+numberBarSize/2, l.gridY+row*l.cell+(l.cell-2*glyphHeight)/2, numbersLabel(b.AwayNumbers, row, l.size), 2, contrast(awayColor2), alignCenter)
+	}
+
+	// the squares
+	scale := l.textScale()
+	maxChars := (l.cell - 4) / (glyphWidth * scale)
+	maxLines := (l.cell - glyphHeight - 6) / ((glyphHeight + 2) * scale)
+	for row := 0; row < l.size; row++ {
+		for col := 0; col < l.size; col++ {
+			squareID := row*l.size + col + 1
+			sx, sy := l.gridX+col*l.cell, l.gridY+row*l.cell
+
+			square, ok := b.Squares[squareID]
+			if ok && square.Claimant != "" {
+				c.rect(sx, sy, l.cell, l.cell, colorClaimed)
+			}
+
+			c.text(sx+2, sy+2, fmt.Sprintf("%d", squareID), 1, colorSquareID, alignLeft)
+
+			if !ok {
+				continue
+			}
+
+			if square.Icon != "" {
+				c.icon(sx+l.cell-2-glyphHeight*scale, sy+2, square.Icon, scale, colorIcon)
+			}
+
+			lines := wrap(square.Claimant, maxChars, maxLines)
+			lineHeight := (glyphHeight + 2) * scale
+			ly := sy + glyphHeight + 4 + (l.cell-glyphHeight-4-len(lines)*lineHeight)/2
+			for _, line := range lines {
+				c.text(sx+l.cell/2, ly, line, scale, colorBlack, alignCenter)
+				ly += lineHeight
+			}
+
+			desc := fmt.Sprintf("Square %d", squareID)
+			if square.Claimant != "" {
+				desc += ": " + square.Claimant
+			}
+			if square.Annotation != "" {
+				desc += " (" + square.Annotation + ")"
+			}
+			c.title(sx, sy, l.cell, l.cell, desc)
+		}
+	}
+
+	// grid lines go on top so that the square backgrounds don't cover them
+	for i := 0; i <= l.size; i++ {
+		c.rect(l.gridX+i*l.cell, l.gridY, 1, boardSize+1, colorGridLine)
+		c.rect(l.gridX, l.gridY+i*l.cell, boardSize+1, 1, colorGridLine)
+	}
+
+	return l
+}
+
+// numbersLabel returns the label of a row or column. On a 5x5 board, each row and column covers two numbers.
+func numbersLabel(numbers []int, i, size int) string {
+	if len(numbers) != 10 {
+		return "?"
+	}
+
+	perSquare := 10 / size
+	strs := make([]string, perSquare)
+	for j := 0; j < perSquare; j++ {
+		strs[j] = fmt.Sprintf("%d", numbers[i*perSquare+j])
+	}
+
+	return strings.Join(strs, " ")
+}
+
+// wrap will break the string into at most maxLines lines of at most maxChars characters
+func wrap(s string, maxChars, maxLines int) []string {
+	if maxChars < 1 || maxLines < 1 {
+		return nil
+	}
+
+	words := strings.Fields(s)
+	lines := make([]string, 0, maxLines)
+	current := ""
+	for i := 0; i < len(words); i++ {
+		word := []rune(words[i])
+		if len(word) > maxChars {
+			// break long words
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+
+			lines = append(lines, string(word[:maxChars]))
+			words[i] = string(word[maxChars:])
+			i--
+		} else if current == "" {
+			current = string(word)
+		} else if len([]rune(current))+1+len(word) <= maxChars {
+			current += " " + string(word)
+		} else {
+			lines = append(lines, current)
+			current = string(word)
+		}
+
+		if len(lines) > maxLines {
+			break
+		}
+	}
+
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] = truncate(lines[maxLines-1]+"...", maxChars)
+	}
+
+	return lines
+}
+
+// truncate will shorten the string to at most maxChars characters
+func truncate(s string, maxChars int) string {
+	r := []rune(s)
+	if len(r) <= maxChars {
+		return s
+	}
+
+	if maxChars <= 2 {
+		return string(r[:maxChars])
+	}
+
+	return string(r[:maxChars-2]) + ".."
+}
+
+// parseColor parses a hex color like #ff0000 or #f00
+func parseColor(s string) color.RGBA {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+
+	var r, g, b uint8
+	if len(s) != 6 {
+		return colorDefaultTeam
+	}
+
+	if _, err := fmt.Sscanf(s, "%02x%02x%02x", &r, &g, &b); err != nil {
+		return colorDefaultTeam
+	}
+
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}
+}
+
+// contrast returns black or white, whichever is easier to read on the background
+func contrast(bg color.RGBA) color.RGBA {
+	luminance := 299*int(bg.R) + 587*int(bg.G) + 114*int(bg.B)
+	if luminance > 128*1000 {
+		return colorBlack
+	}
+
+	return colorWhite
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func testBoards() map[string]*Board {
+	numbers := []int{3, 0, 7, 4, 1, 9, 6, 2, 8, 5}
+	return map[string]*Board{
+		"std100": {
+			Size:        10,
+			Title:       "Big Game <2026>",
+			HomeTeam:    Team{Name: "Home Team", Color1: "#002244", Color2: "#c60c30"},
+			AwayTeam:    Team{Name: "Away Team", Color1: "#fb4f14", Color2: "#ccc"},
+			HomeNumbers: numbers,
+			AwayNumbers: []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+			Squares: map[int]Square{
+				1:   {Claimant: "Tom"},
+				12:  {Claimant: "Bartholomew Richardson-Smythe", Annotation: "Q1 winner", Icon: "trophy"},
+				55:  {Claimant: "Amy & Bob", Icon: "dollar-sign"},
+				100: {Icon: "not-an-icon"},
+			},
+		},
+		"std25": {
+			Size:     5,
+			Title:    "Quarters",
+			HomeTeam: Team{Name: "Home", Color1: "#555555", Color2: "#999999"},
+			AwayTeam: Team{Name: "Away", Color1: "#666666", Color2: "#333333"},
+			Squares: map[int]Square{
+				7:  {Claimant: "Jane Doe", Icon: "football-ball"},
+				25: {Claimant: "Unicode Ünïcödé"},
+			},
+		},
+	}
+}
+
+func golden(t *testing.T, name string, actual []byte) []byte {
+	t.Helper()
+
+	file := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(file, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return expected
+}
+
+func TestSVG(t *testing.T) {
+	for name, board := range testBoards() {
+		t.Run(name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+
+			var buf bytes.Buffer
+			g.Expect(SVG(&buf, board)).Should(gomega.Succeed())
+			g.Expect(buf.String()).Should(gomega.Equal(string(golden(t, name+".svg", buf.Bytes()))))
+		})
+	}
+}
+
+func TestPNG(t *testing.T) {
+	for name, board := range testBoards() {
+		t.Run(name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+
+			var buf bytes.Buffer
+			g.Expect(PNG(&buf, board)).Should(gomega.Succeed())
+
+			// compare the pixels rather than the bytes as the encoder output can change between Go versions
+			actual, err := png.Decode(bytes.NewReader(buf.Bytes()))
+			g.Expect(err).Should(gomega.Succeed())
+			expected, err := png.Decode(bytes.NewReader(golden(t, name+".png", buf.Bytes())))
+			g.Expect(err).Should(gomega.Succeed())
+
+			l := newLayout(board.Size)
+			g.Expect(actual.Bounds()).Should(gomega.Equal(image.Rect(0, 0, l.width*PNGScale, l.height*PNGScale)))
+			g.Expect(actual.Bounds()).Should(gomega.Equal(expected.Bounds()))
+
+			for y := actual.Bounds().Min.Y; y < actual.Bounds().Max.Y; y++ {
+				for x := actual.Bounds().Min.X; x < actual.Bounds().Max.X; x++ {
+					if actual.At(x, y) != expected.At(x, y) {
+						t.Fatalf("pixel %d,%d is %v, expected %v", x, y, actual.At(x, y), expected.At(x, y))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	g.Expect(wrap("", 9, 3)).Should(gomega.BeEmpty())
+	g.Expect(wrap("Tom", 9, 3)).Should(gomega.Equal([]string{"Tom"}))
+	g.Expect(wrap("Tom   Peters", 9, 3)).Should(gomega.Equal([]string{"Tom", "Peters"}))
+	g.Expect(wrap("Amy & Bob", 9, 3)).Should(gomega.Equal([]string{"Amy & Bob"}))
+	g.Expect(wrap("Bartholomew Richardson-Smythe", 9, 3)).Should(gomega.Equal([]string{"Bartholom", "ew", "Richard.."}))
+	g.Expect(wrap("a b c d e f g h i j", 3, 2)).Should(gomega.Equal([]string{"a b", "c.."}))
+	g.Expect(wrap("Tom", 0, 3)).Should(gomega.BeNil())
+}
+
+func TestParseColor(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	g.Expect(parseColor("#ff8000")).Should(gomega.Equal(color.RGBA{R: 0xff, G: 0x80, A: 0xff}))
+	g.Expect(parseColor("#f80")).Should(gomega.Equal(color.RGBA{R: 0xff, G: 0x88, A: 0xff}))
+	g.Expect(parseColor("#ff80")).Should(gomega.Equal(colorDefaultTeam))
+	g.Expect(parseColor("nope")).Should(gomega.Equal(colorDefaultTeam))
+	g.Expect(contrast(parseColor("#ffffff"))).Should(gomega.Equal(colorBlack))
+	g.Expect(contrast(parseColor("#002244"))).Should(gomega.Equal(colorWhite))
+}
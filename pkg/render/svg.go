@@ -0,0 +1,111 @@
+/*
+Copyright 2026 Tom Peters
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+// svgFontFamily is monospaced so that text wraps the same way it does in the PNG
+const svgFontFamily = "Menlo, Consolas, 'DejaVu Sans Mono', monospace"
+
+type svgCanvas struct {
+	buf bytes.Buffer
+}
+
+// SVG will write the board as an SVG image
+func SVG(w io.Writer, b *Board) error {
+	c := &svgCanvas{}
+	l := newLayout(b.Size)
+
+	fmt.Fprintf(&c.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="%s">`+"\n", l.width, l.height, l.width, l.height, svgFontFamily)
+	draw(b, c)
+	c.buf.WriteString("</svg>\n")
+
+	_, err := c.buf.WriteTo(w)
+	return err
+}
+
+func (c *svgCanvas) rect(x, y, w, h int, fill color.RGBA) {
+	fmt.Fprintf(&c.buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", x, y, w, h, svgColor(fill))
+}
+
+func (c *svgCanvas) text(x, y int, s string, scale int, fill color.RGBA, a align) {
+	if s == "" {
+		return
+	}
+
+	anchor := "start"
+	switch a {
+	case alignCenter:
+		anchor = "middle"
+	case alignRight:
+		anchor = "end"
+	}
+
+	// y is the top of the line and SVG wants the baseline, which is the bottom of the seventh row of the glyph
+	fmt.Fprintf(&c.buf, `<text x="%d" y="%d" font-size="%d" fill="%s" text-anchor="%s">%s</text>`+"\n", x, y+7*scale, 10*scale, svgColor(fill), anchor, svgEscape(s))
+}
+
+func (c *svgCanvas) verticalText(cx, cy int, s string, scale int, fill color.RGBA) {
+	if s == "" {
+		return
+	}
+
+	fmt.Fprintf(&c.buf, `<text x="%d" y="%d" font-size="%d" fill="%s" text-anchor="middle" transform="rotate(-90 %d %d)">%s</text>`+"\n", cx, cy+3*scale, 10*scale, svgColor(fill), cx, cy, svgEscape(s))
+}
+
+func (c *svgCanvas) icon(x, y int, name string, scale int, fill color.RGBA) {
+	bitmap := iconBitmap(name)
+
+	var path bytes.Buffer
+	for row, bits := range bitmap {
+		// each run of set pixels in a row is one rectangle
+		for col := 0; col < 8; col++ {
+			if bits&(0x80>>uint(col)) == 0 {
+				continue
+			}
+
+			start := col
+			for col < 8 && bits&(0x80>>uint(col)) != 0 {
+				col++
+			}
+
+			fmt.Fprintf(&path, "M%d %dh%dv%dh%dz", x+start*scale, y+row*scale, (col-start)*scale, scale, -(col-start)*scale)
+		}
+	}
+
+	fmt.Fprintf(&c.buf, `<path d="%s" fill="%s"><title>%s</title></path>`+"\n", path.String(), svgColor(fill), svgEscape(name))
+}
+
+func (c *svgCanvas) title(x, y, w, h int, s string) {
+	fmt.Fprintf(&c.buf, `<rect x="%d" y="%d" width="%d" height="%d" fill-opacity="0"><title>%s</title></rect>`+"\n", x, y, w, h, svgEscape(s))
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func svgEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
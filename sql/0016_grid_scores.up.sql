@@ -0,0 +1,31 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+BEGIN;
+
+CREATE TYPE grid_score_periods AS ENUM ('q1', 'half', 'q3', 'final', 'ot');
+
+CREATE TABLE grid_scores (
+    grid_id bigint not null references grids (id),
+    period grid_score_periods not null,
+    home_score int not null check (home_score >= 0),
+    away_score int not null check (away_score >= 0),
+    created timestamp not null default (now() at time zone 'utc'),
+    modified timestamp not null default (now() at time zone 'utc'),
+    PRIMARY KEY (grid_id, period)
+);
+
+COMMIT;
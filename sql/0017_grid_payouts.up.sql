@@ -0,0 +1,37 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+BEGIN;
+
+CREATE TYPE unclaimed_payout_rules AS ENUM ('house', 'rollover', 'split');
+
+ALTER TABLE grid_settings
+    ADD COLUMN square_price bigint NOT NULL DEFAULT 0 CHECK (square_price >= 0),
+    ADD COLUMN house_cut int NOT NULL DEFAULT 0 CHECK (house_cut >= 0 AND house_cut <= 100),
+    ADD COLUMN unclaimed_rule unclaimed_payout_rules NOT NULL DEFAULT 'house';
+
+CREATE TYPE payout_amount_types AS ENUM ('percent', 'fixed');
+
+CREATE TABLE grid_payouts (
+    grid_id bigint not null references grids (id),
+    period grid_score_periods not null,
+    amount_type payout_amount_types not null,
+    amount bigint not null check (amount >= 0),
+    created timestamp not null default (now() at time zone 'utc'),
+    PRIMARY KEY (grid_id, period)
+);
+
+COMMIT;
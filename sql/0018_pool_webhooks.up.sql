@@ -0,0 +1,51 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+CREATE TABLE pool_webhooks
+(
+    id       bigserial not null primary key,
+    pool_id  bigint    not null references pools (id),
+    url      text      not null,
+    secret   text      not null,
+    events   text[]    not null,
+    created  timestamp not null default (now() at time zone 'utc'),
+    modified timestamp not null default (now() at time zone 'utc')
+);
+
+CREATE INDEX pool_webhooks_pool_id_idx ON pool_webhooks (pool_id);
+
+CREATE TYPE webhook_delivery_states AS ENUM ('pending', 'delivered', 'failed');
+
+CREATE TABLE pool_webhook_deliveries
+(
+    id               bigserial               not null primary key,
+    webhook_id       bigint                  not null references pool_webhooks (id) ON DELETE CASCADE,
+    event            text                    not null,
+    payload          text                    not null,
+    state            webhook_delivery_states not null default 'pending',
+    attempts         int                     not null default 0,
+    next_attempt     timestamp               not null default (now() at time zone 'utc'),
+    last_status_code int,
+    last_error       text,
+    created          timestamp               not null default (now() at time zone 'utc'),
+    modified         timestamp               not null default (now() at time zone 'utc')
+);
+
+CREATE INDEX pool_webhook_deliveries_webhook_id_idx ON pool_webhook_deliveries (webhook_id, id);
+CREATE INDEX pool_webhook_deliveries_pending_idx ON pool_webhook_deliveries (next_attempt) WHERE state = 'pending';
+
+COMMIT;
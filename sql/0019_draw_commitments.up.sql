@@ -0,0 +1,42 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+CREATE TABLE pool_draw_commitments
+(
+    id                bigserial not null primary key,
+    pool_id           bigint    not null references pools (id),
+    seed              bytea     not null,
+    commitment        text      not null,
+    algorithm_version int       not null,
+    created           timestamp not null default (now() at time zone 'utc'),
+    revealed          timestamp
+);
+
+CREATE INDEX pool_draw_commitments_pool_id_idx ON pool_draw_commitments (pool_id, id);
+
+-- a pool can only be committed to one seed at a time
+CREATE UNIQUE INDEX pool_draw_commitments_unrevealed_idx ON pool_draw_commitments (pool_id) WHERE revealed IS NULL;
+
+CREATE TABLE grid_draws
+(
+    grid_id       bigint    not null primary key references grids (id),
+    commitment_id bigint    not null references pool_draw_commitments (id),
+    entropy       text      not null default '',
+    created       timestamp not null default (now() at time zone 'utc')
+);
+
+COMMIT;
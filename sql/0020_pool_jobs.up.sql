@@ -0,0 +1,63 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+CREATE TYPE pool_job_actions AS ENUM ('drawNumbers', 'archive', 'lockReminder');
+CREATE TYPE pool_job_states AS ENUM ('pending', 'done', 'failed', 'cancelled');
+
+CREATE TABLE pool_jobs
+(
+    id           bigserial        not null primary key,
+    pool_id      bigint           not null references pools (id),
+    action       pool_job_actions not null,
+    offset_hours int              not null default 0,
+    run_at       timestamp,
+    state        pool_job_states  not null default 'pending',
+    attempts     int              not null default 0,
+    last_error   text,
+    created      timestamp        not null default (now() at time zone 'utc'),
+    modified     timestamp        not null default (now() at time zone 'utc')
+);
+
+CREATE INDEX pool_jobs_pool_id_idx ON pool_jobs (pool_id, id);
+
+-- a pool can only have one pending job per action
+CREATE UNIQUE INDEX pool_jobs_pending_action_idx ON pool_jobs (pool_id, action) WHERE state = 'pending';
+CREATE INDEX pool_jobs_run_at_idx ON pool_jobs (run_at) WHERE state = 'pending';
+
+-- pool_job_run_at returns when a job should run based upon the current state of the pool. NULL is returned if
+-- the pool does not have the date the job is relative to, e.g. the pool has no lock date.
+CREATE FUNCTION pool_job_run_at(_pool_id bigint, _action pool_job_actions, _offset_hours int) RETURNS timestamp
+    LANGUAGE plpgsql
+AS
+$$
+begin
+    if _action = 'drawNumbers' then
+        return (SELECT locks FROM pools WHERE id = _pool_id);
+    elsif _action = 'lockReminder' then
+        return (SELECT locks - _offset_hours * INTERVAL '1 hour' FROM pools WHERE id = _pool_id);
+    elsif _action = 'archive' then
+        return (SELECT MAX(event_date) + _offset_hours * INTERVAL '1 hour'
+                FROM grids
+                WHERE pool_id = _pool_id
+                  AND state = 'active');
+    end if;
+
+    return null;
+end;
+$$;
+
+COMMIT;
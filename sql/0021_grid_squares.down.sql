@@ -0,0 +1,27 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+DELETE FROM pool_squares_logs WHERE pool_square_id IN (SELECT id FROM pool_squares WHERE grid_id IS NOT NULL);
+DELETE FROM pool_squares WHERE grid_id IS NOT NULL;
+
+DROP INDEX pool_squares_sheet_idx;
+ALTER TABLE pool_squares ADD CONSTRAINT pool_squares_pool_id_square_id_key UNIQUE (pool_id, square_id);
+
+ALTER TABLE pool_squares DROP COLUMN grid_id;
+ALTER TABLE pools DROP COLUMN squares_per_grid;
+
+COMMIT;
@@ -0,0 +1,26 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+ALTER TABLE pools ADD COLUMN squares_per_grid boolean not null default false;
+
+-- squares without a grid make up the sheet that is shared by every grid of the pool
+ALTER TABLE pool_squares ADD COLUMN grid_id bigint REFERENCES grids (id);
+
+ALTER TABLE pool_squares DROP CONSTRAINT pool_squares_pool_id_square_id_key;
+CREATE UNIQUE INDEX pool_squares_sheet_idx ON pool_squares (pool_id, COALESCE(grid_id, 0), square_id);
+
+COMMIT;
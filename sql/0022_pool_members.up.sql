@@ -0,0 +1,43 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+CREATE TABLE pool_bans
+(
+    pool_id   bigint    not null references pools (id),
+    user_id   bigint    not null references users (id),
+    banned_by bigint    not null references users (id),
+    reason    text      not null default '',
+    created   timestamp not null default (now() at time zone 'utc'),
+    PRIMARY KEY (pool_id, user_id)
+);
+
+CREATE TABLE pool_audit_events
+(
+    id             bigserial not null primary key,
+    pool_id        bigint    not null references pools (id),
+    user_id        bigint references users (id),
+    target_user_id bigint references users (id),
+    event          text      not null,
+    remote_addr    text,
+    before         jsonb,
+    after          jsonb,
+    created        timestamp not null default (now() at time zone 'utc')
+);
+
+CREATE INDEX pool_audit_events_pool_id_idx ON pool_audit_events (pool_id, id);
+
+COMMIT;
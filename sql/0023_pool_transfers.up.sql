@@ -0,0 +1,27 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+CREATE TABLE pool_transfers
+(
+    pool_id      bigint    not null primary key references pools (id),
+    from_user_id bigint    not null references users (id),
+    to_user_id   bigint    not null references users (id),
+    created      timestamp not null default (now() at time zone 'utc'),
+    expires      timestamp not null
+);
+
+COMMIT;
@@ -0,0 +1,48 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+-- a value cannot be removed from an enum, so the type is recreated without it
+DELETE FROM pool_jobs WHERE action = 'assignSquares';
+
+DROP FUNCTION pool_job_run_at(bigint, pool_job_actions, int);
+
+ALTER TYPE pool_job_actions RENAME TO pool_job_actions_old;
+CREATE TYPE pool_job_actions AS ENUM ('drawNumbers', 'archive', 'lockReminder');
+ALTER TABLE pool_jobs ALTER COLUMN action TYPE pool_job_actions USING action::text::pool_job_actions;
+DROP TYPE pool_job_actions_old;
+
+CREATE FUNCTION pool_job_run_at(_pool_id bigint, _action pool_job_actions, _offset_hours int) RETURNS timestamp
+    LANGUAGE plpgsql
+AS
+$$
+begin
+    if _action = 'drawNumbers' then
+        return (SELECT locks FROM pools WHERE id = _pool_id);
+    elsif _action = 'lockReminder' then
+        return (SELECT locks - _offset_hours * INTERVAL '1 hour' FROM pools WHERE id = _pool_id);
+    elsif _action = 'archive' then
+        return (SELECT MAX(event_date) + _offset_hours * INTERVAL '1 hour'
+                FROM grids
+                WHERE pool_id = _pool_id
+                  AND state = 'active');
+    end if;
+
+    return null;
+end;
+$$;
+
+COMMIT;
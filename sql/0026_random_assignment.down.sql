@@ -0,0 +1,41 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+CREATE OR REPLACE FUNCTION pool_job_run_at(_pool_id bigint, _action pool_job_actions, _offset_hours int) RETURNS timestamp
+    LANGUAGE plpgsql
+AS
+$$
+begin
+    if _action = 'drawNumbers' then
+        return (SELECT locks FROM pools WHERE id = _pool_id);
+    elsif _action = 'lockReminder' then
+        return (SELECT locks - _offset_hours * INTERVAL '1 hour' FROM pools WHERE id = _pool_id);
+    elsif _action = 'archive' then
+        return (SELECT MAX(event_date) + _offset_hours * INTERVAL '1 hour'
+                FROM grids
+                WHERE pool_id = _pool_id
+                  AND state = 'active');
+    end if;
+
+    return null;
+end;
+$$;
+
+DROP TABLE pool_square_requests;
+ALTER TABLE pools DROP COLUMN random_assignment;
+
+COMMIT;
@@ -0,0 +1,57 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+ALTER TABLE pools ADD COLUMN random_assignment boolean NOT NULL DEFAULT false;
+
+-- the number of squares that a member wants to be assigned at random. grid_id is NULL for the squares that are shared
+-- by every grid of the pool.
+CREATE TABLE pool_square_requests
+(
+    id       bigserial not null primary key,
+    pool_id  bigint    not null references pools (id),
+    grid_id  bigint references grids (id),
+    user_id  bigint    not null references users (id),
+    claimant text      not null,
+    squares  int       not null,
+    assigned int       not null default 0,
+    created  timestamp not null default (now() at time zone 'utc'),
+    modified timestamp not null default (now() at time zone 'utc')
+);
+
+CREATE UNIQUE INDEX pool_square_requests_user_idx ON pool_square_requests (pool_id, COALESCE(grid_id, 0), user_id);
+
+CREATE OR REPLACE FUNCTION pool_job_run_at(_pool_id bigint, _action pool_job_actions, _offset_hours int) RETURNS timestamp
+    LANGUAGE plpgsql
+AS
+$$
+begin
+    if _action = 'drawNumbers' or _action = 'assignSquares' then
+        return (SELECT locks FROM pools WHERE id = _pool_id);
+    elsif _action = 'lockReminder' then
+        return (SELECT locks - _offset_hours * INTERVAL '1 hour' FROM pools WHERE id = _pool_id);
+    elsif _action = 'archive' then
+        return (SELECT MAX(event_date) + _offset_hours * INTERVAL '1 hour'
+                FROM grids
+                WHERE pool_id = _pool_id
+                  AND state = 'active');
+    end if;
+
+    return null;
+end;
+$$;
+
+COMMIT;
@@ -0,0 +1,100 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+BEGIN;
+
+DROP FUNCTION update_pool_square(_id bigint, _state square_states, _claimant text, _user_id bigint,
+    _remote_addr text, _note text, _is_admin boolean, _hold_seconds integer);
+
+ALTER TABLE pool_squares DROP COLUMN hold_expires;
+
+CREATE FUNCTION update_pool_square(_id bigint, _state square_states, _claimant text, _user_id bigint,
+                                   _remote_addr text, _note text, _is_admin boolean) RETURNS boolean
+    LANGUAGE plpgsql
+AS
+$$
+DECLARE
+    _row           pool_squares;
+    _initial_claim boolean;
+    _same_user     boolean;
+    _user_unclaim  boolean;
+    _parent_id     integer;
+    _max_squares   integer;
+    _count         integer;
+BEGIN
+    SELECT INTO _row * FROM pool_squares WHERE id = _id FOR SHARE;
+
+    _initial_claim := _row.claimant IS NULL AND _row.state = 'unclaimed';
+    _same_user := coalesce(_row.user_id, 0) = coalesce(_user_id, 0);
+    _user_unclaim := _same_user AND _row.state = 'claimed' AND _state = 'unclaimed';
+
+    IF NOT _is_admin
+        AND NOT _initial_claim
+        AND NOT _user_unclaim
+    THEN
+        RETURN FALSE;
+    END IF;
+
+    -- a secondary square is claimed along with its parent, so only the parent counts towards the limit
+    IF NOT _is_admin
+        AND _initial_claim
+        AND _state <> 'unclaimed'
+        AND _user_id IS NOT NULL
+        AND _row.parent_id IS NULL
+    THEN
+        SELECT max_squares_per_user INTO _max_squares FROM pools WHERE id = _row.pool_id;
+
+        IF _max_squares > 0 THEN
+            -- serialize the claims in the pool so that two squares claimed at once cannot both slip under the limit
+            PERFORM FROM pools WHERE id = _row.pool_id FOR NO KEY UPDATE;
+
+            SELECT count(*)
+            INTO _count
+            FROM pool_squares
+            WHERE pool_id = _row.pool_id
+              AND user_id = _user_id
+              AND state <> 'unclaimed'
+              AND parent_id IS NULL;
+
+            IF _count >= _max_squares THEN
+                RAISE EXCEPTION 'square limit reached';
+            END IF;
+        END IF;
+    END IF;
+
+    _parent_id = _row.parent_id;
+    IF _state = 'unclaimed' THEN
+        _claimant := NULL;
+        _user_id := NULL;
+        _parent_id := NULL;
+    END IF;
+
+    UPDATE pool_squares
+    SET state           = _state,
+        claimant        = _claimant,
+        user_id         = _user_id,
+        parent_id       = _parent_id,
+        modified        = (now() at time zone 'utc')
+    WHERE id = _id;
+
+    INSERT INTO pool_squares_logs (pool_square_id, user_id, state, claimant, note, remote_addr)
+    VALUES (_id, _user_id, _state, _claimant, _note, _remote_addr);
+
+    RETURN TRUE;
+END;
+$$;
+
+COMMIT;
@@ -0,0 +1,44 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+BEGIN;
+
+CREATE TYPE square_trade_states AS ENUM ('proposed', 'awaitingApproval', 'accepted', 'declined', 'cancelled');
+
+CREATE TABLE pool_square_trades
+(
+    id             bigserial           not null primary key,
+    pool_id        bigint              not null references pools (id) on delete cascade,
+    from_user_id   bigint              not null references users (id),
+    from_square_id bigint              not null references pool_squares (id) on delete cascade,
+    to_user_id     bigint              not null references users (id),
+    to_square_id   bigint              not null references pool_squares (id) on delete cascade,
+    state          square_trade_states not null default 'proposed',
+    approved_by    bigint references users (id),
+    created        timestamp           not null default (now() at time zone 'utc'),
+    modified       timestamp           not null default (now() at time zone 'utc')
+);
+
+CREATE INDEX pool_square_trades_pool_id_idx ON pool_square_trades (pool_id, id);
+
+-- the same trade cannot be proposed twice while it is still open
+CREATE UNIQUE INDEX pool_square_trades_open_idx ON pool_square_trades (from_square_id, to_square_id)
+    WHERE state IN ('proposed', 'awaitingApproval');
+
+-- the log entries of both squares point back to the trade that changed their owners
+ALTER TABLE pool_squares_logs ADD COLUMN trade_id bigint references pool_square_trades (id) on delete set null;
+
+COMMIT;
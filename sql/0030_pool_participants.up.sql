@@ -0,0 +1,135 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+BEGIN;
+
+-- someone who has squares in a pool but does not sign in. the token lets them link their account later.
+CREATE TABLE pool_participants
+(
+    id       bigserial not null primary key,
+    pool_id  bigint    not null references pools (id) on delete cascade,
+    name     text      not null,
+    token    text      not null unique,
+    user_id  bigint references users (id),
+    created  timestamp not null default (now() at time zone 'utc'),
+    modified timestamp not null default (now() at time zone 'utc')
+);
+
+CREATE INDEX pool_participants_pool_id_idx ON pool_participants (pool_id, id);
+
+ALTER TABLE pool_squares ADD COLUMN participant_id bigint references pool_participants (id) on delete set null;
+
+CREATE INDEX pool_squares_participant_id_idx ON pool_squares (participant_id) WHERE participant_id IS NOT NULL;
+
+-- a square that is unclaimed no longer belongs to the participant
+CREATE OR REPLACE FUNCTION update_pool_square(_id bigint, _state square_states, _claimant text, _user_id bigint,
+                                   _remote_addr text, _note text, _is_admin boolean,
+                                   _hold_seconds integer) RETURNS boolean
+    LANGUAGE plpgsql
+AS
+$$
+DECLARE
+    _row           pool_squares;
+    _hold_expired  boolean;
+    _initial_claim boolean;
+    _same_user     boolean;
+    _user_unclaim  boolean;
+    _holder_claim  boolean;
+    _parent_id     integer;
+    _hold_expires  timestamp;
+    _max_squares   integer;
+    _count         integer;
+BEGIN
+    SELECT INTO _row * FROM pool_squares WHERE id = _id FOR SHARE;
+
+    -- a hold that has expired but has not been released yet is as good as unclaimed
+    _hold_expired := _row.state = 'held' AND _row.hold_expires <= (now() at time zone 'utc');
+    _initial_claim := (_row.claimant IS NULL AND _row.state = 'unclaimed') OR _hold_expired;
+    _same_user := coalesce(_row.user_id, 0) = coalesce(_user_id, 0);
+    _user_unclaim := _same_user AND _row.state IN ('claimed', 'held') AND _state = 'unclaimed';
+    _holder_claim := _same_user AND _row.state = 'held' AND NOT _hold_expired AND _state = 'claimed';
+
+    IF NOT _is_admin
+        AND NOT _initial_claim
+        AND NOT _user_unclaim
+        AND NOT _holder_claim
+    THEN
+        RETURN FALSE;
+    END IF;
+
+    -- a secondary square is claimed along with its parent, so only the parent counts towards the limit
+    IF NOT _is_admin
+        AND _initial_claim
+        AND _state <> 'unclaimed'
+        AND _user_id IS NOT NULL
+        AND _row.parent_id IS NULL
+    THEN
+        SELECT max_squares_per_user INTO _max_squares FROM pools WHERE id = _row.pool_id;
+
+        IF _max_squares > 0 THEN
+            -- serialize the claims in the pool so that two squares claimed at once cannot both slip under the limit
+            PERFORM FROM pools WHERE id = _row.pool_id FOR NO KEY UPDATE;
+
+            SELECT count(*)
+            INTO _count
+            FROM pool_squares
+            WHERE pool_id = _row.pool_id
+              AND user_id = _user_id
+              AND state <> 'unclaimed'
+              AND parent_id IS NULL
+              AND id <> _id;
+
+            IF _count >= _max_squares THEN
+                RAISE EXCEPTION 'square limit reached';
+            END IF;
+        END IF;
+    END IF;
+
+    _parent_id = _row.parent_id;
+    IF _state = 'unclaimed' THEN
+        _claimant := NULL;
+        _user_id := NULL;
+        _parent_id := NULL;
+    END IF;
+
+    -- a hold keeps the expiry it was given when it was placed
+    IF _state = 'held' THEN
+        IF _row.state = 'held' AND NOT _hold_expired THEN
+            _hold_expires := _row.hold_expires;
+        ELSE
+            _hold_expires := (now() at time zone 'utc') + _hold_seconds * INTERVAL '1 second';
+        END IF;
+    END IF;
+
+    UPDATE pool_squares
+    SET state           = _state,
+        claimant        = _claimant,
+        user_id         = _user_id,
+        parent_id       = _parent_id,
+        hold_expires    = _hold_expires,
+        participant_id  = CASE WHEN _state = 'unclaimed' THEN NULL ELSE participant_id END,
+        modified        = (now() at time zone 'utc')
+    WHERE id = _id;
+
+    INSERT INTO pool_squares_logs (pool_square_id, user_id, state, claimant, note, remote_addr)
+    VALUES (_id, _user_id, _state, _claimant, _note, _remote_addr);
+
+    RETURN TRUE;
+END;
+$$;
+
+
+COMMIT;
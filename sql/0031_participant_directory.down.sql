@@ -0,0 +1,26 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+BEGIN;
+
+-- the participants without a token were not created by an admin, so they are removed along with their link to squares
+DELETE FROM pool_participants WHERE token IS NULL;
+
+DROP INDEX pool_participants_user_id_idx;
+ALTER TABLE pool_participants ALTER COLUMN token SET NOT NULL;
+ALTER TABLE pool_participants DROP COLUMN contact;
+
+COMMIT;
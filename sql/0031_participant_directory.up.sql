@@ -0,0 +1,99 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+BEGIN;
+
+ALTER TABLE pool_participants ADD COLUMN contact text NOT NULL DEFAULT '';
+
+-- a participant that is linked to an account has no use for a token
+ALTER TABLE pool_participants ALTER COLUMN token DROP NOT NULL;
+
+-- an account has a single participant per pool, so any that were linked more than once are merged
+WITH keep AS (
+    SELECT pool_id, user_id, MIN(id) AS id
+    FROM pool_participants
+    WHERE user_id IS NOT NULL
+    GROUP BY pool_id, user_id
+)
+UPDATE pool_squares ps
+SET participant_id = keep.id
+FROM pool_participants pp,
+     keep
+WHERE ps.participant_id = pp.id
+  AND pp.pool_id = keep.pool_id
+  AND pp.user_id = keep.user_id
+  AND pp.id <> keep.id;
+
+WITH keep AS (
+    SELECT pool_id, user_id, MIN(id) AS id
+    FROM pool_participants
+    WHERE user_id IS NOT NULL
+    GROUP BY pool_id, user_id
+)
+DELETE
+FROM pool_participants pp
+    USING keep
+WHERE pp.pool_id = keep.pool_id
+  AND pp.user_id = keep.user_id
+  AND pp.id <> keep.id;
+
+CREATE UNIQUE INDEX pool_participants_user_id_idx ON pool_participants (pool_id, user_id) WHERE user_id IS NOT NULL;
+
+-- every claimed square belongs to a participant. a member's squares belong to the participant linked to their account,
+-- and the rest belong to the participant with the same name as the claimant.
+INSERT INTO pool_participants (pool_id, name, user_id)
+SELECT DISTINCT ON (pool_id, user_id) pool_id, claimant, user_id
+FROM pool_squares
+WHERE user_id IS NOT NULL
+  AND participant_id IS NULL
+  AND claimant IS NOT NULL
+  AND state <> 'unclaimed'
+ORDER BY pool_id, user_id, modified DESC
+ON CONFLICT (pool_id, user_id) WHERE user_id IS NOT NULL DO NOTHING;
+
+UPDATE pool_squares ps
+SET participant_id = pp.id
+FROM pool_participants pp
+WHERE ps.participant_id IS NULL
+  AND ps.user_id IS NOT NULL
+  AND ps.state <> 'unclaimed'
+  AND pp.pool_id = ps.pool_id
+  AND pp.user_id = ps.user_id;
+
+INSERT INTO pool_participants (pool_id, name)
+SELECT DISTINCT ps.pool_id, ps.claimant
+FROM pool_squares ps
+WHERE ps.user_id IS NULL
+  AND ps.participant_id IS NULL
+  AND ps.claimant IS NOT NULL
+  AND ps.state <> 'unclaimed'
+  AND NOT EXISTS(SELECT 1
+                 FROM pool_participants pp
+                 WHERE pp.pool_id = ps.pool_id
+                   AND pp.user_id IS NULL
+                   AND pp.name = ps.claimant);
+
+UPDATE pool_squares ps
+SET participant_id = pp.id
+FROM pool_participants pp
+WHERE ps.participant_id IS NULL
+  AND ps.user_id IS NULL
+  AND ps.state <> 'unclaimed'
+  AND pp.pool_id = ps.pool_id
+  AND pp.user_id IS NULL
+  AND pp.name = ps.claimant;
+
+COMMIT;
@@ -0,0 +1,41 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+BEGIN;
+
+CREATE TYPE payment_methods AS ENUM ('cash', 'check', 'venmo', 'paypal', 'zelle', 'other');
+
+-- the payment ledger of a pool. a payment is made by a participant and may be for one of their squares. a payment is
+-- never removed, but voided instead.
+CREATE TABLE pool_payments
+(
+    id             bigserial       not null primary key,
+    pool_id        bigint          not null references pools (id),
+    participant_id bigint          references pool_participants (id) on delete set null,
+    pool_square_id bigint          references pool_squares (id),
+    amount         bigint          not null check (amount > 0),
+    method         payment_methods not null,
+    note           text            not null default '',
+    recorded_by    bigint          not null references users (id),
+    voided         timestamp,
+    voided_by      bigint          references users (id),
+    created        timestamp       not null default (now() at time zone 'utc')
+);
+
+CREATE INDEX pool_payments_pool_id_idx ON pool_payments (pool_id, id);
+CREATE INDEX pool_payments_participant_id_idx ON pool_payments (participant_id);
+
+COMMIT;
@@ -0,0 +1,24 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+BEGIN;
+
+-- the audit log can be filtered by event and by the admin who caused it
+CREATE INDEX pool_audit_events_event_idx ON pool_audit_events (pool_id, event, id);
+CREATE INDEX pool_audit_events_user_id_idx ON pool_audit_events (pool_id, user_id, id);
+CREATE INDEX pool_audit_events_created_idx ON pool_audit_events (pool_id, created);
+
+COMMIT;
@@ -0,0 +1,29 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+-- the square logs can be searched by claimant and note text
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- the square logs can be filtered by square, user, state and date, and are paged through by id
+CREATE INDEX pool_squares_logs_pool_square_id_id_idx ON pool_squares_logs (pool_square_id, id);
+CREATE INDEX pool_squares_logs_user_id_idx ON pool_squares_logs (user_id, id);
+CREATE INDEX pool_squares_logs_state_idx ON pool_squares_logs (state, id);
+CREATE INDEX pool_squares_logs_created_idx ON pool_squares_logs (created);
+CREATE INDEX pool_squares_logs_claimant_trgm_idx ON pool_squares_logs USING gin (claimant gin_trgm_ops);
+CREATE INDEX pool_squares_logs_note_trgm_idx ON pool_squares_logs USING gin (note gin_trgm_ops);
+
+COMMIT;
@@ -0,0 +1,33 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+DROP TRIGGER grid_payouts_grid_version ON grid_payouts;
+DROP TRIGGER grid_scores_grid_version ON grid_scores;
+DROP TRIGGER grid_annotations_grid_version ON grid_annotations;
+DROP TRIGGER grid_settings_grid_version ON grid_settings;
+DROP FUNCTION bump_grid_version();
+
+DROP TRIGGER pool_squares_version ON pool_squares;
+DROP TRIGGER grids_version ON grids;
+DROP TRIGGER pools_version ON pools;
+DROP FUNCTION bump_version();
+
+ALTER TABLE pool_squares DROP COLUMN version;
+ALTER TABLE grids DROP COLUMN version;
+ALTER TABLE pools DROP COLUMN version;
+
+COMMIT;
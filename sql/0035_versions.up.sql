@@ -0,0 +1,59 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+-- pools, grids and squares have a version that goes up every time they change, so that a client can tell whether
+-- someone else changed one since it was read
+ALTER TABLE pools ADD COLUMN version bigint not null default 1;
+ALTER TABLE grids ADD COLUMN version bigint not null default 1;
+ALTER TABLE pool_squares ADD COLUMN version bigint not null default 1;
+
+CREATE FUNCTION bump_version() RETURNS trigger
+    LANGUAGE plpgsql
+AS
+$$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER pools_version BEFORE UPDATE ON pools FOR EACH ROW EXECUTE PROCEDURE bump_version();
+CREATE TRIGGER grids_version BEFORE UPDATE ON grids FOR EACH ROW EXECUTE PROCEDURE bump_version();
+CREATE TRIGGER pool_squares_version BEFORE UPDATE ON pool_squares FOR EACH ROW EXECUTE PROCEDURE bump_version();
+
+-- the settings, annotations, scores and payouts of a grid are part of the grid, so changing them changes its version
+CREATE FUNCTION bump_grid_version() RETURNS trigger
+    LANGUAGE plpgsql
+AS
+$$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE grids SET version = version + 1 WHERE id = OLD.grid_id;
+    ELSE
+        UPDATE grids SET version = version + 1 WHERE id = NEW.grid_id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER grid_settings_grid_version AFTER INSERT OR UPDATE OR DELETE ON grid_settings FOR EACH ROW EXECUTE PROCEDURE bump_grid_version();
+CREATE TRIGGER grid_annotations_grid_version AFTER INSERT OR UPDATE OR DELETE ON grid_annotations FOR EACH ROW EXECUTE PROCEDURE bump_grid_version();
+CREATE TRIGGER grid_scores_grid_version AFTER INSERT OR UPDATE OR DELETE ON grid_scores FOR EACH ROW EXECUTE PROCEDURE bump_grid_version();
+CREATE TRIGGER grid_payouts_grid_version AFTER INSERT OR UPDATE OR DELETE ON grid_payouts FOR EACH ROW EXECUTE PROCEDURE bump_grid_version();
+
+COMMIT;
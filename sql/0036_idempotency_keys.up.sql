@@ -0,0 +1,34 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+-- a client can send an Idempotency-Key with a request that it may retry. the response is kept so that a retry gets
+-- the same response instead of doing the same thing twice. status_code is NULL while the request is being handled.
+CREATE TABLE idempotency_keys
+(
+    user_id      bigint    not null references users (id),
+    key          text      not null,
+    request_hash bytea     not null,
+    status_code  int,
+    content_type text,
+    response     bytea,
+    created      timestamp not null default (now() at time zone 'utc'),
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idempotency_keys_created_idx ON idempotency_keys (created);
+
+COMMIT;
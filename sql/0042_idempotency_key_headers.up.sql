@@ -0,0 +1,28 @@
+-- Copyright 2026 Tom Peters
+-- 
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+-- 
+--    http://www.apache.org/licenses/LICENSE-2.0
+-- 
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+BEGIN;
+
+-- a replayed response needs more than its content type, e.g. the ETag of what was created and where it is, so the
+-- headers that are replayed are kept together
+ALTER TABLE idempotency_keys ADD COLUMN headers jsonb;
+
+UPDATE idempotency_keys
+SET headers = jsonb_build_object('Content-Type', jsonb_build_array(content_type))
+WHERE content_type IS NOT NULL;
+
+ALTER TABLE idempotency_keys DROP COLUMN content_type;
+
+COMMIT;